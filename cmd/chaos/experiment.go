@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Experiment is one chaos experiment file. See experiments/ for examples.
+type Experiment struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	// Prometheus is the base URL for promql probes; --prometheus overrides it.
+	Prometheus string `yaml:"prometheus"`
+
+	SteadyState SteadyState `yaml:"steady_state"`
+	// Method lists the faults injected for Duration.
+	Method   []Action `yaml:"method"`
+	Duration Duration `yaml:"duration"`
+	Rollback Rollback `yaml:"rollback"`
+}
+
+// SteadyState is the hypothesis: every probe passes before the faults, at
+// every Interval while they are active, and after rollback and Recovery.
+type SteadyState struct {
+	Probes   []Probe  `yaml:"probes"`
+	Interval Duration `yaml:"interval"`
+	// Recovery is how long to wait after rollback before the final check.
+	Recovery Duration `yaml:"recovery"`
+}
+
+// Probe is a PromQL or HTTP check; exactly one of them is set.
+type Probe struct {
+	Name     string     `yaml:"name"`
+	PromQL   *PromProbe `yaml:"promql"`
+	HTTP     *HTTPProbe `yaml:"http"`
+	Phases   []string   `yaml:"phases"`
+	phaseSet map[string]bool
+}
+
+// PromProbe evaluates an instant query; the single result must lie within
+// [Min, Max].
+type PromProbe struct {
+	Query string   `yaml:"query"`
+	Min   *float64 `yaml:"min"`
+	Max   *float64 `yaml:"max"`
+	// AllowEmpty passes a query that returns no series, e.g. an error rate
+	// before any error has been counted.
+	AllowEmpty bool `yaml:"allow_empty"`
+}
+
+// HTTPProbe expects Status (default 200) and, if set, a body matching
+// BodyRegexp.
+type HTTPProbe struct {
+	URL        string   `yaml:"url"`
+	Method     string   `yaml:"method"`
+	Status     int      `yaml:"status"`
+	BodyRegexp string   `yaml:"body_regexp"`
+	Timeout    Duration `yaml:"timeout"`
+	bodyRE     *regexp.Regexp
+}
+
+// Action sets one fault through a service's chaos API.
+type Action struct {
+	Name string `yaml:"name"`
+	// Service is the base URL of the service's admin port, e.g.
+	// http://localhost:8082.
+	Service string `yaml:"service"`
+	Fault   Fault  `yaml:"fault"`
+}
+
+// Fault mirrors chaos.Fault.
+type Fault struct {
+	Target          string            `yaml:"target"`
+	Method          string            `yaml:"method"`
+	Metadata        map[string]string `yaml:"metadata"`
+	Probability     float64           `yaml:"probability"`
+	LatencyMS       int64             `yaml:"latency_ms"`
+	LatencyJitterMS int64             `yaml:"latency_jitter_ms"`
+	LatencyModel    *LatencyModel     `yaml:"latency_model"`
+	LatencySchedule *LatencySchedule  `yaml:"latency_schedule"`
+	Seed            int64             `yaml:"seed"`
+	Error           string            `yaml:"error"`
+}
+
+// LatencyModel mirrors chaos.LatencyModel.
+type LatencyModel struct {
+	Distribution string  `yaml:"distribution"`
+	StdDevMS     int64   `yaml:"stddev_ms"`
+	Sigma        float64 `yaml:"sigma"`
+	Alpha        float64 `yaml:"alpha"`
+	SlowMS       int64   `yaml:"slow_ms"`
+	SlowFraction float64 `yaml:"slow_fraction"`
+	MaxMS        int64   `yaml:"max_ms"`
+}
+
+// LatencySchedule mirrors chaos.Schedule.
+type LatencySchedule struct {
+	Ramp     Duration `yaml:"ramp"`
+	Windows  []Window `yaml:"windows"`
+	TimeZone string   `yaml:"time_zone"`
+}
+
+// Window is a daily "HH:MM" range.
+type Window struct {
+	Start string  `yaml:"start"`
+	End   string  `yaml:"end"`
+	Scale float64 `yaml:"scale"`
+}
+
+// Rollback always clears the faults the experiment set; ClearAll also clears
+// faults set by anyone else on the same services.
+type Rollback struct {
+	ClearAll bool `yaml:"clear_all"`
+}
+
+// Duration accepts Go duration strings ("30s", "2m") in YAML.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(n *yaml.Node) error {
+	v, err := time.ParseDuration(n.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", n.Line, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+const (
+	phaseBefore = "before"
+	phaseDuring = "during"
+	phaseAfter  = "after"
+)
+
+// LoadExperiment reads and validates an experiment file.
+func LoadExperiment(path string) (*Experiment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	exp, err := ParseExperiment(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return exp, nil
+}
+
+// ParseExperiment decodes an experiment, rejecting unknown keys, and fills
+// in defaults.
+func ParseExperiment(data []byte) (*Experiment, error) {
+	var exp Experiment
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&exp); err != nil {
+		return nil, err
+	}
+	if err := exp.validate(); err != nil {
+		return nil, err
+	}
+	return &exp, nil
+}
+
+func (e *Experiment) validate() error {
+	var errs []error
+	if e.Title == "" {
+		errs = append(errs, errors.New("title is required"))
+	}
+	if len(e.SteadyState.Probes) == 0 {
+		errs = append(errs, errors.New("steady_state.probes: at least one probe is required"))
+	}
+	if e.Duration <= 0 {
+		errs = append(errs, errors.New("duration must be positive"))
+	}
+	if e.SteadyState.Interval <= 0 {
+		e.SteadyState.Interval = Duration(10 * time.Second)
+	}
+	for i := range e.SteadyState.Probes {
+		p := &e.SteadyState.Probes[i]
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("probe %d", i+1)
+		}
+		if (p.PromQL == nil) == (p.HTTP == nil) {
+			errs = append(errs, fmt.Errorf("%s: set exactly one of promql and http", p.Name))
+			continue
+		}
+		if len(p.Phases) == 0 {
+			p.Phases = []string{phaseBefore, phaseDuring, phaseAfter}
+		}
+		p.phaseSet = make(map[string]bool)
+		for _, ph := range p.Phases {
+			if ph != phaseBefore && ph != phaseDuring && ph != phaseAfter {
+				errs = append(errs, fmt.Errorf("%s: unknown phase %q", p.Name, ph))
+			}
+			p.phaseSet[ph] = true
+		}
+		if q := p.PromQL; q != nil {
+			if q.Query == "" || (q.Min == nil && q.Max == nil) {
+				errs = append(errs, fmt.Errorf("%s: promql needs a query and a min or max", p.Name))
+			}
+		}
+		if h := p.HTTP; h != nil {
+			if h.URL == "" {
+				errs = append(errs, fmt.Errorf("%s: http.url is required", p.Name))
+			}
+			if h.Status == 0 {
+				h.Status = 200
+			}
+			if h.Method == "" {
+				h.Method = "GET"
+			}
+			if h.Timeout <= 0 {
+				h.Timeout = Duration(5 * time.Second)
+			}
+			if h.BodyRegexp != "" {
+				re, err := regexp.Compile(h.BodyRegexp)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: body_regexp: %w", p.Name, err))
+				}
+				h.bodyRE = re
+			}
+		}
+	}
+	for i := range e.Method {
+		a := &e.Method[i]
+		if a.Name == "" {
+			a.Name = fmt.Sprintf("action %d", i+1)
+		}
+		if a.Service == "" || a.Fault.Target == "" {
+			errs = append(errs, fmt.Errorf("%s: service and fault.target are required", a.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
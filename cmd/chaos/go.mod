@@ -0,0 +1,14 @@
+module github.com/reliability-lab/cmd/chaos
+
+go 1.22
+
+require (
+	github.com/reliability-lab/gen v0.0.0
+	github.com/reliability-lab/pkg/platform v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+	google.golang.org/protobuf v1.32.0
+)
+
+replace github.com/reliability-lab/gen => ../../gen
+
+replace github.com/reliability-lab/pkg/platform => ../../pkg/platform
@@ -0,0 +1,99 @@
+// Command chaos runs chaos experiments against the services' chaos API:
+//
+//	chaos run [--prometheus URL] [--report out.json] [--token T] experiments/payments-declines.yaml
+//	chaos validate experiments/*.yaml
+//
+// The chaos API accepts only operator tokens from the services' AUTH_TOKENS;
+// --token defaults to $CHAOS_TOKEN.
+//
+// It exits 0 when the steady-state hypothesis held, 1 when it did not and 2
+// when the experiment was aborted or could not be loaded.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: chaos run [--prometheus URL] [--report out.json] [--token T] experiment.yaml\n       chaos validate experiment.yaml...")
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	switch args[0] {
+	case "run":
+		return runCmd(args[1:])
+	case "validate":
+		return validateCmd(args[1:])
+	default:
+		usage()
+		return 2
+	}
+}
+
+func runCmd(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	prom := fs.String("prometheus", "", "Prometheus base URL; overrides the experiment's prometheus")
+	report := fs.String("report", "", "also write the report as JSON to this file")
+	timeout := fs.Duration("http-timeout", 10*time.Second, "timeout of chaos API and Prometheus requests")
+	token := fs.String("token", os.Getenv("CHAOS_TOKEN"), "operator bearer token for the chaos API")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		usage()
+		return 2
+	}
+	exp, err := LoadExperiment(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	r := &Runner{Prometheus: *prom, Client: &http.Client{Timeout: *timeout}, Log: os.Stdout, Token: *token}
+	rep := r.Run(ctx, exp)
+	rep.WriteText(os.Stdout)
+	if *report != "" {
+		if err := rep.WriteJSON(*report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	switch rep.Status {
+	case StatusPassed:
+		return 0
+	case StatusFailed:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func validateCmd(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	rc := 0
+	for _, path := range args {
+		if _, err := LoadExperiment(path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			rc = 2
+			continue
+		}
+		fmt.Println(path + ": ok")
+	}
+	return rc
+}
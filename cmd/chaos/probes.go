@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ProbeResult is one evaluation of a probe.
+type ProbeResult struct {
+	Probe  string    `json:"probe"`
+	Phase  string    `json:"phase"`
+	At     time.Time `json:"at"`
+	OK     bool      `json:"ok"`
+	Detail string    `json:"detail"`
+}
+
+func (r *Runner) check(ctx context.Context, p *Probe, phase string) ProbeResult {
+	res := ProbeResult{Probe: p.Name, Phase: phase, At: time.Now()}
+	var err error
+	if p.PromQL != nil {
+		res.Detail, err = r.checkPromQL(ctx, p.PromQL)
+	} else {
+		res.Detail, err = r.checkHTTP(ctx, p.HTTP)
+	}
+	if err != nil {
+		res.Detail = err.Error()
+		return res
+	}
+	res.OK = true
+	return res
+}
+
+// promResponse is the subset of /api/v1/query responses the probes read.
+type promResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+func (r *Runner) checkPromQL(ctx context.Context, q *PromProbe) (string, error) {
+	if r.Prometheus == "" {
+		return "", fmt.Errorf("no prometheus URL: set prometheus in the experiment or pass --prometheus")
+	}
+	u := r.Prometheus + "/api/v1/query?" + url.Values{"query": {q.Query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var pr promResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&pr); err != nil {
+		return "", fmt.Errorf("prometheus: %s: %w", resp.Status, err)
+	}
+	if pr.Status != "success" {
+		return "", fmt.Errorf("prometheus: %s: %s", pr.ErrorType, pr.Error)
+	}
+
+	values, err := promValues(pr.Data.ResultType, pr.Data.Result)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case len(values) == 0 && q.AllowEmpty:
+		return "no series", nil
+	case len(values) == 0:
+		return "", fmt.Errorf("query returned no series")
+	case len(values) > 1:
+		return "", fmt.Errorf("query returned %d series, want 1; aggregate it", len(values))
+	}
+	v := values[0]
+	if q.Min != nil && v < *q.Min {
+		return "", fmt.Errorf("value %g below min %g", v, *q.Min)
+	}
+	if q.Max != nil && v > *q.Max {
+		return "", fmt.Errorf("value %g above max %g", v, *q.Max)
+	}
+	return fmt.Sprintf("value %g", v), nil
+}
+
+// promValues extracts the sample values of a vector or scalar result.
+func promValues(resultType string, raw json.RawMessage) ([]float64, error) {
+	var samples [][2]any
+	switch resultType {
+	case "vector":
+		var vec []struct {
+			Value [2]any `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &vec); err != nil {
+			return nil, err
+		}
+		for _, s := range vec {
+			samples = append(samples, s.Value)
+		}
+	case "scalar":
+		var s [2]any
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		samples = append(samples, s)
+	default:
+		return nil, fmt.Errorf("unsupported result type %q; use an instant vector or scalar", resultType)
+	}
+	values := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		str, _ := s[1].(string)
+		v, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, fmt.Errorf("sample value %v: %w", s[1], err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func (r *Runner) checkHTTP(ctx context.Context, h *HTTPProbe) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.Timeout))
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, h.Method, h.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != h.Status {
+		return "", fmt.Errorf("status %d, want %d", resp.StatusCode, h.Status)
+	}
+	if h.bodyRE != nil && !h.bodyRE.Match(body) {
+		return "", fmt.Errorf("body does not match %q", h.BodyRegexp)
+	}
+	return fmt.Sprintf("status %d", resp.StatusCode), nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Experiment outcomes.
+const (
+	StatusPassed = "passed"
+	// StatusFailed means the hypothesis did not hold or rollback failed.
+	StatusFailed = "failed"
+	// StatusAborted means no conclusion: the steady state did not hold to
+	// begin with, a fault could not be injected or the run was interrupted.
+	StatusAborted = "aborted"
+)
+
+// Report is the outcome of one run, written as text and optionally JSON.
+type Report struct {
+	Title          string         `json:"title"`
+	Status         string         `json:"status"`
+	Reason         string         `json:"reason,omitempty"`
+	Started        time.Time      `json:"started"`
+	Finished       time.Time      `json:"finished"`
+	Actions        []ActionResult `json:"actions"`
+	Probes         []ProbeResult  `json:"probes"`
+	RollbackErrors []string       `json:"rollback_errors,omitempty"`
+}
+
+// fail marks a run that was passing as failed; the first reason wins.
+func (r *Report) fail(reason string) {
+	if r.Status == StatusPassed {
+		r.Status, r.Reason = StatusFailed, reason
+	}
+}
+
+func (r *Report) abort(reason string) {
+	if r.Status == StatusPassed {
+		r.Status, r.Reason = StatusAborted, reason
+	}
+}
+
+// WriteText prints a summary: the status, a pass count per probe and phase,
+// and the failed checks.
+func (r *Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "\n%s: %s", r.Title, r.Status)
+	if r.Reason != "" {
+		fmt.Fprintf(w, " (%s)", r.Reason)
+	}
+	fmt.Fprintf(w, " in %s\n", r.Finished.Sub(r.Started).Round(time.Millisecond))
+
+	type key struct{ probe, phase string }
+	var order []key
+	passed, total := make(map[key]int), make(map[key]int)
+	for _, p := range r.Probes {
+		k := key{p.Probe, p.Phase}
+		if total[k] == 0 {
+			order = append(order, k)
+		}
+		total[k]++
+		if p.OK {
+			passed[k]++
+		}
+	}
+	for _, k := range order {
+		fmt.Fprintf(w, "  %-6s %-40s %d/%d\n", k.phase, k.probe, passed[k], total[k])
+	}
+	for _, p := range r.Probes {
+		if !p.OK {
+			fmt.Fprintf(w, "  FAIL %s %s at %s: %s\n", p.Phase, p.Probe, p.At.Format(time.TimeOnly), p.Detail)
+		}
+	}
+	for _, e := range r.RollbackErrors {
+		fmt.Fprintf(w, "  rollback: %s\n", e)
+	}
+}
+
+// WriteJSON writes the full report to path.
+func (r *Report) WriteJSON(path string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	chaospb "github.com/reliability-lab/gen/chaos"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ttlMargin keeps injected faults alive past the experiment's duration so
+// they do not expire before the last during probe, yet still go away on
+// their own if the runner dies before rollback.
+const ttlMargin = time.Minute
+
+// Runner executes experiments.
+type Runner struct {
+	// Prometheus is the base URL for promql probes.
+	Prometheus string
+	// Client is used for probes and the chaos API; http.DefaultClient if nil.
+	Client *http.Client
+	// Log receives progress lines; nil discards them.
+	Log io.Writer
+	// Token is sent as "Authorization: Bearer <token>" to the chaos API,
+	// which accepts only operator principals.
+	Token string
+}
+
+func (r *Runner) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return http.DefaultClient
+}
+
+func (r *Runner) logf(format string, args ...any) {
+	if r.Log != nil {
+		fmt.Fprintf(r.Log, format+"\n", args...)
+	}
+}
+
+// Run checks the steady state, injects the method's faults for the
+// experiment's duration while probing, rolls back and checks again. Rollback
+// runs even when ctx is cancelled.
+func (r *Runner) Run(ctx context.Context, exp *Experiment) *Report {
+	if r.Prometheus == "" {
+		r.Prometheus = exp.Prometheus
+	}
+	r.Prometheus = strings.TrimRight(r.Prometheus, "/")
+	rep := &Report{Title: exp.Title, Started: time.Now(), Status: StatusPassed}
+	defer func() { rep.Finished = time.Now() }()
+
+	r.logf("experiment %q", exp.Title)
+	if !r.probe(ctx, exp, phaseBefore, rep) {
+		rep.abort("steady state not met before injecting faults")
+		return rep
+	}
+
+	defer r.rollback(exp, rep)
+	for i := range exp.Method {
+		res := r.inject(ctx, exp, i)
+		rep.Actions = append(rep.Actions, res)
+		if res.Error != "" {
+			rep.abort(fmt.Sprintf("action %s: %s", res.Name, res.Error))
+			return rep
+		}
+		r.logf("  injected %s: fault %s on %s", res.Name, res.FaultID, res.Service)
+	}
+
+	end := time.Now().Add(time.Duration(exp.Duration))
+	tick := time.NewTicker(time.Duration(exp.SteadyState.Interval))
+	defer tick.Stop()
+	for {
+		if !r.probe(ctx, exp, phaseDuring, rep) {
+			rep.fail("steady state deviated while faults were active")
+			return rep
+		}
+		wait := time.Until(end)
+		if wait <= 0 {
+			break
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			rep.abort("interrupted: " + ctx.Err().Error())
+			return rep
+		case <-tick.C:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+	return rep
+}
+
+// rollback clears the experiment's faults with a fresh context, waits for
+// recovery and runs the after probes, unless the run was aborted.
+func (r *Runner) rollback(exp *Experiment, rep *Report) {
+	r.clearFaults(exp, rep)
+	if rep.Status == StatusAborted {
+		return
+	}
+	if rec := time.Duration(exp.SteadyState.Recovery); rec > 0 {
+		r.logf("  waiting %s for recovery", rec)
+		time.Sleep(rec)
+	}
+	if !r.probe(context.Background(), exp, phaseAfter, rep) {
+		rep.fail("steady state not restored after rollback")
+	}
+}
+
+func (r *Runner) clearFaults(exp *Experiment, rep *Report) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	r.logf("  rolling back")
+	if exp.Rollback.ClearAll {
+		seen := make(map[string]bool)
+		for _, a := range exp.Method {
+			if seen[a.Service] {
+				continue
+			}
+			seen[a.Service] = true
+			if err := r.call(ctx, http.MethodDelete, a.Service, "", nil, exp.Title, nil); err != nil {
+				rep.RollbackErrors = append(rep.RollbackErrors, fmt.Sprintf("%s: %v", a.Service, err))
+			}
+		}
+	} else {
+		for _, a := range rep.Actions {
+			if a.FaultID == "" {
+				continue
+			}
+			if err := r.call(ctx, http.MethodDelete, a.Service, a.FaultID, nil, exp.Title, nil); err != nil {
+				rep.RollbackErrors = append(rep.RollbackErrors, fmt.Sprintf("%s fault %s: %v", a.Service, a.FaultID, err))
+			}
+		}
+	}
+	if len(rep.RollbackErrors) > 0 {
+		rep.fail("rollback failed; the faults expire when their TTL runs out")
+	}
+}
+
+// probe runs the probes of phase and reports whether all passed.
+func (r *Runner) probe(ctx context.Context, exp *Experiment, phase string, rep *Report) bool {
+	ok := true
+	for i := range exp.SteadyState.Probes {
+		p := &exp.SteadyState.Probes[i]
+		if !p.phaseSet[phase] {
+			continue
+		}
+		res := r.check(ctx, p, phase)
+		rep.Probes = append(rep.Probes, res)
+		mark := "ok"
+		if !res.OK {
+			mark, ok = "FAIL", false
+		}
+		r.logf("  [%s] %-4s %s: %s", phase, mark, res.Probe, res.Detail)
+	}
+	return ok
+}
+
+// ActionResult records one injected fault.
+type ActionResult struct {
+	Name    string `json:"name"`
+	Service string `json:"service"`
+	FaultID string `json:"fault_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+func (r *Runner) inject(ctx context.Context, exp *Experiment, i int) ActionResult {
+	a := exp.Method[i]
+	res := ActionResult{Name: a.Name, Service: a.Service}
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(exp.Title), "-"), "-")
+	req := &chaospb.SetFaultRequest{
+		Fault: &chaospb.Fault{
+			Id:              fmt.Sprintf("exp-%s-%d", slug, i+1),
+			Target:          a.Fault.Target,
+			Method:          a.Fault.Method,
+			Metadata:        a.Fault.Metadata,
+			Probability:     a.Fault.Probability,
+			LatencyMs:       a.Fault.LatencyMS,
+			LatencyJitterMs: a.Fault.LatencyJitterMS,
+			Seed:            a.Fault.Seed,
+			Error:           a.Fault.Error,
+			Reason:          fmt.Sprintf("experiment %q: %s", exp.Title, a.Name),
+		},
+		Ttl: durationpb.New(time.Duration(exp.Duration) + ttlMargin),
+	}
+	if m := a.Fault.LatencyModel; m != nil {
+		req.Fault.LatencyModel = &chaospb.LatencyModel{
+			Distribution: m.Distribution,
+			StddevMs:     m.StdDevMS,
+			Sigma:        m.Sigma,
+			Alpha:        m.Alpha,
+			SlowMs:       m.SlowMS,
+			SlowFraction: m.SlowFraction,
+			MaxMs:        m.MaxMS,
+		}
+	}
+	if sc := a.Fault.LatencySchedule; sc != nil {
+		ps := &chaospb.LatencySchedule{TimeZone: sc.TimeZone}
+		if sc.Ramp > 0 {
+			ps.Ramp = durationpb.New(time.Duration(sc.Ramp))
+		}
+		for _, w := range sc.Windows {
+			ps.Windows = append(ps.Windows, &chaospb.TimeWindow{Start: w.Start, End: w.End, Scale: w.Scale})
+		}
+		req.Fault.LatencySchedule = ps
+	}
+	body, err := protojson.Marshal(req)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	var resp chaospb.SetFaultResponse
+	if err := r.call(ctx, http.MethodPost, a.Service, "", body, exp.Title, &resp); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.FaultID = resp.GetFault().GetId()
+	return res
+}
+
+// call sends one request to a service's /chaos/faults endpoint.
+func (r *Runner) call(ctx context.Context, method, service, id string, body []byte, actor string, out *chaospb.SetFaultResponse) error {
+	u := strings.TrimRight(service, "/") + "/chaos/faults"
+	if id != "" {
+		u += "/" + id
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(chaos.ActorHeader, "chaos-cli:"+actor)
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(b, &e) == nil && e.Error != "" {
+			return fmt.Errorf("%s: %s", resp.Status, e.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	if out != nil {
+		return protojson.Unmarshal(b, out)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/reliability-lab/pkg/platform/chaos"
+)
+
+// fakeServices serves an in-process payments chaos API and a fake
+// Prometheus whose error rate is 0.5 while any fault is active on payments.
+type fakeServices struct {
+	reg      *chaos.Registry
+	payments *httptest.Server
+	prom     *httptest.Server
+	// healthy is what /readyz on the payments server reports.
+	healthy func() bool
+	// injected is set by the first SetFault call.
+	injected atomic.Bool
+}
+
+// testToken is the operator token the fake chaos API accepts.
+const testToken = "op-token"
+
+func startFakes(t *testing.T) *fakeServices {
+	t.Helper()
+	f := &fakeServices{reg: chaos.NewRegistry("payments"), healthy: func() bool { return true }}
+	f.reg.RegisterTarget(chaos.Target{Name: "payments.charge", Errors: []string{"DECLINED"}})
+
+	mux := http.NewServeMux()
+	api := chaos.NewServer(f.reg, chaos.WithAuthorize(func(authorization string) (string, bool) {
+		return "operator:ci", authorization == "Bearer "+testToken
+	}))
+	track := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			f.injected.Store(true)
+		}
+		api.ServeHTTP(w, r)
+	})
+	mux.Handle("/chaos/faults", track)
+	mux.Handle("/chaos/faults/", track)
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !f.healthy() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+	f.payments = httptest.NewServer(mux)
+	t.Cleanup(f.payments.Close)
+
+	f.prom = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" || r.URL.Query().Get("query") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		rate := "0"
+		if len(f.reg.List()) > 0 {
+			rate = "0.5"
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"%s"]}]}}`, rate)
+	}))
+	t.Cleanup(f.prom.Close)
+	return f
+}
+
+func (f *fakeServices) experiment(t *testing.T, maxErrorRate string, extra string) *Experiment {
+	t.Helper()
+	exp, err := ParseExperiment([]byte(fmt.Sprintf(`
+title: Payments decline half the charges
+prometheus: %s
+duration: 60ms
+steady_state:
+  interval: 20ms
+  probes:
+    - name: error rate
+      promql:
+        query: sum(rate(http_requests_total{status=~"5.."}[1m]))
+        max: %s
+    - name: payments ready
+      http:
+        url: %s/readyz
+        body_regexp: ok
+method:
+  - name: decline
+    service: %s
+    fault:
+      target: payments.charge
+      error: DECLINED
+      probability: 0.5
+%s`, f.prom.URL, maxErrorRate, f.payments.URL, f.payments.URL, extra)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exp
+}
+
+func TestRun_Passes(t *testing.T) {
+	f := startFakes(t)
+	rep := (&Runner{Token: testToken}).Run(context.Background(), f.experiment(t, "0.6", ""))
+	if rep.Status != StatusPassed {
+		t.Fatalf("status %s (%s), want passed", rep.Status, rep.Reason)
+	}
+	phases := map[string]int{}
+	for _, p := range rep.Probes {
+		phases[p.Phase]++
+	}
+	if phases[phaseBefore] != 2 || phases[phaseDuring] < 2 || phases[phaseAfter] != 2 {
+		t.Errorf("probe runs per phase: %v", phases)
+	}
+	if len(rep.Actions) != 1 || rep.Actions[0].FaultID != "exp-payments-decline-half-the-charges-1" {
+		t.Errorf("actions: %+v", rep.Actions)
+	}
+	if n := len(f.reg.List()); n != 0 {
+		t.Errorf("%d faults left after rollback", n)
+	}
+}
+
+func TestRun_FailsAndRollsBack(t *testing.T) {
+	f := startFakes(t)
+	// A fault left behind by someone else breaks the steady state up front.
+	if _, err := f.reg.Set(context.Background(), chaos.Fault{Target: "payments.charge", Latency: 1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	rep := (&Runner{Token: testToken}).Run(context.Background(), f.experiment(t, "0.01", ""))
+	if rep.Status != StatusAborted {
+		t.Fatalf("status %s, want aborted while the other fault is active", rep.Status)
+	}
+	f.reg.Clear(context.Background(), "")
+
+	rep = (&Runner{Token: testToken}).Run(context.Background(), f.experiment(t, "0.01", "rollback:\n  clear_all: true"))
+	if rep.Status != StatusFailed || !strings.Contains(rep.Reason, "deviated") {
+		t.Fatalf("status %s (%s), want failed during", rep.Status, rep.Reason)
+	}
+	if n := len(f.reg.List()); n != 0 {
+		t.Errorf("%d faults left after rollback", n)
+	}
+	// The after phase still runs and the system recovered.
+	last := rep.Probes[len(rep.Probes)-1]
+	if last.Phase != phaseAfter || !last.OK {
+		t.Errorf("last probe %+v, want a passing after probe", last)
+	}
+}
+
+func TestRun_AbortsWithoutInjecting(t *testing.T) {
+	f := startFakes(t)
+	f.healthy = func() bool { return false }
+	rep := (&Runner{Token: testToken}).Run(context.Background(), f.experiment(t, "0.6", ""))
+	if rep.Status != StatusAborted || len(rep.Actions) != 0 {
+		t.Fatalf("status %s, actions %+v: want aborted before any action", rep.Status, rep.Actions)
+	}
+}
+
+func TestRun_AfterProbeFails(t *testing.T) {
+	f := startFakes(t)
+	exp := f.experiment(t, "0.6", "")
+	// Readiness is lost once the fault has been injected and never comes back.
+	f.healthy = func() bool { return !f.injected.Load() }
+	exp.SteadyState.Probes[1].Phases = []string{phaseBefore, phaseAfter}
+	exp.SteadyState.Probes[1].phaseSet = map[string]bool{phaseBefore: true, phaseAfter: true}
+	rep := (&Runner{Token: testToken}).Run(context.Background(), exp)
+	if rep.Status != StatusFailed || !strings.Contains(rep.Reason, "not restored") {
+		t.Fatalf("status %s (%s), want failed after rollback", rep.Status, rep.Reason)
+	}
+}
+
+func TestParseExperiment_Invalid(t *testing.T) {
+	_, err := ParseExperiment([]byte(`
+title: bad
+duration: 1m
+steady_state:
+  probes:
+    - name: both
+      promql: {query: up, min: 1}
+      http: {url: http://x}
+    - name: phase
+      http: {url: http://x}
+      phases: [sometime]
+method:
+  - fault: {target: payments.charge}
+`))
+	for _, want := range []string{"both: set exactly one", `unknown phase "sometime"`, "action 1: service"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %q", err, want)
+		}
+	}
+
+	_, err = ParseExperiment([]byte("title: typo\nduraton: 1m\n"))
+	if err == nil || !strings.Contains(err.Error(), "duraton") {
+		t.Errorf("got %v, want unknown key error", err)
+	}
+}
+
+func TestExamplesAreValid(t *testing.T) {
+	paths, _ := filepath.Glob("../../experiments/*.yaml")
+	if len(paths) == 0 {
+		t.Fatal("no example experiments found")
+	}
+	for _, p := range paths {
+		if _, err := LoadExperiment(p); err != nil {
+			t.Error(err)
+		}
+	}
+}
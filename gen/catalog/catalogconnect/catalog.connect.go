@@ -0,0 +1,174 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: catalog.proto
+
+package catalogconnect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	catalog "github.com/reliability-lab/gen/catalog"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// CatalogName is the fully-qualified name of the Catalog service.
+	CatalogName = "catalog.Catalog"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// CatalogListProductsProcedure is the fully-qualified name of the Catalog's ListProducts RPC.
+	CatalogListProductsProcedure = "/catalog.Catalog/ListProducts"
+	// CatalogGetProductProcedure is the fully-qualified name of the Catalog's GetProduct RPC.
+	CatalogGetProductProcedure = "/catalog.Catalog/GetProduct"
+	// CatalogGetSkusProcedure is the fully-qualified name of the Catalog's GetSkus RPC.
+	CatalogGetSkusProcedure = "/catalog.Catalog/GetSkus"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	catalogServiceDescriptor            = catalog.File_catalog_proto.Services().ByName("Catalog")
+	catalogListProductsMethodDescriptor = catalogServiceDescriptor.Methods().ByName("ListProducts")
+	catalogGetProductMethodDescriptor   = catalogServiceDescriptor.Methods().ByName("GetProduct")
+	catalogGetSkusMethodDescriptor      = catalogServiceDescriptor.Methods().ByName("GetSkus")
+)
+
+// CatalogClient is a client for the catalog.Catalog service.
+type CatalogClient interface {
+	ListProducts(context.Context, *connect.Request[catalog.ListProductsRequest]) (*connect.Response[catalog.ListProductsResponse], error)
+	GetProduct(context.Context, *connect.Request[catalog.GetProductRequest]) (*connect.Response[catalog.GetProductResponse], error)
+	// GetSkus looks up SKUs by code, in request order. It fails with NotFound
+	// naming the first unknown code. Orders prices line items through it.
+	GetSkus(context.Context, *connect.Request[catalog.GetSkusRequest]) (*connect.Response[catalog.GetSkusResponse], error)
+}
+
+// NewCatalogClient constructs a client for the catalog.Catalog service. By default, it uses the
+// Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewCatalogClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) CatalogClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &catalogClient{
+		listProducts: connect.NewClient[catalog.ListProductsRequest, catalog.ListProductsResponse](
+			httpClient,
+			baseURL+CatalogListProductsProcedure,
+			connect.WithSchema(catalogListProductsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getProduct: connect.NewClient[catalog.GetProductRequest, catalog.GetProductResponse](
+			httpClient,
+			baseURL+CatalogGetProductProcedure,
+			connect.WithSchema(catalogGetProductMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getSkus: connect.NewClient[catalog.GetSkusRequest, catalog.GetSkusResponse](
+			httpClient,
+			baseURL+CatalogGetSkusProcedure,
+			connect.WithSchema(catalogGetSkusMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// catalogClient implements CatalogClient.
+type catalogClient struct {
+	listProducts *connect.Client[catalog.ListProductsRequest, catalog.ListProductsResponse]
+	getProduct   *connect.Client[catalog.GetProductRequest, catalog.GetProductResponse]
+	getSkus      *connect.Client[catalog.GetSkusRequest, catalog.GetSkusResponse]
+}
+
+// ListProducts calls catalog.Catalog.ListProducts.
+func (c *catalogClient) ListProducts(ctx context.Context, req *connect.Request[catalog.ListProductsRequest]) (*connect.Response[catalog.ListProductsResponse], error) {
+	return c.listProducts.CallUnary(ctx, req)
+}
+
+// GetProduct calls catalog.Catalog.GetProduct.
+func (c *catalogClient) GetProduct(ctx context.Context, req *connect.Request[catalog.GetProductRequest]) (*connect.Response[catalog.GetProductResponse], error) {
+	return c.getProduct.CallUnary(ctx, req)
+}
+
+// GetSkus calls catalog.Catalog.GetSkus.
+func (c *catalogClient) GetSkus(ctx context.Context, req *connect.Request[catalog.GetSkusRequest]) (*connect.Response[catalog.GetSkusResponse], error) {
+	return c.getSkus.CallUnary(ctx, req)
+}
+
+// CatalogHandler is an implementation of the catalog.Catalog service.
+type CatalogHandler interface {
+	ListProducts(context.Context, *connect.Request[catalog.ListProductsRequest]) (*connect.Response[catalog.ListProductsResponse], error)
+	GetProduct(context.Context, *connect.Request[catalog.GetProductRequest]) (*connect.Response[catalog.GetProductResponse], error)
+	// GetSkus looks up SKUs by code, in request order. It fails with NotFound
+	// naming the first unknown code. Orders prices line items through it.
+	GetSkus(context.Context, *connect.Request[catalog.GetSkusRequest]) (*connect.Response[catalog.GetSkusResponse], error)
+}
+
+// NewCatalogHandler builds an HTTP handler from the service implementation. It returns the path on
+// which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewCatalogHandler(svc CatalogHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	catalogListProductsHandler := connect.NewUnaryHandler(
+		CatalogListProductsProcedure,
+		svc.ListProducts,
+		connect.WithSchema(catalogListProductsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	catalogGetProductHandler := connect.NewUnaryHandler(
+		CatalogGetProductProcedure,
+		svc.GetProduct,
+		connect.WithSchema(catalogGetProductMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	catalogGetSkusHandler := connect.NewUnaryHandler(
+		CatalogGetSkusProcedure,
+		svc.GetSkus,
+		connect.WithSchema(catalogGetSkusMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/catalog.Catalog/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case CatalogListProductsProcedure:
+			catalogListProductsHandler.ServeHTTP(w, r)
+		case CatalogGetProductProcedure:
+			catalogGetProductHandler.ServeHTTP(w, r)
+		case CatalogGetSkusProcedure:
+			catalogGetSkusHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedCatalogHandler returns CodeUnimplemented from all methods.
+type UnimplementedCatalogHandler struct{}
+
+func (UnimplementedCatalogHandler) ListProducts(context.Context, *connect.Request[catalog.ListProductsRequest]) (*connect.Response[catalog.ListProductsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("catalog.Catalog.ListProducts is not implemented"))
+}
+
+func (UnimplementedCatalogHandler) GetProduct(context.Context, *connect.Request[catalog.GetProductRequest]) (*connect.Response[catalog.GetProductResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("catalog.Catalog.GetProduct is not implemented"))
+}
+
+func (UnimplementedCatalogHandler) GetSkus(context.Context, *connect.Request[catalog.GetSkusRequest]) (*connect.Response[catalog.GetSkusResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("catalog.Catalog.GetSkus is not implemented"))
+}
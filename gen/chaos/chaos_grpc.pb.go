@@ -0,0 +1,187 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: chaos.proto
+
+package chaos
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Chaos_SetFault_FullMethodName    = "/chaos.Chaos/SetFault"
+	Chaos_ListFaults_FullMethodName  = "/chaos.Chaos/ListFaults"
+	Chaos_ClearFaults_FullMethodName = "/chaos.Chaos/ClearFaults"
+)
+
+// ChaosClient is the client API for Chaos service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ChaosClient interface {
+	// SetFault adds a fault, or replaces the fault with the same id.
+	SetFault(ctx context.Context, in *SetFaultRequest, opts ...grpc.CallOption) (*SetFaultResponse, error)
+	ListFaults(ctx context.Context, in *ListFaultsRequest, opts ...grpc.CallOption) (*ListFaultsResponse, error)
+	// ClearFaults removes one fault by id, or every fault when id is empty.
+	ClearFaults(ctx context.Context, in *ClearFaultsRequest, opts ...grpc.CallOption) (*ClearFaultsResponse, error)
+}
+
+type chaosClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChaosClient(cc grpc.ClientConnInterface) ChaosClient {
+	return &chaosClient{cc}
+}
+
+func (c *chaosClient) SetFault(ctx context.Context, in *SetFaultRequest, opts ...grpc.CallOption) (*SetFaultResponse, error) {
+	out := new(SetFaultResponse)
+	err := c.cc.Invoke(ctx, Chaos_SetFault_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chaosClient) ListFaults(ctx context.Context, in *ListFaultsRequest, opts ...grpc.CallOption) (*ListFaultsResponse, error) {
+	out := new(ListFaultsResponse)
+	err := c.cc.Invoke(ctx, Chaos_ListFaults_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chaosClient) ClearFaults(ctx context.Context, in *ClearFaultsRequest, opts ...grpc.CallOption) (*ClearFaultsResponse, error) {
+	out := new(ClearFaultsResponse)
+	err := c.cc.Invoke(ctx, Chaos_ClearFaults_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChaosServer is the server API for Chaos service.
+// All implementations must embed UnimplementedChaosServer
+// for forward compatibility
+type ChaosServer interface {
+	// SetFault adds a fault, or replaces the fault with the same id.
+	SetFault(context.Context, *SetFaultRequest) (*SetFaultResponse, error)
+	ListFaults(context.Context, *ListFaultsRequest) (*ListFaultsResponse, error)
+	// ClearFaults removes one fault by id, or every fault when id is empty.
+	ClearFaults(context.Context, *ClearFaultsRequest) (*ClearFaultsResponse, error)
+	mustEmbedUnimplementedChaosServer()
+}
+
+// UnimplementedChaosServer must be embedded to have forward compatible implementations.
+type UnimplementedChaosServer struct {
+}
+
+func (UnimplementedChaosServer) SetFault(context.Context, *SetFaultRequest) (*SetFaultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFault not implemented")
+}
+func (UnimplementedChaosServer) ListFaults(context.Context, *ListFaultsRequest) (*ListFaultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFaults not implemented")
+}
+func (UnimplementedChaosServer) ClearFaults(context.Context, *ClearFaultsRequest) (*ClearFaultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearFaults not implemented")
+}
+func (UnimplementedChaosServer) mustEmbedUnimplementedChaosServer() {}
+
+// UnsafeChaosServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChaosServer will
+// result in compilation errors.
+type UnsafeChaosServer interface {
+	mustEmbedUnimplementedChaosServer()
+}
+
+func RegisterChaosServer(s grpc.ServiceRegistrar, srv ChaosServer) {
+	s.RegisterService(&Chaos_ServiceDesc, srv)
+}
+
+func _Chaos_SetFault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetFaultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChaosServer).SetFault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Chaos_SetFault_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChaosServer).SetFault(ctx, req.(*SetFaultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chaos_ListFaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFaultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChaosServer).ListFaults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Chaos_ListFaults_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChaosServer).ListFaults(ctx, req.(*ListFaultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chaos_ClearFaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearFaultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChaosServer).ClearFaults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Chaos_ClearFaults_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChaosServer).ClearFaults(ctx, req.(*ClearFaultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Chaos_ServiceDesc is the grpc.ServiceDesc for Chaos service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Chaos_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chaos.Chaos",
+	HandlerType: (*ChaosServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetFault",
+			Handler:    _Chaos_SetFault_Handler,
+		},
+		{
+			MethodName: "ListFaults",
+			Handler:    _Chaos_ListFaults_Handler,
+		},
+		{
+			MethodName: "ClearFaults",
+			Handler:    _Chaos_ClearFaults_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "chaos.proto",
+}
@@ -0,0 +1,174 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: chaos.proto
+
+package chaosconnect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	chaos "github.com/reliability-lab/gen/chaos"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// ChaosName is the fully-qualified name of the Chaos service.
+	ChaosName = "chaos.Chaos"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// ChaosSetFaultProcedure is the fully-qualified name of the Chaos's SetFault RPC.
+	ChaosSetFaultProcedure = "/chaos.Chaos/SetFault"
+	// ChaosListFaultsProcedure is the fully-qualified name of the Chaos's ListFaults RPC.
+	ChaosListFaultsProcedure = "/chaos.Chaos/ListFaults"
+	// ChaosClearFaultsProcedure is the fully-qualified name of the Chaos's ClearFaults RPC.
+	ChaosClearFaultsProcedure = "/chaos.Chaos/ClearFaults"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	chaosServiceDescriptor           = chaos.File_chaos_proto.Services().ByName("Chaos")
+	chaosSetFaultMethodDescriptor    = chaosServiceDescriptor.Methods().ByName("SetFault")
+	chaosListFaultsMethodDescriptor  = chaosServiceDescriptor.Methods().ByName("ListFaults")
+	chaosClearFaultsMethodDescriptor = chaosServiceDescriptor.Methods().ByName("ClearFaults")
+)
+
+// ChaosClient is a client for the chaos.Chaos service.
+type ChaosClient interface {
+	// SetFault adds a fault, or replaces the fault with the same id.
+	SetFault(context.Context, *connect.Request[chaos.SetFaultRequest]) (*connect.Response[chaos.SetFaultResponse], error)
+	ListFaults(context.Context, *connect.Request[chaos.ListFaultsRequest]) (*connect.Response[chaos.ListFaultsResponse], error)
+	// ClearFaults removes one fault by id, or every fault when id is empty.
+	ClearFaults(context.Context, *connect.Request[chaos.ClearFaultsRequest]) (*connect.Response[chaos.ClearFaultsResponse], error)
+}
+
+// NewChaosClient constructs a client for the chaos.Chaos service. By default, it uses the Connect
+// protocol with the binary Protobuf Codec, asks for gzipped responses, and sends uncompressed
+// requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewChaosClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) ChaosClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &chaosClient{
+		setFault: connect.NewClient[chaos.SetFaultRequest, chaos.SetFaultResponse](
+			httpClient,
+			baseURL+ChaosSetFaultProcedure,
+			connect.WithSchema(chaosSetFaultMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listFaults: connect.NewClient[chaos.ListFaultsRequest, chaos.ListFaultsResponse](
+			httpClient,
+			baseURL+ChaosListFaultsProcedure,
+			connect.WithSchema(chaosListFaultsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		clearFaults: connect.NewClient[chaos.ClearFaultsRequest, chaos.ClearFaultsResponse](
+			httpClient,
+			baseURL+ChaosClearFaultsProcedure,
+			connect.WithSchema(chaosClearFaultsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// chaosClient implements ChaosClient.
+type chaosClient struct {
+	setFault    *connect.Client[chaos.SetFaultRequest, chaos.SetFaultResponse]
+	listFaults  *connect.Client[chaos.ListFaultsRequest, chaos.ListFaultsResponse]
+	clearFaults *connect.Client[chaos.ClearFaultsRequest, chaos.ClearFaultsResponse]
+}
+
+// SetFault calls chaos.Chaos.SetFault.
+func (c *chaosClient) SetFault(ctx context.Context, req *connect.Request[chaos.SetFaultRequest]) (*connect.Response[chaos.SetFaultResponse], error) {
+	return c.setFault.CallUnary(ctx, req)
+}
+
+// ListFaults calls chaos.Chaos.ListFaults.
+func (c *chaosClient) ListFaults(ctx context.Context, req *connect.Request[chaos.ListFaultsRequest]) (*connect.Response[chaos.ListFaultsResponse], error) {
+	return c.listFaults.CallUnary(ctx, req)
+}
+
+// ClearFaults calls chaos.Chaos.ClearFaults.
+func (c *chaosClient) ClearFaults(ctx context.Context, req *connect.Request[chaos.ClearFaultsRequest]) (*connect.Response[chaos.ClearFaultsResponse], error) {
+	return c.clearFaults.CallUnary(ctx, req)
+}
+
+// ChaosHandler is an implementation of the chaos.Chaos service.
+type ChaosHandler interface {
+	// SetFault adds a fault, or replaces the fault with the same id.
+	SetFault(context.Context, *connect.Request[chaos.SetFaultRequest]) (*connect.Response[chaos.SetFaultResponse], error)
+	ListFaults(context.Context, *connect.Request[chaos.ListFaultsRequest]) (*connect.Response[chaos.ListFaultsResponse], error)
+	// ClearFaults removes one fault by id, or every fault when id is empty.
+	ClearFaults(context.Context, *connect.Request[chaos.ClearFaultsRequest]) (*connect.Response[chaos.ClearFaultsResponse], error)
+}
+
+// NewChaosHandler builds an HTTP handler from the service implementation. It returns the path on
+// which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewChaosHandler(svc ChaosHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	chaosSetFaultHandler := connect.NewUnaryHandler(
+		ChaosSetFaultProcedure,
+		svc.SetFault,
+		connect.WithSchema(chaosSetFaultMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	chaosListFaultsHandler := connect.NewUnaryHandler(
+		ChaosListFaultsProcedure,
+		svc.ListFaults,
+		connect.WithSchema(chaosListFaultsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	chaosClearFaultsHandler := connect.NewUnaryHandler(
+		ChaosClearFaultsProcedure,
+		svc.ClearFaults,
+		connect.WithSchema(chaosClearFaultsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/chaos.Chaos/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case ChaosSetFaultProcedure:
+			chaosSetFaultHandler.ServeHTTP(w, r)
+		case ChaosListFaultsProcedure:
+			chaosListFaultsHandler.ServeHTTP(w, r)
+		case ChaosClearFaultsProcedure:
+			chaosClearFaultsHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedChaosHandler returns CodeUnimplemented from all methods.
+type UnimplementedChaosHandler struct{}
+
+func (UnimplementedChaosHandler) SetFault(context.Context, *connect.Request[chaos.SetFaultRequest]) (*connect.Response[chaos.SetFaultResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("chaos.Chaos.SetFault is not implemented"))
+}
+
+func (UnimplementedChaosHandler) ListFaults(context.Context, *connect.Request[chaos.ListFaultsRequest]) (*connect.Response[chaos.ListFaultsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("chaos.Chaos.ListFaults is not implemented"))
+}
+
+func (UnimplementedChaosHandler) ClearFaults(context.Context, *connect.Request[chaos.ClearFaultsRequest]) (*connect.Response[chaos.ClearFaultsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("chaos.Chaos.ClearFaults is not implemented"))
+}
@@ -3,6 +3,9 @@ module github.com/reliability-lab/gen
 go 1.22
 
 require (
+	connectrpc.com/connect v1.16.1
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.19.1
+	google.golang.org/genproto/googleapis/api v0.0.0-20240221002015-b0ce06bbee7c
 	google.golang.org/grpc v1.62.0
 	google.golang.org/protobuf v1.32.0
 )
@@ -0,0 +1,287 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: inventory.proto
+
+package inventoryconnect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	inventory "github.com/reliability-lab/gen/inventory"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// InventoryName is the fully-qualified name of the Inventory service.
+	InventoryName = "inventory.Inventory"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// InventoryReserveProcedure is the fully-qualified name of the Inventory's Reserve RPC.
+	InventoryReserveProcedure = "/inventory.Inventory/Reserve"
+	// InventoryCommitProcedure is the fully-qualified name of the Inventory's Commit RPC.
+	InventoryCommitProcedure = "/inventory.Inventory/Commit"
+	// InventoryReleaseProcedure is the fully-qualified name of the Inventory's Release RPC.
+	InventoryReleaseProcedure = "/inventory.Inventory/Release"
+	// InventoryReturnProcedure is the fully-qualified name of the Inventory's Return RPC.
+	InventoryReturnProcedure = "/inventory.Inventory/Return"
+	// InventoryGetStockProcedure is the fully-qualified name of the Inventory's GetStock RPC.
+	InventoryGetStockProcedure = "/inventory.Inventory/GetStock"
+	// InventorySetStockProcedure is the fully-qualified name of the Inventory's SetStock RPC.
+	InventorySetStockProcedure = "/inventory.Inventory/SetStock"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	inventoryServiceDescriptor        = inventory.File_inventory_proto.Services().ByName("Inventory")
+	inventoryReserveMethodDescriptor  = inventoryServiceDescriptor.Methods().ByName("Reserve")
+	inventoryCommitMethodDescriptor   = inventoryServiceDescriptor.Methods().ByName("Commit")
+	inventoryReleaseMethodDescriptor  = inventoryServiceDescriptor.Methods().ByName("Release")
+	inventoryReturnMethodDescriptor   = inventoryServiceDescriptor.Methods().ByName("Return")
+	inventoryGetStockMethodDescriptor = inventoryServiceDescriptor.Methods().ByName("GetStock")
+	inventorySetStockMethodDescriptor = inventoryServiceDescriptor.Methods().ByName("SetStock")
+)
+
+// InventoryClient is a client for the inventory.Inventory service.
+type InventoryClient interface {
+	// Reserve holds stock for every item or for none. It is idempotent by
+	// reservation_id: repeating it returns the existing reservation. Stock
+	// that is short fails with FailedPrecondition, an untracked SKU with
+	// NotFound.
+	Reserve(context.Context, *connect.Request[inventory.ReserveRequest]) (*connect.Response[inventory.ReserveResponse], error)
+	// Commit takes a held reservation's items out of stock. Committing twice is
+	// a no-op; an expired hold is committed only if the stock is still there.
+	Commit(context.Context, *connect.Request[inventory.CommitRequest]) (*connect.Response[inventory.CommitResponse], error)
+	// Release returns a held reservation's items to stock. Releasing a
+	// released or expired reservation is a no-op; a committed one fails with
+	// FailedPrecondition.
+	Release(context.Context, *connect.Request[inventory.ReleaseRequest]) (*connect.Response[inventory.ReleaseResponse], error)
+	// Return gives back the stock of a cancelled order: a held reservation is
+	// released, a committed one is put back on hand and becomes RETURNED.
+	// Repeating it is a no-op. An unknown reservation_id is recorded as
+	// RELEASED, so a Reserve that arrives after the cancellation holds nothing.
+	Return(context.Context, *connect.Request[inventory.ReturnRequest]) (*connect.Response[inventory.ReturnResponse], error)
+	GetStock(context.Context, *connect.Request[inventory.GetStockRequest]) (*connect.Response[inventory.GetStockResponse], error)
+	// SetStock sets the quantity on hand of a SKU, which must not drop below
+	// what is reserved. It is an operator call and is not exposed over REST.
+	SetStock(context.Context, *connect.Request[inventory.SetStockRequest]) (*connect.Response[inventory.SetStockResponse], error)
+}
+
+// NewInventoryClient constructs a client for the inventory.Inventory service. By default, it uses
+// the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewInventoryClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) InventoryClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &inventoryClient{
+		reserve: connect.NewClient[inventory.ReserveRequest, inventory.ReserveResponse](
+			httpClient,
+			baseURL+InventoryReserveProcedure,
+			connect.WithSchema(inventoryReserveMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		commit: connect.NewClient[inventory.CommitRequest, inventory.CommitResponse](
+			httpClient,
+			baseURL+InventoryCommitProcedure,
+			connect.WithSchema(inventoryCommitMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		release: connect.NewClient[inventory.ReleaseRequest, inventory.ReleaseResponse](
+			httpClient,
+			baseURL+InventoryReleaseProcedure,
+			connect.WithSchema(inventoryReleaseMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		_return: connect.NewClient[inventory.ReturnRequest, inventory.ReturnResponse](
+			httpClient,
+			baseURL+InventoryReturnProcedure,
+			connect.WithSchema(inventoryReturnMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getStock: connect.NewClient[inventory.GetStockRequest, inventory.GetStockResponse](
+			httpClient,
+			baseURL+InventoryGetStockProcedure,
+			connect.WithSchema(inventoryGetStockMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		setStock: connect.NewClient[inventory.SetStockRequest, inventory.SetStockResponse](
+			httpClient,
+			baseURL+InventorySetStockProcedure,
+			connect.WithSchema(inventorySetStockMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// inventoryClient implements InventoryClient.
+type inventoryClient struct {
+	reserve  *connect.Client[inventory.ReserveRequest, inventory.ReserveResponse]
+	commit   *connect.Client[inventory.CommitRequest, inventory.CommitResponse]
+	release  *connect.Client[inventory.ReleaseRequest, inventory.ReleaseResponse]
+	_return  *connect.Client[inventory.ReturnRequest, inventory.ReturnResponse]
+	getStock *connect.Client[inventory.GetStockRequest, inventory.GetStockResponse]
+	setStock *connect.Client[inventory.SetStockRequest, inventory.SetStockResponse]
+}
+
+// Reserve calls inventory.Inventory.Reserve.
+func (c *inventoryClient) Reserve(ctx context.Context, req *connect.Request[inventory.ReserveRequest]) (*connect.Response[inventory.ReserveResponse], error) {
+	return c.reserve.CallUnary(ctx, req)
+}
+
+// Commit calls inventory.Inventory.Commit.
+func (c *inventoryClient) Commit(ctx context.Context, req *connect.Request[inventory.CommitRequest]) (*connect.Response[inventory.CommitResponse], error) {
+	return c.commit.CallUnary(ctx, req)
+}
+
+// Release calls inventory.Inventory.Release.
+func (c *inventoryClient) Release(ctx context.Context, req *connect.Request[inventory.ReleaseRequest]) (*connect.Response[inventory.ReleaseResponse], error) {
+	return c.release.CallUnary(ctx, req)
+}
+
+// Return calls inventory.Inventory.Return.
+func (c *inventoryClient) Return(ctx context.Context, req *connect.Request[inventory.ReturnRequest]) (*connect.Response[inventory.ReturnResponse], error) {
+	return c._return.CallUnary(ctx, req)
+}
+
+// GetStock calls inventory.Inventory.GetStock.
+func (c *inventoryClient) GetStock(ctx context.Context, req *connect.Request[inventory.GetStockRequest]) (*connect.Response[inventory.GetStockResponse], error) {
+	return c.getStock.CallUnary(ctx, req)
+}
+
+// SetStock calls inventory.Inventory.SetStock.
+func (c *inventoryClient) SetStock(ctx context.Context, req *connect.Request[inventory.SetStockRequest]) (*connect.Response[inventory.SetStockResponse], error) {
+	return c.setStock.CallUnary(ctx, req)
+}
+
+// InventoryHandler is an implementation of the inventory.Inventory service.
+type InventoryHandler interface {
+	// Reserve holds stock for every item or for none. It is idempotent by
+	// reservation_id: repeating it returns the existing reservation. Stock
+	// that is short fails with FailedPrecondition, an untracked SKU with
+	// NotFound.
+	Reserve(context.Context, *connect.Request[inventory.ReserveRequest]) (*connect.Response[inventory.ReserveResponse], error)
+	// Commit takes a held reservation's items out of stock. Committing twice is
+	// a no-op; an expired hold is committed only if the stock is still there.
+	Commit(context.Context, *connect.Request[inventory.CommitRequest]) (*connect.Response[inventory.CommitResponse], error)
+	// Release returns a held reservation's items to stock. Releasing a
+	// released or expired reservation is a no-op; a committed one fails with
+	// FailedPrecondition.
+	Release(context.Context, *connect.Request[inventory.ReleaseRequest]) (*connect.Response[inventory.ReleaseResponse], error)
+	// Return gives back the stock of a cancelled order: a held reservation is
+	// released, a committed one is put back on hand and becomes RETURNED.
+	// Repeating it is a no-op. An unknown reservation_id is recorded as
+	// RELEASED, so a Reserve that arrives after the cancellation holds nothing.
+	Return(context.Context, *connect.Request[inventory.ReturnRequest]) (*connect.Response[inventory.ReturnResponse], error)
+	GetStock(context.Context, *connect.Request[inventory.GetStockRequest]) (*connect.Response[inventory.GetStockResponse], error)
+	// SetStock sets the quantity on hand of a SKU, which must not drop below
+	// what is reserved. It is an operator call and is not exposed over REST.
+	SetStock(context.Context, *connect.Request[inventory.SetStockRequest]) (*connect.Response[inventory.SetStockResponse], error)
+}
+
+// NewInventoryHandler builds an HTTP handler from the service implementation. It returns the path
+// on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewInventoryHandler(svc InventoryHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	inventoryReserveHandler := connect.NewUnaryHandler(
+		InventoryReserveProcedure,
+		svc.Reserve,
+		connect.WithSchema(inventoryReserveMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	inventoryCommitHandler := connect.NewUnaryHandler(
+		InventoryCommitProcedure,
+		svc.Commit,
+		connect.WithSchema(inventoryCommitMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	inventoryReleaseHandler := connect.NewUnaryHandler(
+		InventoryReleaseProcedure,
+		svc.Release,
+		connect.WithSchema(inventoryReleaseMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	inventoryReturnHandler := connect.NewUnaryHandler(
+		InventoryReturnProcedure,
+		svc.Return,
+		connect.WithSchema(inventoryReturnMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	inventoryGetStockHandler := connect.NewUnaryHandler(
+		InventoryGetStockProcedure,
+		svc.GetStock,
+		connect.WithSchema(inventoryGetStockMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	inventorySetStockHandler := connect.NewUnaryHandler(
+		InventorySetStockProcedure,
+		svc.SetStock,
+		connect.WithSchema(inventorySetStockMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/inventory.Inventory/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case InventoryReserveProcedure:
+			inventoryReserveHandler.ServeHTTP(w, r)
+		case InventoryCommitProcedure:
+			inventoryCommitHandler.ServeHTTP(w, r)
+		case InventoryReleaseProcedure:
+			inventoryReleaseHandler.ServeHTTP(w, r)
+		case InventoryReturnProcedure:
+			inventoryReturnHandler.ServeHTTP(w, r)
+		case InventoryGetStockProcedure:
+			inventoryGetStockHandler.ServeHTTP(w, r)
+		case InventorySetStockProcedure:
+			inventorySetStockHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedInventoryHandler returns CodeUnimplemented from all methods.
+type UnimplementedInventoryHandler struct{}
+
+func (UnimplementedInventoryHandler) Reserve(context.Context, *connect.Request[inventory.ReserveRequest]) (*connect.Response[inventory.ReserveResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("inventory.Inventory.Reserve is not implemented"))
+}
+
+func (UnimplementedInventoryHandler) Commit(context.Context, *connect.Request[inventory.CommitRequest]) (*connect.Response[inventory.CommitResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("inventory.Inventory.Commit is not implemented"))
+}
+
+func (UnimplementedInventoryHandler) Release(context.Context, *connect.Request[inventory.ReleaseRequest]) (*connect.Response[inventory.ReleaseResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("inventory.Inventory.Release is not implemented"))
+}
+
+func (UnimplementedInventoryHandler) Return(context.Context, *connect.Request[inventory.ReturnRequest]) (*connect.Response[inventory.ReturnResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("inventory.Inventory.Return is not implemented"))
+}
+
+func (UnimplementedInventoryHandler) GetStock(context.Context, *connect.Request[inventory.GetStockRequest]) (*connect.Response[inventory.GetStockResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("inventory.Inventory.GetStock is not implemented"))
+}
+
+func (UnimplementedInventoryHandler) SetStock(context.Context, *connect.Request[inventory.SetStockRequest]) (*connect.Response[inventory.SetStockResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("inventory.Inventory.SetStock is not implemented"))
+}
@@ -1,4 +1,8 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: notifications.proto
 
 package notifications
 
@@ -9,6 +13,9 @@ import (
 	status "google.golang.org/grpc/status"
 )
 
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
 const _ = grpc.SupportPackageIsVersion7
 
 const (
@@ -16,7 +23,11 @@ const (
 )
 
 // NotificationsClient is the client API for Notifications service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type NotificationsClient interface {
+	// SendReceipt is called by the gateway after checkout and is not exposed
+	// over REST or Connect.
 	SendReceipt(ctx context.Context, in *SendReceiptRequest, opts ...grpc.CallOption) (*SendReceiptResponse, error)
 }
 
@@ -38,18 +49,27 @@ func (c *notificationsClient) SendReceipt(ctx context.Context, in *SendReceiptRe
 }
 
 // NotificationsServer is the server API for Notifications service.
+// All implementations must embed UnimplementedNotificationsServer
+// for forward compatibility
 type NotificationsServer interface {
+	// SendReceipt is called by the gateway after checkout and is not exposed
+	// over REST or Connect.
 	SendReceipt(context.Context, *SendReceiptRequest) (*SendReceiptResponse, error)
 	mustEmbedUnimplementedNotificationsServer()
 }
 
-type UnimplementedNotificationsServer struct{}
+// UnimplementedNotificationsServer must be embedded to have forward compatible implementations.
+type UnimplementedNotificationsServer struct {
+}
 
 func (UnimplementedNotificationsServer) SendReceipt(context.Context, *SendReceiptRequest) (*SendReceiptResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendReceipt not implemented")
 }
 func (UnimplementedNotificationsServer) mustEmbedUnimplementedNotificationsServer() {}
 
+// UnsafeNotificationsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NotificationsServer will
+// result in compilation errors.
 type UnsafeNotificationsServer interface {
 	mustEmbedUnimplementedNotificationsServer()
 }
@@ -76,6 +96,9 @@ func _Notifications_SendReceipt_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+// Notifications_ServiceDesc is the grpc.ServiceDesc for Notifications service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
 var Notifications_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "notifications.Notifications",
 	HandlerType: (*NotificationsServer)(nil),
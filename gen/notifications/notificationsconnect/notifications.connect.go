@@ -0,0 +1,117 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: notifications.proto
+
+package notificationsconnect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	notifications "github.com/reliability-lab/gen/notifications"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// NotificationsName is the fully-qualified name of the Notifications service.
+	NotificationsName = "notifications.Notifications"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// NotificationsSendReceiptProcedure is the fully-qualified name of the Notifications's SendReceipt
+	// RPC.
+	NotificationsSendReceiptProcedure = "/notifications.Notifications/SendReceipt"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	notificationsServiceDescriptor           = notifications.File_notifications_proto.Services().ByName("Notifications")
+	notificationsSendReceiptMethodDescriptor = notificationsServiceDescriptor.Methods().ByName("SendReceipt")
+)
+
+// NotificationsClient is a client for the notifications.Notifications service.
+type NotificationsClient interface {
+	// SendReceipt is called by the gateway after checkout and is not exposed
+	// over REST or Connect.
+	SendReceipt(context.Context, *connect.Request[notifications.SendReceiptRequest]) (*connect.Response[notifications.SendReceiptResponse], error)
+}
+
+// NewNotificationsClient constructs a client for the notifications.Notifications service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewNotificationsClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) NotificationsClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &notificationsClient{
+		sendReceipt: connect.NewClient[notifications.SendReceiptRequest, notifications.SendReceiptResponse](
+			httpClient,
+			baseURL+NotificationsSendReceiptProcedure,
+			connect.WithSchema(notificationsSendReceiptMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// notificationsClient implements NotificationsClient.
+type notificationsClient struct {
+	sendReceipt *connect.Client[notifications.SendReceiptRequest, notifications.SendReceiptResponse]
+}
+
+// SendReceipt calls notifications.Notifications.SendReceipt.
+func (c *notificationsClient) SendReceipt(ctx context.Context, req *connect.Request[notifications.SendReceiptRequest]) (*connect.Response[notifications.SendReceiptResponse], error) {
+	return c.sendReceipt.CallUnary(ctx, req)
+}
+
+// NotificationsHandler is an implementation of the notifications.Notifications service.
+type NotificationsHandler interface {
+	// SendReceipt is called by the gateway after checkout and is not exposed
+	// over REST or Connect.
+	SendReceipt(context.Context, *connect.Request[notifications.SendReceiptRequest]) (*connect.Response[notifications.SendReceiptResponse], error)
+}
+
+// NewNotificationsHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewNotificationsHandler(svc NotificationsHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	notificationsSendReceiptHandler := connect.NewUnaryHandler(
+		NotificationsSendReceiptProcedure,
+		svc.SendReceipt,
+		connect.WithSchema(notificationsSendReceiptMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/notifications.Notifications/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case NotificationsSendReceiptProcedure:
+			notificationsSendReceiptHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedNotificationsHandler returns CodeUnimplemented from all methods.
+type UnimplementedNotificationsHandler struct{}
+
+func (UnimplementedNotificationsHandler) SendReceipt(context.Context, *connect.Request[notifications.SendReceiptRequest]) (*connect.Response[notifications.SendReceiptResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("notifications.Notifications.SendReceipt is not implemented"))
+}
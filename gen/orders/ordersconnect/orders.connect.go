@@ -0,0 +1,292 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: orders.proto
+
+package ordersconnect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	orders "github.com/reliability-lab/gen/orders"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// OrdersName is the fully-qualified name of the Orders service.
+	OrdersName = "orders.Orders"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// OrdersCreateOrderProcedure is the fully-qualified name of the Orders's CreateOrder RPC.
+	OrdersCreateOrderProcedure = "/orders.Orders/CreateOrder"
+	// OrdersGetOrderProcedure is the fully-qualified name of the Orders's GetOrder RPC.
+	OrdersGetOrderProcedure = "/orders.Orders/GetOrder"
+	// OrdersUpdateOrderStatusProcedure is the fully-qualified name of the Orders's UpdateOrderStatus
+	// RPC.
+	OrdersUpdateOrderStatusProcedure = "/orders.Orders/UpdateOrderStatus"
+	// OrdersCancelOrderProcedure is the fully-qualified name of the Orders's CancelOrder RPC.
+	OrdersCancelOrderProcedure = "/orders.Orders/CancelOrder"
+	// OrdersGetOrderHistoryProcedure is the fully-qualified name of the Orders's GetOrderHistory RPC.
+	OrdersGetOrderHistoryProcedure = "/orders.Orders/GetOrderHistory"
+	// OrdersWatchOrdersProcedure is the fully-qualified name of the Orders's WatchOrders RPC.
+	OrdersWatchOrdersProcedure = "/orders.Orders/WatchOrders"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	ordersServiceDescriptor                 = orders.File_orders_proto.Services().ByName("Orders")
+	ordersCreateOrderMethodDescriptor       = ordersServiceDescriptor.Methods().ByName("CreateOrder")
+	ordersGetOrderMethodDescriptor          = ordersServiceDescriptor.Methods().ByName("GetOrder")
+	ordersUpdateOrderStatusMethodDescriptor = ordersServiceDescriptor.Methods().ByName("UpdateOrderStatus")
+	ordersCancelOrderMethodDescriptor       = ordersServiceDescriptor.Methods().ByName("CancelOrder")
+	ordersGetOrderHistoryMethodDescriptor   = ordersServiceDescriptor.Methods().ByName("GetOrderHistory")
+	ordersWatchOrdersMethodDescriptor       = ordersServiceDescriptor.Methods().ByName("WatchOrders")
+)
+
+// OrdersClient is a client for the orders.Orders service.
+type OrdersClient interface {
+	// CreateOrder only records an order; it is not reserved, charged or
+	// receipted. Clients check out through the gateway's POST /orders, so it
+	// is not exposed over REST or Connect.
+	CreateOrder(context.Context, *connect.Request[orders.CreateOrderRequest]) (*connect.Response[orders.CreateOrderResponse], error)
+	GetOrder(context.Context, *connect.Request[orders.GetOrderRequest]) (*connect.Response[orders.GetOrderResponse], error)
+	// UpdateOrderStatus records the checkout outcome for an order: PAID,
+	// PAYMENT_FAILED or OUT_OF_STOCK. It is called by the gateway and is not
+	// exposed over REST.
+	UpdateOrderStatus(context.Context, *connect.Request[orders.UpdateOrderStatusRequest]) (*connect.Response[orders.UpdateOrderStatusResponse], error)
+	// CancelOrder cancels a CREATED or PAID order and reverses its payment
+	// through payments: an authorization in flight is voided, a capture is
+	// refunded. Cancelling a cancelled order returns it unchanged; other
+	// statuses fail with FailedPrecondition. The gateway serves it as
+	// POST /orders/{order_id}/cancel.
+	CancelOrder(context.Context, *connect.Request[orders.CancelOrderRequest]) (*connect.Response[orders.CancelOrderResponse], error)
+	// GetOrderHistory returns every change to an order, oldest first: the
+	// status it moved from and to, why, who asked, through which service and
+	// in which trace.
+	GetOrderHistory(context.Context, *connect.Request[orders.GetOrderHistoryRequest]) (*connect.Response[orders.GetOrderHistoryResponse], error)
+	// WatchOrders streams status transitions for one order or for all orders of
+	// a user. Events with sequence <= after_sequence are skipped, so a client can
+	// resume from the last sequence it saw.
+	WatchOrders(context.Context, *connect.Request[orders.WatchOrdersRequest]) (*connect.ServerStreamForClient[orders.OrderEvent], error)
+}
+
+// NewOrdersClient constructs a client for the orders.Orders service. By default, it uses the
+// Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewOrdersClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) OrdersClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &ordersClient{
+		createOrder: connect.NewClient[orders.CreateOrderRequest, orders.CreateOrderResponse](
+			httpClient,
+			baseURL+OrdersCreateOrderProcedure,
+			connect.WithSchema(ordersCreateOrderMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getOrder: connect.NewClient[orders.GetOrderRequest, orders.GetOrderResponse](
+			httpClient,
+			baseURL+OrdersGetOrderProcedure,
+			connect.WithSchema(ordersGetOrderMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		updateOrderStatus: connect.NewClient[orders.UpdateOrderStatusRequest, orders.UpdateOrderStatusResponse](
+			httpClient,
+			baseURL+OrdersUpdateOrderStatusProcedure,
+			connect.WithSchema(ordersUpdateOrderStatusMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		cancelOrder: connect.NewClient[orders.CancelOrderRequest, orders.CancelOrderResponse](
+			httpClient,
+			baseURL+OrdersCancelOrderProcedure,
+			connect.WithSchema(ordersCancelOrderMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getOrderHistory: connect.NewClient[orders.GetOrderHistoryRequest, orders.GetOrderHistoryResponse](
+			httpClient,
+			baseURL+OrdersGetOrderHistoryProcedure,
+			connect.WithSchema(ordersGetOrderHistoryMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		watchOrders: connect.NewClient[orders.WatchOrdersRequest, orders.OrderEvent](
+			httpClient,
+			baseURL+OrdersWatchOrdersProcedure,
+			connect.WithSchema(ordersWatchOrdersMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// ordersClient implements OrdersClient.
+type ordersClient struct {
+	createOrder       *connect.Client[orders.CreateOrderRequest, orders.CreateOrderResponse]
+	getOrder          *connect.Client[orders.GetOrderRequest, orders.GetOrderResponse]
+	updateOrderStatus *connect.Client[orders.UpdateOrderStatusRequest, orders.UpdateOrderStatusResponse]
+	cancelOrder       *connect.Client[orders.CancelOrderRequest, orders.CancelOrderResponse]
+	getOrderHistory   *connect.Client[orders.GetOrderHistoryRequest, orders.GetOrderHistoryResponse]
+	watchOrders       *connect.Client[orders.WatchOrdersRequest, orders.OrderEvent]
+}
+
+// CreateOrder calls orders.Orders.CreateOrder.
+func (c *ordersClient) CreateOrder(ctx context.Context, req *connect.Request[orders.CreateOrderRequest]) (*connect.Response[orders.CreateOrderResponse], error) {
+	return c.createOrder.CallUnary(ctx, req)
+}
+
+// GetOrder calls orders.Orders.GetOrder.
+func (c *ordersClient) GetOrder(ctx context.Context, req *connect.Request[orders.GetOrderRequest]) (*connect.Response[orders.GetOrderResponse], error) {
+	return c.getOrder.CallUnary(ctx, req)
+}
+
+// UpdateOrderStatus calls orders.Orders.UpdateOrderStatus.
+func (c *ordersClient) UpdateOrderStatus(ctx context.Context, req *connect.Request[orders.UpdateOrderStatusRequest]) (*connect.Response[orders.UpdateOrderStatusResponse], error) {
+	return c.updateOrderStatus.CallUnary(ctx, req)
+}
+
+// CancelOrder calls orders.Orders.CancelOrder.
+func (c *ordersClient) CancelOrder(ctx context.Context, req *connect.Request[orders.CancelOrderRequest]) (*connect.Response[orders.CancelOrderResponse], error) {
+	return c.cancelOrder.CallUnary(ctx, req)
+}
+
+// GetOrderHistory calls orders.Orders.GetOrderHistory.
+func (c *ordersClient) GetOrderHistory(ctx context.Context, req *connect.Request[orders.GetOrderHistoryRequest]) (*connect.Response[orders.GetOrderHistoryResponse], error) {
+	return c.getOrderHistory.CallUnary(ctx, req)
+}
+
+// WatchOrders calls orders.Orders.WatchOrders.
+func (c *ordersClient) WatchOrders(ctx context.Context, req *connect.Request[orders.WatchOrdersRequest]) (*connect.ServerStreamForClient[orders.OrderEvent], error) {
+	return c.watchOrders.CallServerStream(ctx, req)
+}
+
+// OrdersHandler is an implementation of the orders.Orders service.
+type OrdersHandler interface {
+	// CreateOrder only records an order; it is not reserved, charged or
+	// receipted. Clients check out through the gateway's POST /orders, so it
+	// is not exposed over REST or Connect.
+	CreateOrder(context.Context, *connect.Request[orders.CreateOrderRequest]) (*connect.Response[orders.CreateOrderResponse], error)
+	GetOrder(context.Context, *connect.Request[orders.GetOrderRequest]) (*connect.Response[orders.GetOrderResponse], error)
+	// UpdateOrderStatus records the checkout outcome for an order: PAID,
+	// PAYMENT_FAILED or OUT_OF_STOCK. It is called by the gateway and is not
+	// exposed over REST.
+	UpdateOrderStatus(context.Context, *connect.Request[orders.UpdateOrderStatusRequest]) (*connect.Response[orders.UpdateOrderStatusResponse], error)
+	// CancelOrder cancels a CREATED or PAID order and reverses its payment
+	// through payments: an authorization in flight is voided, a capture is
+	// refunded. Cancelling a cancelled order returns it unchanged; other
+	// statuses fail with FailedPrecondition. The gateway serves it as
+	// POST /orders/{order_id}/cancel.
+	CancelOrder(context.Context, *connect.Request[orders.CancelOrderRequest]) (*connect.Response[orders.CancelOrderResponse], error)
+	// GetOrderHistory returns every change to an order, oldest first: the
+	// status it moved from and to, why, who asked, through which service and
+	// in which trace.
+	GetOrderHistory(context.Context, *connect.Request[orders.GetOrderHistoryRequest]) (*connect.Response[orders.GetOrderHistoryResponse], error)
+	// WatchOrders streams status transitions for one order or for all orders of
+	// a user. Events with sequence <= after_sequence are skipped, so a client can
+	// resume from the last sequence it saw.
+	WatchOrders(context.Context, *connect.Request[orders.WatchOrdersRequest], *connect.ServerStream[orders.OrderEvent]) error
+}
+
+// NewOrdersHandler builds an HTTP handler from the service implementation. It returns the path on
+// which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewOrdersHandler(svc OrdersHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	ordersCreateOrderHandler := connect.NewUnaryHandler(
+		OrdersCreateOrderProcedure,
+		svc.CreateOrder,
+		connect.WithSchema(ordersCreateOrderMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ordersGetOrderHandler := connect.NewUnaryHandler(
+		OrdersGetOrderProcedure,
+		svc.GetOrder,
+		connect.WithSchema(ordersGetOrderMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ordersUpdateOrderStatusHandler := connect.NewUnaryHandler(
+		OrdersUpdateOrderStatusProcedure,
+		svc.UpdateOrderStatus,
+		connect.WithSchema(ordersUpdateOrderStatusMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ordersCancelOrderHandler := connect.NewUnaryHandler(
+		OrdersCancelOrderProcedure,
+		svc.CancelOrder,
+		connect.WithSchema(ordersCancelOrderMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ordersGetOrderHistoryHandler := connect.NewUnaryHandler(
+		OrdersGetOrderHistoryProcedure,
+		svc.GetOrderHistory,
+		connect.WithSchema(ordersGetOrderHistoryMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ordersWatchOrdersHandler := connect.NewServerStreamHandler(
+		OrdersWatchOrdersProcedure,
+		svc.WatchOrders,
+		connect.WithSchema(ordersWatchOrdersMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/orders.Orders/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case OrdersCreateOrderProcedure:
+			ordersCreateOrderHandler.ServeHTTP(w, r)
+		case OrdersGetOrderProcedure:
+			ordersGetOrderHandler.ServeHTTP(w, r)
+		case OrdersUpdateOrderStatusProcedure:
+			ordersUpdateOrderStatusHandler.ServeHTTP(w, r)
+		case OrdersCancelOrderProcedure:
+			ordersCancelOrderHandler.ServeHTTP(w, r)
+		case OrdersGetOrderHistoryProcedure:
+			ordersGetOrderHistoryHandler.ServeHTTP(w, r)
+		case OrdersWatchOrdersProcedure:
+			ordersWatchOrdersHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedOrdersHandler returns CodeUnimplemented from all methods.
+type UnimplementedOrdersHandler struct{}
+
+func (UnimplementedOrdersHandler) CreateOrder(context.Context, *connect.Request[orders.CreateOrderRequest]) (*connect.Response[orders.CreateOrderResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("orders.Orders.CreateOrder is not implemented"))
+}
+
+func (UnimplementedOrdersHandler) GetOrder(context.Context, *connect.Request[orders.GetOrderRequest]) (*connect.Response[orders.GetOrderResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("orders.Orders.GetOrder is not implemented"))
+}
+
+func (UnimplementedOrdersHandler) UpdateOrderStatus(context.Context, *connect.Request[orders.UpdateOrderStatusRequest]) (*connect.Response[orders.UpdateOrderStatusResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("orders.Orders.UpdateOrderStatus is not implemented"))
+}
+
+func (UnimplementedOrdersHandler) CancelOrder(context.Context, *connect.Request[orders.CancelOrderRequest]) (*connect.Response[orders.CancelOrderResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("orders.Orders.CancelOrder is not implemented"))
+}
+
+func (UnimplementedOrdersHandler) GetOrderHistory(context.Context, *connect.Request[orders.GetOrderHistoryRequest]) (*connect.Response[orders.GetOrderHistoryResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("orders.Orders.GetOrderHistory is not implemented"))
+}
+
+func (UnimplementedOrdersHandler) WatchOrders(context.Context, *connect.Request[orders.WatchOrdersRequest], *connect.ServerStream[orders.OrderEvent]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("orders.Orders.WatchOrders is not implemented"))
+}
@@ -1,4 +1,8 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: payments.proto
 
 package payments
 
@@ -9,15 +13,29 @@ import (
 	status "google.golang.org/grpc/status"
 )
 
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Payments_Charge_FullMethodName = "/payments.Payments/Charge"
+	Payments_Charge_FullMethodName  = "/payments.Payments/Charge"
+	Payments_Reverse_FullMethodName = "/payments.Payments/Reverse"
 )
 
 // PaymentsClient is the client API for Payments service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PaymentsClient interface {
+	// Charge is called by the gateway during checkout and is not exposed over
+	// REST or Connect.
 	Charge(ctx context.Context, in *ChargeRequest, opts ...grpc.CallOption) (*ChargeResponse, error)
+	// Reverse undoes an order's payment: it voids an authorization that has
+	// not been captured, so the charge in flight is declined, and refunds a
+	// captured one. An order that was never charged is voided too, so a late
+	// charge cannot capture. Reversing again returns the same outcome. It is
+	// called by orders when an order is cancelled and is not exposed over REST.
+	Reverse(ctx context.Context, in *ReverseRequest, opts ...grpc.CallOption) (*ReverseResponse, error)
 }
 
 type paymentsClient struct {
@@ -37,19 +55,46 @@ func (c *paymentsClient) Charge(ctx context.Context, in *ChargeRequest, opts ...
 	return out, nil
 }
 
+func (c *paymentsClient) Reverse(ctx context.Context, in *ReverseRequest, opts ...grpc.CallOption) (*ReverseResponse, error) {
+	out := new(ReverseResponse)
+	err := c.cc.Invoke(ctx, Payments_Reverse_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentsServer is the server API for Payments service.
+// All implementations must embed UnimplementedPaymentsServer
+// for forward compatibility
 type PaymentsServer interface {
+	// Charge is called by the gateway during checkout and is not exposed over
+	// REST or Connect.
 	Charge(context.Context, *ChargeRequest) (*ChargeResponse, error)
+	// Reverse undoes an order's payment: it voids an authorization that has
+	// not been captured, so the charge in flight is declined, and refunds a
+	// captured one. An order that was never charged is voided too, so a late
+	// charge cannot capture. Reversing again returns the same outcome. It is
+	// called by orders when an order is cancelled and is not exposed over REST.
+	Reverse(context.Context, *ReverseRequest) (*ReverseResponse, error)
 	mustEmbedUnimplementedPaymentsServer()
 }
 
-type UnimplementedPaymentsServer struct{}
+// UnimplementedPaymentsServer must be embedded to have forward compatible implementations.
+type UnimplementedPaymentsServer struct {
+}
 
 func (UnimplementedPaymentsServer) Charge(context.Context, *ChargeRequest) (*ChargeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Charge not implemented")
 }
+func (UnimplementedPaymentsServer) Reverse(context.Context, *ReverseRequest) (*ReverseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reverse not implemented")
+}
 func (UnimplementedPaymentsServer) mustEmbedUnimplementedPaymentsServer() {}
 
+// UnsafePaymentsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PaymentsServer will
+// result in compilation errors.
 type UnsafePaymentsServer interface {
 	mustEmbedUnimplementedPaymentsServer()
 }
@@ -76,6 +121,27 @@ func _Payments_Charge_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Payments_Reverse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReverseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentsServer).Reverse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Payments_Reverse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentsServer).Reverse(ctx, req.(*ReverseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Payments_ServiceDesc is the grpc.ServiceDesc for Payments service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
 var Payments_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "payments.Payments",
 	HandlerType: (*PaymentsServer)(nil),
@@ -84,6 +150,10 @@ var Payments_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Charge",
 			Handler:    _Payments_Charge_Handler,
 		},
+		{
+			MethodName: "Reverse",
+			Handler:    _Payments_Reverse_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "payments.proto",
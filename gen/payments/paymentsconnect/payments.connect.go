@@ -0,0 +1,155 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: payments.proto
+
+package paymentsconnect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	payments "github.com/reliability-lab/gen/payments"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// PaymentsName is the fully-qualified name of the Payments service.
+	PaymentsName = "payments.Payments"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// PaymentsChargeProcedure is the fully-qualified name of the Payments's Charge RPC.
+	PaymentsChargeProcedure = "/payments.Payments/Charge"
+	// PaymentsReverseProcedure is the fully-qualified name of the Payments's Reverse RPC.
+	PaymentsReverseProcedure = "/payments.Payments/Reverse"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	paymentsServiceDescriptor       = payments.File_payments_proto.Services().ByName("Payments")
+	paymentsChargeMethodDescriptor  = paymentsServiceDescriptor.Methods().ByName("Charge")
+	paymentsReverseMethodDescriptor = paymentsServiceDescriptor.Methods().ByName("Reverse")
+)
+
+// PaymentsClient is a client for the payments.Payments service.
+type PaymentsClient interface {
+	// Charge is called by the gateway during checkout and is not exposed over
+	// REST or Connect.
+	Charge(context.Context, *connect.Request[payments.ChargeRequest]) (*connect.Response[payments.ChargeResponse], error)
+	// Reverse undoes an order's payment: it voids an authorization that has
+	// not been captured, so the charge in flight is declined, and refunds a
+	// captured one. An order that was never charged is voided too, so a late
+	// charge cannot capture. Reversing again returns the same outcome. It is
+	// called by orders when an order is cancelled and is not exposed over REST.
+	Reverse(context.Context, *connect.Request[payments.ReverseRequest]) (*connect.Response[payments.ReverseResponse], error)
+}
+
+// NewPaymentsClient constructs a client for the payments.Payments service. By default, it uses the
+// Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewPaymentsClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) PaymentsClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &paymentsClient{
+		charge: connect.NewClient[payments.ChargeRequest, payments.ChargeResponse](
+			httpClient,
+			baseURL+PaymentsChargeProcedure,
+			connect.WithSchema(paymentsChargeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		reverse: connect.NewClient[payments.ReverseRequest, payments.ReverseResponse](
+			httpClient,
+			baseURL+PaymentsReverseProcedure,
+			connect.WithSchema(paymentsReverseMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// paymentsClient implements PaymentsClient.
+type paymentsClient struct {
+	charge  *connect.Client[payments.ChargeRequest, payments.ChargeResponse]
+	reverse *connect.Client[payments.ReverseRequest, payments.ReverseResponse]
+}
+
+// Charge calls payments.Payments.Charge.
+func (c *paymentsClient) Charge(ctx context.Context, req *connect.Request[payments.ChargeRequest]) (*connect.Response[payments.ChargeResponse], error) {
+	return c.charge.CallUnary(ctx, req)
+}
+
+// Reverse calls payments.Payments.Reverse.
+func (c *paymentsClient) Reverse(ctx context.Context, req *connect.Request[payments.ReverseRequest]) (*connect.Response[payments.ReverseResponse], error) {
+	return c.reverse.CallUnary(ctx, req)
+}
+
+// PaymentsHandler is an implementation of the payments.Payments service.
+type PaymentsHandler interface {
+	// Charge is called by the gateway during checkout and is not exposed over
+	// REST or Connect.
+	Charge(context.Context, *connect.Request[payments.ChargeRequest]) (*connect.Response[payments.ChargeResponse], error)
+	// Reverse undoes an order's payment: it voids an authorization that has
+	// not been captured, so the charge in flight is declined, and refunds a
+	// captured one. An order that was never charged is voided too, so a late
+	// charge cannot capture. Reversing again returns the same outcome. It is
+	// called by orders when an order is cancelled and is not exposed over REST.
+	Reverse(context.Context, *connect.Request[payments.ReverseRequest]) (*connect.Response[payments.ReverseResponse], error)
+}
+
+// NewPaymentsHandler builds an HTTP handler from the service implementation. It returns the path on
+// which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewPaymentsHandler(svc PaymentsHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	paymentsChargeHandler := connect.NewUnaryHandler(
+		PaymentsChargeProcedure,
+		svc.Charge,
+		connect.WithSchema(paymentsChargeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	paymentsReverseHandler := connect.NewUnaryHandler(
+		PaymentsReverseProcedure,
+		svc.Reverse,
+		connect.WithSchema(paymentsReverseMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/payments.Payments/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case PaymentsChargeProcedure:
+			paymentsChargeHandler.ServeHTTP(w, r)
+		case PaymentsReverseProcedure:
+			paymentsReverseHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedPaymentsHandler returns CodeUnimplemented from all methods.
+type UnimplementedPaymentsHandler struct{}
+
+func (UnimplementedPaymentsHandler) Charge(context.Context, *connect.Request[payments.ChargeRequest]) (*connect.Response[payments.ChargeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("payments.Payments.Charge is not implemented"))
+}
+
+func (UnimplementedPaymentsHandler) Reverse(context.Context, *connect.Request[payments.ReverseRequest]) (*connect.Response[payments.ReverseResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("payments.Payments.Reverse is not implemented"))
+}
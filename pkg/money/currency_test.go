@@ -0,0 +1,22 @@
+package money
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupCurrency(t *testing.T) {
+	for code, exp := range map[string]int{"USD": 2, "JPY": 0, "KWD": 3, "CLF": 4} {
+		if c, ok := LookupCurrency(code); !ok || c.Exponent != exp {
+			t.Errorf("LookupCurrency(%s) = %+v, %v; want exponent %d", code, c, ok, exp)
+		}
+	}
+	for code, want := range map[string]string{"usd": "upper case", "XXX": "not an ISO 4217", "dollars": "not an ISO 4217", "": "required"} {
+		if _, ok := LookupCurrency(code); ok {
+			t.Errorf("LookupCurrency(%q) found a currency", code)
+		}
+		if err := ValidateCurrency(code); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateCurrency(%q) = %v, want it to mention %q", code, err, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/reliability-lab/pkg/money"
+	"gopkg.in/yaml.v3"
+)
+
+// Table is a Source of rates against one base currency, for offline use. It
+// is loaded from a file like:
+//
+//	base: USD
+//	as_of: 2026-10-01T00:00:00Z
+//	rates:
+//	  EUR: "0.9213"
+//	  JPY: "149.85"
+//
+// where each rate is units of the currency per unit of base. Rates between
+// two other currencies go through the base.
+type Table struct {
+	base  string
+	asOf  time.Time
+	rates map[string]*big.Rat
+}
+
+// LoadFile reads a rates file.
+func LoadFile(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("rates %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Parse reads the rates file in data.
+func Parse(data []byte) (*Table, error) {
+	var f struct {
+		Base  string            `yaml:"base"`
+		AsOf  time.Time         `yaml:"as_of"`
+		Rates map[string]string `yaml:"rates"`
+	}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	var errs []error
+	if err := money.ValidateCurrency(f.Base); err != nil {
+		errs = append(errs, fmt.Errorf("base: %w", err))
+	}
+	if f.AsOf.IsZero() {
+		errs = append(errs, errors.New("as_of required"))
+	}
+	t := &Table{base: f.Base, asOf: f.AsOf, rates: map[string]*big.Rat{f.Base: big.NewRat(1, 1)}}
+	for code, s := range f.Rates {
+		if err := money.ValidateCurrency(code); err != nil {
+			errs = append(errs, fmt.Errorf("rates: %w", err))
+			continue
+		}
+		v, ok := new(big.Rat).SetString(s)
+		if !ok || v.Sign() <= 0 {
+			errs = append(errs, fmt.Errorf("rates: %s: %q is not a positive decimal", code, s))
+			continue
+		}
+		t.rates[code] = v
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Table) Rate(_ context.Context, from, to string) (Rate, error) {
+	f, ok := t.rates[from]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w from %s", ErrNoRate, from)
+	}
+	g, ok := t.rates[to]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w to %s", ErrNoRate, to)
+	}
+	return NewRate(from, to, new(big.Rat).Quo(g, f), t.asOf), nil
+}
+
+var _ Source = (*Table)(nil)
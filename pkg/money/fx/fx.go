@@ -0,0 +1,114 @@
+// Package fx converts amounts between currencies at rates from a pluggable
+// Source.
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/reliability-lab/pkg/money"
+)
+
+// ErrNoRate is returned by a Source that has no rate for a pair.
+var ErrNoRate = errors.New("fx: no rate")
+
+// ErrStaleRate is returned by a MaxAge source for a rate published too long
+// ago.
+var ErrStaleRate = errors.New("fx: stale rate")
+
+// Source provides exchange rates.
+type Source interface {
+	// Rate returns the rate from one currency into another, or an error
+	// wrapping ErrNoRate.
+	Rate(ctx context.Context, from, to string) (Rate, error)
+}
+
+// MaxAge returns a Source that passes on the rates of src published within
+// maxAge, and fails with an error wrapping ErrStaleRate for older ones.
+func MaxAge(src Source, maxAge time.Duration) Source {
+	return maxAgeSource{src: src, maxAge: maxAge, now: time.Now}
+}
+
+type maxAgeSource struct {
+	src    Source
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+func (s maxAgeSource) Rate(ctx context.Context, from, to string) (Rate, error) {
+	r, err := s.src.Rate(ctx, from, to)
+	if err != nil {
+		return Rate{}, err
+	}
+	if age := s.now().Sub(r.AsOf); age > s.maxAge {
+		return Rate{}, fmt.Errorf("%w from %s to %s: published %s, more than %s ago", ErrStaleRate, from, to, r.AsOf.UTC().Format(time.RFC3339), s.maxAge)
+	}
+	return r, nil
+}
+
+// RateDecimals is the precision rates are quoted and applied at.
+const RateDecimals = 10
+
+// Rate converts amounts of From into To.
+type Rate struct {
+	From, To string
+	// Value is units of To per unit of From, a decimal with at most
+	// RateDecimals places. It is exactly the rate Convert applies.
+	Value string
+	// AsOf is when the source published the rate.
+	AsOf time.Time
+}
+
+// NewRate returns the rate from one currency into another, with value
+// rounded to RateDecimals places.
+func NewRate(from, to string, value *big.Rat, asOf time.Time) Rate {
+	return Rate{From: from, To: to, Value: trimZeros(value.FloatString(RateDecimals)), AsOf: asOf}
+}
+
+// Convert converts m, which must be in r.From, into r.To, rounding half away
+// from zero to the minor units of r.To.
+func (r Rate) Convert(m money.Money) (money.Money, error) {
+	if m.Currency != r.From {
+		return money.Money{}, fmt.Errorf("fx: %w: rate from %s for an amount in %s", money.ErrCurrencyMismatch, r.From, m.Currency)
+	}
+	from, ok := money.LookupCurrency(r.From)
+	if !ok {
+		return money.Money{}, fmt.Errorf("fx: %w", money.ValidateCurrency(r.From))
+	}
+	to, ok := money.LookupCurrency(r.To)
+	if !ok {
+		return money.Money{}, fmt.Errorf("fx: %w", money.ValidateCurrency(r.To))
+	}
+	v, ok := new(big.Rat).SetString(r.Value)
+	if !ok || v.Sign() <= 0 {
+		return money.Money{}, fmt.Errorf("fx: invalid rate %q", r.Value)
+	}
+	// Rescale from the minor units of From to those of To.
+	if d := to.Exponent - from.Exponent; d > 0 {
+		v.Mul(v, new(big.Rat).SetInt(pow10(d)))
+	} else if d < 0 {
+		v.Quo(v, new(big.Rat).SetInt(pow10(-d)))
+	}
+	out, err := money.Money{Amount: m.Amount, Currency: r.To}.Scale(v, money.HalfAwayFromZero)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("fx: converting %s: %w", m, err)
+	}
+	return out, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func trimZeros(s string) string {
+	for len(s) > 1 && s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+	if s[len(s)-1] == '.' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
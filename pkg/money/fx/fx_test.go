@@ -0,0 +1,103 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reliability-lab/pkg/money"
+)
+
+const rates = `
+base: USD
+as_of: 2026-10-01T00:00:00Z
+rates:
+  EUR: "0.92"
+  JPY: 150
+  KWD: "0.3075"
+`
+
+func TestTable_Rate(t *testing.T) {
+	table, err := Parse([]byte(rates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, tt := range []struct {
+		from, to string
+		amount   int64
+		value    string
+		want     int64
+	}{
+		{"USD", "EUR", 1299, "0.92", 1195},         // 11.95 EUR
+		{"USD", "JPY", 1299, "150", 1949},          // 1948.5 JPY rounds up
+		{"JPY", "USD", 1949, "0.0066666667", 1299}, // 12.9933 USD
+		{"EUR", "KWD", 1000, "0.3342391304", 3342}, // 3.342 KWD
+		{"USD", "USD", 1299, "1", 1299},
+		{"USD", "EUR", -1299, "0.92", -1195},
+	} {
+		r, err := table.Rate(ctx, tt.from, tt.to)
+		if err != nil || r.Value != tt.value || !r.AsOf.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Rate(%s, %s) = %+v, %v; want %s", tt.from, tt.to, r, err, tt.value)
+			continue
+		}
+		if got, err := r.Convert(money.Money{Amount: tt.amount, Currency: tt.from}); err != nil || got != (money.Money{Amount: tt.want, Currency: tt.to}) {
+			t.Errorf("%d %s in %s = %v, %v; want %d", tt.amount, tt.from, tt.to, got, err, tt.want)
+		}
+	}
+	if _, err := table.Rate(ctx, "USD", "GBP"); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Rate to GBP: %v, want ErrNoRate", err)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	table, err := Parse([]byte(rates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	asOf := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	src := maxAgeSource{src: table, maxAge: 24 * time.Hour}
+	for _, tt := range []struct {
+		now   time.Time
+		stale bool
+	}{
+		{asOf.Add(time.Hour), false},
+		{asOf.Add(24 * time.Hour), false},
+		{asOf.Add(25 * time.Hour), true},
+	} {
+		src.now = func() time.Time { return tt.now }
+		r, err := src.Rate(ctx, "USD", "EUR")
+		if tt.stale && !errors.Is(err, ErrStaleRate) {
+			t.Errorf("Rate at %s = %+v, %v; want ErrStaleRate", tt.now, r, err)
+		}
+		if !tt.stale && (err != nil || r.Value != "0.92") {
+			t.Errorf("Rate at %s = %+v, %v; want 0.92", tt.now, r, err)
+		}
+	}
+	if _, err := src.Rate(ctx, "USD", "GBP"); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Rate to GBP: %v, want ErrNoRate", err)
+	}
+}
+
+func TestConvert_Overflow(t *testing.T) {
+	r := NewRate("USD", "KWD", big.NewRat(1000, 1), time.Now())
+	if _, err := r.Convert(money.Money{Amount: 1 << 62, Currency: "USD"}); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("overflowing conversion: %v, want ErrOverflow", err)
+	}
+	if _, err := r.Convert(money.Money{Amount: 100, Currency: "EUR"}); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("converting EUR at a USD rate: %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestParse_Rejects(t *testing.T) {
+	_, err := Parse([]byte("base: usd\nrates:\n  EUR: \"-1\"\n  XXX: \"1\"\n"))
+	for _, want := range []string{"base:", "as_of required", "EUR", "XXX"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse error %v, want it to mention %s", err, want)
+		}
+	}
+}
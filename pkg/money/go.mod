@@ -0,0 +1,12 @@
+module github.com/reliability-lab/pkg/money
+
+go 1.22
+
+require (
+	github.com/reliability-lab/gen v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require google.golang.org/protobuf v1.32.0 // indirect
+
+replace github.com/reliability-lab/gen => ../../gen
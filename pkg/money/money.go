@@ -0,0 +1,143 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+var (
+	// ErrOverflow is returned for results outside the int64 range of minor
+	// units.
+	ErrOverflow = errors.New("money: amount out of range")
+	// ErrCurrencyMismatch is returned for arithmetic on two currencies.
+	ErrCurrencyMismatch = errors.New("money: currencies differ")
+)
+
+// Money is an amount in the minor units of a currency. Its methods never
+// wrap around: a result that does not fit in int64 is ErrOverflow.
+type Money struct {
+	// Amount is in minor units of Currency: cents for USD, yen for JPY,
+	// thousandths for KWD.
+	Amount   int64
+	Currency string
+}
+
+// New returns amount minor units of currency, which must be ISO 4217.
+func New(amount int64, currency string) (Money, error) {
+	if err := ValidateCurrency(currency); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Add returns m + o.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return m.result(new(big.Int).Add(big.NewInt(m.Amount), big.NewInt(o.Amount)))
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return m.result(new(big.Int).Sub(big.NewInt(m.Amount), big.NewInt(o.Amount)))
+}
+
+// Mul returns m times n, e.g. a unit price times a quantity.
+func (m Money) Mul(n int64) (Money, error) {
+	return m.result(new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(n)))
+}
+
+// Scale returns m times r, rounded to minor units with mode, e.g. for a
+// percentage.
+func (m Money) Scale(r *big.Rat, mode RoundingMode) (Money, error) {
+	x := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), r)
+	return m.result(Round(x, mode))
+}
+
+// Allocate splits m into parts proportional to weights, which must not be
+// negative and must not all be zero. The parts add up to m exactly: minor
+// units left over by rounding down go one each to the parts that lost the
+// most, earlier parts first on ties. Allocate(1, 1, 1) of 100 cents is 34,
+// 33, 33.
+func (m Money) Allocate(weights ...int64) ([]Money, error) {
+	total := new(big.Int)
+	for _, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("money: negative weight %d", w)
+		}
+		total.Add(total, big.NewInt(w))
+	}
+	if total.Sign() == 0 {
+		return nil, errors.New("money: weights add up to zero")
+	}
+	parts := make([]Money, len(weights))
+	rems := make([]*big.Int, len(weights))
+	left := m.Amount
+	for i, w := range weights {
+		q, r := new(big.Int).QuoRem(new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(w)), total, new(big.Int))
+		// |q| <= |m.Amount|, so it fits.
+		parts[i] = Money{Amount: q.Int64(), Currency: m.Currency}
+		rems[i] = r.Abs(r)
+		left -= q.Int64()
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return rems[order[a]].Cmp(rems[order[b]]) > 0 })
+	unit := int64(1)
+	if left < 0 {
+		unit, left = -1, -left
+	}
+	for _, i := range order[:left] {
+		parts[i].Amount += unit
+	}
+	return parts, nil
+}
+
+// Split splits m into n parts that differ by at most one minor unit, the
+// larger ones first.
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("money: cannot split into %d parts", n)
+	}
+	weights := make([]int64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return m.Allocate(weights...)
+}
+
+// IsZero reports whether m is no money.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// String formats m in major units, e.g. "12.99 USD", "1949 JPY" or
+// "-3.075 KWD". Unknown currencies are shown in minor units.
+func (m Money) String() string {
+	c, ok := LookupCurrency(m.Currency)
+	if !ok || c.Exponent == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+	digits := fmt.Sprintf("%0*d", c.Exponent+1, new(big.Int).Abs(big.NewInt(m.Amount)))
+	sign := ""
+	if m.Amount < 0 {
+		sign = "-"
+	}
+	cut := len(digits) - c.Exponent
+	return sign + digits[:cut] + "." + digits[cut:] + " " + m.Currency
+}
+
+func (m Money) result(x *big.Int) (Money, error) {
+	if !x.IsInt64() {
+		return Money{}, fmt.Errorf("%w in %s", ErrOverflow, m.Currency)
+	}
+	return Money{Amount: x.Int64(), Currency: m.Currency}, nil
+}
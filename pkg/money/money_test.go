@@ -0,0 +1,127 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"slices"
+	"testing"
+
+	moneypb "github.com/reliability-lab/gen/money"
+)
+
+func usd(amount int64) Money { return Money{Amount: amount, Currency: "USD"} }
+
+func TestArithmetic(t *testing.T) {
+	if got, err := usd(1299).Add(usd(899)); err != nil || got != usd(2198) {
+		t.Errorf("Add = %v, %v", got, err)
+	}
+	if got, err := usd(899).Sub(usd(1299)); err != nil || got != usd(-400) {
+		t.Errorf("Sub = %v, %v", got, err)
+	}
+	if got, err := usd(1299).Mul(3); err != nil || got != usd(3897) {
+		t.Errorf("Mul = %v, %v", got, err)
+	}
+	for name, err := range map[string]error{
+		"Add":      second(usd(math.MaxInt64).Add(usd(1))),
+		"Sub":      second(usd(math.MinInt64).Sub(usd(1))),
+		"Mul":      second(usd(1 << 62).Mul(2)),
+		"Mul(-1)":  second(usd(math.MinInt64).Mul(-1)),
+		"Scale":    second(usd(math.MaxInt64).Scale(big.NewRat(3, 2), Down)),
+		"mismatch": second(usd(1).Add(Money{Amount: 1, Currency: "EUR"})),
+	} {
+		want := ErrOverflow
+		if name == "mismatch" {
+			want = ErrCurrencyMismatch
+		}
+		if !errors.Is(err, want) {
+			t.Errorf("%s: %v, want %v", name, err, want)
+		}
+	}
+}
+
+func second(_ Money, err error) error { return err }
+
+func TestRound(t *testing.T) {
+	modes := []RoundingMode{HalfAwayFromZero, HalfEven, Down, Up, Floor, Ceiling}
+	for _, tt := range []struct {
+		x    *big.Rat
+		want []int64 // one per mode, in the order of modes
+	}{
+		{big.NewRat(5, 2), []int64{3, 2, 2, 3, 2, 3}},
+		{big.NewRat(7, 2), []int64{4, 4, 3, 4, 3, 4}},
+		{big.NewRat(-5, 2), []int64{-3, -2, -2, -3, -3, -2}},
+		{big.NewRat(26, 10), []int64{3, 3, 2, 3, 2, 3}},
+		{big.NewRat(-24, 10), []int64{-2, -2, -2, -3, -3, -2}},
+		{big.NewRat(4, 1), []int64{4, 4, 4, 4, 4, 4}},
+	} {
+		for i, mode := range modes {
+			if got := Round(tt.x, mode); got.Int64() != tt.want[i] {
+				t.Errorf("Round(%s, mode %d) = %d, want %d", tt.x.RatString(), mode, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	for _, tt := range []struct {
+		m       Money
+		weights []int64
+		want    []int64
+	}{
+		{usd(100), []int64{1, 1, 1}, []int64{34, 33, 33}},
+		{usd(-100), []int64{1, 1, 1}, []int64{-34, -33, -33}},
+		{usd(5), []int64{3, 7}, []int64{2, 3}}, // 1.5 and 3.5: the tie goes to the first part
+		{usd(1000), []int64{1, 0, 2}, []int64{333, 0, 667}},
+		{usd(math.MaxInt64), []int64{math.MaxInt64, math.MaxInt64}, []int64{math.MaxInt64/2 + 1, math.MaxInt64 / 2}},
+	} {
+		parts, err := tt.m.Allocate(tt.weights...)
+		if err != nil {
+			t.Errorf("Allocate(%v) of %v: %v", tt.weights, tt.m, err)
+			continue
+		}
+		got := make([]int64, len(parts))
+		for i, p := range parts {
+			got[i] = p.Amount
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Allocate(%v) of %v = %v, want %v", tt.weights, tt.m, got, tt.want)
+		}
+	}
+	if _, err := usd(100).Allocate(0, 0); err == nil {
+		t.Error("Allocate with zero weights succeeded")
+	}
+	if _, err := usd(100).Allocate(1, -1); err == nil {
+		t.Error("Allocate with a negative weight succeeded")
+	}
+	if parts, err := usd(10).Split(4); err != nil || !slices.Equal(parts, []Money{usd(3), usd(3), usd(2), usd(2)}) {
+		t.Errorf("Split(4) of 10 = %v, %v", parts, err)
+	}
+}
+
+func TestString(t *testing.T) {
+	for m, want := range map[Money]string{
+		usd(1299):                        "12.99 USD",
+		usd(-5):                          "-0.05 USD",
+		{Amount: 1949, Currency: "JPY"}:  "1949 JPY",
+		{Amount: -3075, Currency: "KWD"}: "-3.075 KWD",
+		{Amount: 12, Currency: "NOPE"}:   "12 NOPE",
+	} {
+		if got := m.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestProto(t *testing.T) {
+	m, err := FromProto(usd(1299).Proto())
+	if err != nil || m != usd(1299) {
+		t.Errorf("round trip = %v, %v", m, err)
+	}
+	if _, err := FromProto(&moneypb.Money{MinorUnits: 1, Currency: "usd"}); err == nil {
+		t.Error("FromProto accepted a lower-case currency")
+	}
+	if _, err := FromProto(nil); err == nil {
+		t.Error("FromProto accepted nil")
+	}
+}
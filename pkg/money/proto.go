@@ -0,0 +1,20 @@
+package money
+
+import (
+	"errors"
+
+	moneypb "github.com/reliability-lab/gen/money"
+)
+
+// FromProto returns m as Money. Its currency must be ISO 4217.
+func FromProto(m *moneypb.Money) (Money, error) {
+	if m == nil {
+		return Money{}, errors.New("amount required")
+	}
+	return New(m.MinorUnits, m.Currency)
+}
+
+// Proto returns m as a proto message.
+func (m Money) Proto() *moneypb.Money {
+	return &moneypb.Money{Currency: m.Currency, MinorUnits: m.Amount}
+}
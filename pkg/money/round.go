@@ -0,0 +1,52 @@
+package money
+
+import "math/big"
+
+// RoundingMode says how to round a fraction of a minor unit.
+type RoundingMode int
+
+const (
+	// HalfAwayFromZero rounds to the nearest unit, halves away from zero:
+	// 2.5 to 3, -2.5 to -3. It is the usual commercial rounding.
+	HalfAwayFromZero RoundingMode = iota
+	// HalfEven rounds to the nearest unit, halves to the even one: 2.5 to 2,
+	// 3.5 to 4. It does not drift when many roundings are added up.
+	HalfEven
+	// Down truncates toward zero.
+	Down
+	// Up rounds away from zero.
+	Up
+	// Floor rounds toward negative infinity.
+	Floor
+	// Ceiling rounds toward positive infinity.
+	Ceiling
+)
+
+// Round rounds x to an integer with mode.
+func Round(x *big.Rat, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(x.Num(), x.Denom(), new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// q is x truncated toward zero; away moves it one unit further out.
+	away := false
+	switch mode {
+	case Up:
+		away = true
+	case Floor:
+		away = x.Sign() < 0
+	case Ceiling:
+		away = x.Sign() > 0
+	case HalfAwayFromZero, HalfEven:
+		switch new(big.Int).Mul(r.Abs(r), big.NewInt(2)).Cmp(x.Denom()) {
+		case 1:
+			away = true
+		case 0:
+			away = mode == HalfAwayFromZero || q.Bit(0) == 1
+		}
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(x.Sign())))
+	}
+	return q
+}
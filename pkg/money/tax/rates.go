@@ -0,0 +1,137 @@
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+
+	"github.com/reliability-lab/pkg/money"
+	"gopkg.in/yaml.v3"
+)
+
+// Rates is a Jurisdiction levying flat-rate taxes, each on some product tax
+// categories. Categories no rate names are exempt.
+type Rates struct {
+	code, name string
+	rates      []rate
+}
+
+type rate struct {
+	name       string
+	value      *big.Rat
+	text       string
+	categories []string
+}
+
+func (r *Rates) Code() string { return r.code }
+
+// Name is the jurisdiction's display name.
+func (r *Rates) Name() string { return r.name }
+
+// Taxes levies each rate on the total of the lines in its categories,
+// rounding half away from zero. Rates no line is taxable under are left
+// out.
+func (r *Rates) Taxes(lines []Line) ([]Tax, error) {
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	var out []Tax
+	for _, rt := range r.rates {
+		taxable := money.Money{Currency: lines[0].Amount.Currency}
+		for _, l := range lines {
+			if !slices.Contains(rt.categories, l.Category) {
+				continue
+			}
+			var err error
+			if taxable, err = taxable.Add(l.Amount); err != nil {
+				return nil, err
+			}
+		}
+		if taxable.Amount == 0 {
+			continue
+		}
+		amount, err := taxable.Scale(rt.value, money.HalfAwayFromZero)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Tax{Name: rt.name, Rate: rt.text, Taxable: taxable, Amount: amount})
+	}
+	return out, nil
+}
+
+// LoadFile reads a rules file.
+func LoadFile(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("tax rules %s: %w", path, err)
+	}
+	return e, nil
+}
+
+// Parse reads a rules file like:
+//
+//	version: 2026-10-01
+//	categories: [standard, clothing, food]
+//	jurisdictions:
+//	  - code: US-CA
+//	    name: California
+//	    rates:
+//	      - {name: Sales tax, rate: "0.0725", categories: [standard, clothing]}
+//
+// version names the rules and should change with every edit. Every
+// jurisdiction is a Rates.
+func Parse(data []byte) (*Engine, error) {
+	var f struct {
+		Version       string   `yaml:"version"`
+		Categories    []string `yaml:"categories"`
+		Jurisdictions []struct {
+			Code  string `yaml:"code"`
+			Name  string `yaml:"name"`
+			Rates []struct {
+				Name       string   `yaml:"name"`
+				Rate       string   `yaml:"rate"`
+				Categories []string `yaml:"categories"`
+			} `yaml:"rates"`
+		} `yaml:"jurisdictions"`
+	}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	var errs []error
+	var jurisdictions []Jurisdiction
+	for i, fj := range f.Jurisdictions {
+		if fj.Code == "" {
+			errs = append(errs, fmt.Errorf("jurisdiction %d: code required", i))
+			continue
+		}
+		j := &Rates{code: fj.Code, name: fj.Name}
+		for k, fr := range fj.Rates {
+			v, ok := new(big.Rat).SetString(fr.Rate)
+			if !ok || v.Sign() < 0 || v.Cmp(big.NewRat(1, 1)) >= 0 {
+				errs = append(errs, fmt.Errorf("jurisdiction %s: rate %d: %q is not a decimal from 0 to 1", fj.Code, k, fr.Rate))
+			}
+			if fr.Name == "" || len(fr.Categories) == 0 {
+				errs = append(errs, fmt.Errorf("jurisdiction %s: rate %d: name and categories required", fj.Code, k))
+			}
+			for _, c := range fr.Categories {
+				if !slices.Contains(f.Categories, c) {
+					errs = append(errs, fmt.Errorf("jurisdiction %s: rate %d: %w %q", fj.Code, k, ErrUnknownCategory, c))
+				}
+			}
+			j.rates = append(j.rates, rate{name: fr.Name, value: v, text: fr.Rate, categories: fr.Categories})
+		}
+		jurisdictions = append(jurisdictions, j)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return NewEngine(f.Version, f.Categories, jurisdictions...)
+}
+
+var _ Jurisdiction = (*Rates)(nil)
@@ -0,0 +1,100 @@
+// Package tax works out the taxes on a sale from rules per jurisdiction.
+package tax
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/reliability-lab/pkg/money"
+)
+
+// DefaultCategory is the tax category of products that name none.
+const DefaultCategory = "standard"
+
+var (
+	// ErrUnknownJurisdiction is returned for a jurisdiction without rules.
+	ErrUnknownJurisdiction = errors.New("tax: unknown jurisdiction")
+	// ErrUnknownCategory is returned for a product tax category the rules
+	// do not define.
+	ErrUnknownCategory = errors.New("tax: unknown category")
+)
+
+// Line is an amount of a sale in one product tax category.
+type Line struct {
+	Category string
+	Amount   money.Money
+}
+
+// Tax is one tax levied on a sale.
+type Tax struct {
+	Name string
+	// Rate is the rate applied as a decimal string: "0.0725" is 7.25%.
+	Rate string
+	// Taxable is the part of the sale the rate applied to.
+	Taxable money.Money
+	Amount  money.Money
+}
+
+// Jurisdiction levies taxes on the sales made in it.
+type Jurisdiction interface {
+	// Code identifies the jurisdiction: an ISO 3166 country or subdivision
+	// code such as "DE" or "US-CA".
+	Code() string
+	// Taxes returns the taxes on lines, which are in one currency and have
+	// known categories. Taxes are added to the amounts, not included in them.
+	Taxes(lines []Line) ([]Tax, error)
+}
+
+// Engine taxes sales by jurisdiction under one version of the rules.
+type Engine struct {
+	version    string
+	categories map[string]bool
+	byCode     map[string]Jurisdiction
+}
+
+// NewEngine returns an engine for version of the rules, which defines the
+// product tax categories and jurisdictions. categories must include
+// DefaultCategory.
+func NewEngine(version string, categories []string, jurisdictions ...Jurisdiction) (*Engine, error) {
+	if version == "" {
+		return nil, errors.New("tax: version required")
+	}
+	e := &Engine{version: version, categories: make(map[string]bool), byCode: make(map[string]Jurisdiction)}
+	for _, c := range categories {
+		e.categories[c] = true
+	}
+	if !e.categories[DefaultCategory] {
+		return nil, fmt.Errorf("tax: categories must include %q", DefaultCategory)
+	}
+	for _, j := range jurisdictions {
+		if _, dup := e.byCode[j.Code()]; dup {
+			return nil, fmt.Errorf("tax: jurisdiction %s: duplicate", j.Code())
+		}
+		e.byCode[j.Code()] = j
+	}
+	return e, nil
+}
+
+// Version identifies the rules; orders store it with their taxes.
+func (e *Engine) Version() string { return e.version }
+
+// Calculate returns the taxes on lines sold in jurisdiction. Lines without a
+// category are in DefaultCategory.
+func (e *Engine) Calculate(jurisdiction string, lines []Line) ([]Tax, error) {
+	j, ok := e.byCode[jurisdiction]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownJurisdiction, jurisdiction)
+	}
+	lines = append([]Line(nil), lines...)
+	for i, l := range lines {
+		if l.Category == "" {
+			lines[i].Category = DefaultCategory
+		} else if !e.categories[l.Category] {
+			return nil, fmt.Errorf("%w %q", ErrUnknownCategory, l.Category)
+		}
+		if l.Amount.Currency != lines[0].Amount.Currency {
+			return nil, fmt.Errorf("tax: %w: lines in %s and %s", money.ErrCurrencyMismatch, lines[0].Amount.Currency, l.Amount.Currency)
+		}
+	}
+	return j.Taxes(lines)
+}
@@ -0,0 +1,101 @@
+package tax
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/reliability-lab/pkg/money"
+)
+
+const rules = `
+version: test-1
+categories: [standard, clothing, food]
+jurisdictions:
+  - code: US-CA
+    rates:
+      - {name: Sales tax, rate: "0.0725", categories: [standard, clothing]}
+  - code: DE
+    rates:
+      - {name: USt 19%, rate: "0.19", categories: [standard, clothing]}
+      - {name: USt 7%, rate: "0.07", categories: [food]}
+  - code: US-OR
+`
+
+func usd(n int64) money.Money { return money.Money{Amount: n, Currency: "USD"} }
+
+func TestEngine_Calculate(t *testing.T) {
+	e, err := Parse([]byte(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Version() != "test-1" {
+		t.Errorf("Version() = %q", e.Version())
+	}
+	lines := []Line{{Category: "clothing", Amount: usd(2598)}, {Amount: usd(899)}, {Category: "food", Amount: usd(450)}}
+	for _, tt := range []struct {
+		jurisdiction string
+		want         []Tax
+	}{
+		// 7.25% of 34.97 is 2.535325: food is exempt.
+		{"US-CA", []Tax{{Name: "Sales tax", Rate: "0.0725", Taxable: usd(3497), Amount: usd(254)}}},
+		{"DE", []Tax{
+			{Name: "USt 19%", Rate: "0.19", Taxable: usd(3497), Amount: usd(664)}, // 6.6443
+			{Name: "USt 7%", Rate: "0.07", Taxable: usd(450), Amount: usd(32)},    // 0.315 rounds up
+		}},
+		{"US-OR", nil},
+	} {
+		got, err := e.Calculate(tt.jurisdiction, lines)
+		if err != nil || len(got) != len(tt.want) {
+			t.Errorf("Calculate(%s) = %+v, %v; want %+v", tt.jurisdiction, got, err, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Calculate(%s)[%d] = %+v, want %+v", tt.jurisdiction, i, got[i], tt.want[i])
+			}
+		}
+	}
+
+	if _, err := e.Calculate("US-TX", lines); !errors.Is(err, ErrUnknownJurisdiction) {
+		t.Errorf("Calculate(US-TX) error %v, want ErrUnknownJurisdiction", err)
+	}
+	if _, err := e.Calculate("DE", []Line{{Category: "toys", Amount: usd(100)}}); !errors.Is(err, ErrUnknownCategory) {
+		t.Errorf("Calculate(toys) error %v, want ErrUnknownCategory", err)
+	}
+	if _, err := e.Calculate("DE", []Line{{Amount: usd(100)}, {Amount: money.Money{Amount: 100, Currency: "EUR"}}}); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Calculate(USD and EUR) error %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte(`
+version: v1
+categories: [standard]
+jurisdictions:
+  - code: A
+    rates:
+      - {name: a, rate: "1.5", categories: [standard]}
+      - {name: b, rate: "0.1", categories: [toys]}
+      - {rate: "0.1", categories: [standard]}
+  - name: no code
+`))
+	if err == nil {
+		t.Fatal("Parse accepted invalid rules")
+	}
+	for _, want := range []string{`rate 0: "1.5"`, `rate 1: tax: unknown category "toys"`, "rate 2: name and categories required", "jurisdiction 1: code required"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	for _, data := range []string{
+		"categories: [standard]",
+		"version: v1\ncategories: [food]",
+		"version: v1\ncategories: [standard]\njurisdictions: [{code: A}, {code: A}]",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%q) accepted invalid rules", data)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package platform
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"github.com/rs/zerolog/log"
+)
+
+func (s *Service) registerAdmin(api *chaos.Server) {
+	s.Admin.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	s.Admin.HandleFunc("/readyz", s.handleReady)
+	s.Admin.Handle("/metrics", promhttp.Handler())
+	if api != nil {
+		s.Admin.Handle("/chaos/faults", api)
+		s.Admin.Handle("/chaos/faults/", api)
+	}
+}
+
+// chaosAPI returns the chaos API for Chaos, or nil when Config.ChaosAPI is
+// off. Only operator principals may call it, so without an operator token it
+// stays off rather than open.
+func (s *Service) chaosAPI() *chaos.Server {
+	if !s.opts.config.ChaosAPI {
+		return nil
+	}
+	if !s.Auth.HasOperator() {
+		log.Warn().Msg("chaos API disabled: AUTH_TOKENS has no operator principal")
+		return nil
+	}
+	return chaos.NewServer(s.Chaos, chaos.WithAuthorize(s.Auth.Operator))
+}
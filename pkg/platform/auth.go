@@ -0,0 +1,78 @@
+package platform
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+)
+
+// operatorPrefix marks the principals of trusted operator tooling: they may
+// act for someone else and call the chaos API.
+const operatorPrefix = "operator:"
+
+// Authenticator resolves "Authorization: Bearer <token>" credentials to the
+// principals configured for them in Config.AuthTokens, e.g. "user:u1" or
+// "operator:alice".
+type Authenticator struct {
+	tokens     [][]byte
+	principals []string
+}
+
+// NewAuthenticator parses "token=principal" pairs.
+func NewAuthenticator(pairs []string) (*Authenticator, error) {
+	a := &Authenticator{}
+	for i, p := range pairs {
+		token, principal, ok := strings.Cut(p, "=")
+		token, principal = strings.TrimSpace(token), strings.TrimSpace(principal)
+		if !ok || token == "" || principal == "" {
+			// The entry is a secret; only say which one is wrong.
+			return nil, fmt.Errorf("AUTH_TOKENS: entry %d: want token=principal", i+1)
+		}
+		a.tokens = append(a.tokens, []byte(token))
+		a.principals = append(a.principals, principal)
+	}
+	return a, nil
+}
+
+// Principal returns the principal authorization, an Authorization header
+// value, authenticates. ok is false for a missing, malformed or unknown
+// credential.
+func (a *Authenticator) Principal(authorization string) (principal string, ok bool) {
+	token, found := strings.CutPrefix(authorization, "Bearer ")
+	if a == nil || !found || token == "" {
+		return "", false
+	}
+	// Compare against every token so timing does not reveal which matched.
+	for i, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t, []byte(token)) == 1 && !ok {
+			principal, ok = a.principals[i], true
+		}
+	}
+	return principal, ok
+}
+
+// HasOperator reports whether any configured token authenticates an
+// operator.
+func (a *Authenticator) HasOperator() bool {
+	if a == nil {
+		return false
+	}
+	for _, p := range a.principals {
+		if IsOperator(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOperator reports whether principal belongs to trusted operator tooling.
+func IsOperator(principal string) bool {
+	return strings.HasPrefix(principal, operatorPrefix)
+}
+
+// Operator authenticates authorization and accepts only operator principals;
+// it has the shape of chaos.Authorize.
+func (a *Authenticator) Operator(authorization string) (principal string, ok bool) {
+	principal, ok = a.Principal(authorization)
+	return principal, ok && IsOperator(principal)
+}
@@ -0,0 +1,47 @@
+package platform
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Calls between services say who made them, for audit trails: Service's
+// DialOptions add the calling service, and WithActor the person or system
+// the call is made for.
+const (
+	ActorHeader  = "x-actor"
+	SourceHeader = "x-source-service"
+)
+
+// WithActor returns ctx with actor attached to the calls made with it.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ActorHeader, actor)
+}
+
+// Caller returns the actor and calling service of an incoming call, each
+// empty if the caller did not say.
+func Caller(ctx context.Context) (actor, source string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return first(md, ActorHeader), first(md, SourceHeader)
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func sourceUnaryClientInterceptor(service string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(metadata.AppendToOutgoingContext(ctx, SourceHeader, service), method, req, reply, cc, opts...)
+	}
+}
+
+func sourceStreamClientInterceptor(service string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(metadata.AppendToOutgoingContext(ctx, SourceHeader, service), desc, cc, method, opts...)
+	}
+}
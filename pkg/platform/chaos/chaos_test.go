@@ -0,0 +1,153 @@
+package chaos
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRegistry() *Registry {
+	r := NewRegistry("test")
+	r.RegisterTarget(Target{Name: "payments.charge", Errors: []string{"DECLINED"}})
+	return r
+}
+
+func TestRegistry_FaultsExpire(t *testing.T) {
+	r := newTestRegistry()
+	f, err := r.Set(context.Background(), Fault{Target: "payments.charge", Error: "DECLINED"}, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits := r.Hit(context.Background(), "payments.charge", Call{}); len(hits) != 1 || hits[0].ID != f.ID {
+		t.Fatalf("hits = %+v, want the new fault", hits)
+	}
+
+	// Replacing the fault restarts its TTL; the old timer must not remove it.
+	if _, err := r.Set(context.Background(), Fault{ID: f.ID, Target: "payments.charge", Latency: time.Millisecond}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := r.List(); len(got) != 1 || got[0].Latency != time.Millisecond {
+		t.Fatalf("after replace: %+v", got)
+	}
+
+	if _, err := r.Set(context.Background(), Fault{Target: "payments.charge", Error: "DECLINED"}, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(r.List()) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("fault did not expire: %+v", r.List())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRegistry_Probability(t *testing.T) {
+	r := newTestRegistry()
+	rolls := []float64{0.1, 0.9}
+	r.roll = func() float64 { v := rolls[0]; rolls = rolls[1:]; return v }
+	if _, err := r.Set(context.Background(), Fault{Target: "payments.charge", Error: "DECLINED", Probability: 0.5}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(r.Hit(context.Background(), "payments.charge", Call{})); n != 1 {
+		t.Errorf("roll 0.1 < 0.5: %d hits, want 1", n)
+	}
+	if n := len(r.Hit(context.Background(), "payments.charge", Call{})); n != 0 {
+		t.Errorf("roll 0.9 >= 0.5: %d hits, want 0", n)
+	}
+	if n := len(r.Hit(context.Background(), "orders.create", Call{})); n != 0 {
+		t.Errorf("other target: %d hits, want 0", n)
+	}
+}
+
+func TestServer_HTTP(t *testing.T) {
+	r := newTestRegistry()
+	srv := httptest.NewServer(NewServer(r))
+	defer srv.Close()
+
+	do := func(method, path, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set(ActorHeader, "alice")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var b strings.Builder
+		_, _ = io.Copy(&b, resp.Body)
+		return resp.StatusCode, b.String()
+	}
+
+	code, body := do(http.MethodPost, "/chaos/faults", `{"fault":{"id":"f1","target":"payments.charge","error":"DECLINED","probability":0.3},"ttl":"120s"}`)
+	if code != http.StatusOK || !strings.Contains(body, `"actor":"alice"`) {
+		t.Fatalf("set: %d %s", code, body)
+	}
+	if code, body = do(http.MethodPost, "/chaos/faults", `{"fault":{"target":"payments.refund","error":"DECLINED"}}`); code != http.StatusBadRequest {
+		t.Errorf("unknown target: %d %s, want 400", code, body)
+	}
+	if code, body = do(http.MethodPost, "/chaos/faults", `{"fault":{"target":"payments.charge","error":"DECLINED"},"ttl":"7200s"}`); code != http.StatusBadRequest {
+		t.Errorf("ttl over max: %d %s, want 400", code, body)
+	}
+	code, body = do(http.MethodGet, "/chaos/faults", "")
+	if code != http.StatusOK || !strings.Contains(body, `"id":"f1"`) || !strings.Contains(body, `"name":"payments.charge"`) {
+		t.Errorf("list: %d %s", code, body)
+	}
+	if code, _ = do(http.MethodDelete, "/chaos/faults/f1", ""); code != http.StatusOK {
+		t.Errorf("clear f1: %d", code)
+	}
+	if code, _ = do(http.MethodDelete, "/chaos/faults/f1", ""); code != http.StatusNotFound {
+		t.Errorf("clear f1 again: %d, want 404", code)
+	}
+	if len(r.List()) != 0 {
+		t.Errorf("faults left: %+v", r.List())
+	}
+}
+
+func TestServer_Authorize(t *testing.T) {
+	r := newTestRegistry()
+	authorize := func(authorization string) (string, bool) {
+		return "operator:alice", authorization == "Bearer op"
+	}
+	srv := httptest.NewServer(NewServer(r, WithAuthorize(authorize)))
+	defer srv.Close()
+
+	set := func(token, actor string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/chaos/faults", strings.NewReader(`{"fault":{"target":"payments.charge","error":"DECLINED"}}`))
+		if actor != "" {
+			req.Header.Set(ActorHeader, actor)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var b strings.Builder
+		_, _ = io.Copy(&b, resp.Body)
+		return resp.StatusCode, b.String()
+	}
+	for _, token := range []string{"", "wrong"} {
+		if code, body := set(token, "mallory"); code != http.StatusUnauthorized {
+			t.Errorf("token %q: %d %s, want 401", token, code, body)
+		}
+	}
+	if len(r.List()) != 0 {
+		t.Fatalf("unauthorized call set a fault: %+v", r.List())
+	}
+	// The principal is audited; the claimed actor only annotates it.
+	if code, body := set("op", "mallory"); code != http.StatusOK || !strings.Contains(body, `"actor":"operator:alice (mallory)"`) {
+		t.Errorf("operator: %d %s", code, body)
+	}
+	if code, body := set("op", ""); code != http.StatusOK || !strings.Contains(body, `"actor":"operator:alice"`) {
+		t.Errorf("operator without actor header: %d %s", code, body)
+	}
+}
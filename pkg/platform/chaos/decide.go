@@ -0,0 +1,112 @@
+package chaos
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// KeyMetadata carries a call's decision key in gRPC metadata; the
+// interceptors pass it to WithKey. Callers that retry should append the
+// attempt so each attempt decides independently, yet reproducibly.
+const KeyMetadata = "x-chaos-key"
+
+// Decision sources, recorded as the chaos.decision_source span attribute.
+const (
+	// SourceSeeded decisions derive from the seed and the call's key, so
+	// replaying the same keys reproduces them.
+	SourceSeeded = "seeded"
+	// SourceRandom decisions come from math/rand: no seed is configured or
+	// the call has no key.
+	SourceRandom = "random"
+	// SourceAlways marks faults with probability 0, which fire on every call.
+	SourceAlways = "always"
+)
+
+type callKey struct {
+	key string
+	// n numbers the draws made for the call.
+	n atomic.Uint64
+}
+
+type callKeyCtx struct{}
+
+// WithKey sets the key fault decisions for the call in ctx derive from, for
+// example the request's idempotency key. It only takes effect with a seed
+// (SetSeed or Fault.Seed).
+func WithKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, callKeyCtx{}, &callKey{key: key})
+}
+
+// withMetadataKey applies WithKey with the KeyMetadata value in md, if any.
+func withMetadataKey(ctx context.Context, md metadata.MD) context.Context {
+	if v := md.Get(KeyMetadata); len(v) > 0 {
+		return WithKey(ctx, v[0])
+	}
+	return ctx
+}
+
+// SetSeed makes decisions for calls with a key deterministic; 0 restores
+// random decisions. Fault.Seed overrides it per fault.
+func (r *Registry) SetSeed(seed int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seed = seed
+}
+
+// source returns the uniform draws in [0, 1) for f on the call in ctx and
+// where they come from. Seeded draws hash the seed, service, target, key and
+// draw number, so a call's decisions only depend on the order of its own
+// draws, not on concurrent traffic.
+func (r *Registry) source(ctx context.Context, f Fault) (func() float64, string) {
+	seed := f.Seed
+	if seed == 0 {
+		r.mu.Lock()
+		seed = r.seed
+		r.mu.Unlock()
+	}
+	ck, _ := ctx.Value(callKeyCtx{}).(*callKey)
+	if seed == 0 || ck == nil {
+		return r.roll, SourceRandom
+	}
+	return func() float64 {
+		h := fnv.New64a()
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(seed))
+		h.Write(b[:])
+		for _, s := range []string{r.service, f.Target, ck.key} {
+			h.Write([]byte(s))
+			h.Write([]byte{0})
+		}
+		binary.LittleEndian.PutUint64(b[:], ck.n.Add(1)-1)
+		h.Write(b[:])
+		return float64(mix64(h.Sum64())>>11) / (1 << 53)
+	}, SourceSeeded
+}
+
+// mix64 is the splitmix64 finalizer; FNV alone spreads nearby inputs poorly.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// recordSource sets the decision source (and key, when seeded) on the span.
+func recordSource(ctx context.Context, src string) {
+	attrs := []attribute.KeyValue{attribute.String("chaos.decision_source", src)}
+	if ck, ok := ctx.Value(callKeyCtx{}).(*callKey); ok && src == SourceSeeded {
+		attrs = append(attrs, attribute.String("chaos.decision_key", ck.key))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
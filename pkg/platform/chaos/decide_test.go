@@ -0,0 +1,97 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// declines returns which of n keyed calls a 30% DECLINED fault hits.
+func declines(t *testing.T, seed int64, n int) string {
+	t.Helper()
+	r := newTestRegistry()
+	r.SetSeed(seed)
+	if _, err := r.Set(context.Background(), Fault{Target: "payments.charge", Error: "DECLINED", Probability: 0.3}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, n)
+	for i := range out {
+		ctx := WithKey(context.Background(), fmt.Sprintf("order-%d", i))
+		out[i] = '.'
+		if len(r.Hit(ctx, "payments.charge", Call{})) > 0 {
+			out[i] = 'x'
+		}
+	}
+	return string(out)
+}
+
+func TestSeed_ReproducesDecisions(t *testing.T) {
+	a, b := declines(t, 42, 200), declines(t, 42, 200)
+	if a != b {
+		t.Fatalf("same seed and keys decided differently:\n%s\n%s", a, b)
+	}
+	if c := declines(t, 43, 200); c == a {
+		t.Error("a different seed gave the same pattern")
+	}
+	hits := 0
+	for _, c := range a {
+		if c == 'x' {
+			hits++
+		}
+	}
+	if hits < 40 || hits > 80 {
+		t.Errorf("%d of 200 declined, want about 60", hits)
+	}
+}
+
+func TestSeed_DelayIsReproducible(t *testing.T) {
+	r := newTestRegistry()
+	f, err := r.Set(context.Background(), Fault{
+		Target:  "payments.charge",
+		Latency: 10 * time.Millisecond,
+		Model:   LatencyModel{Distribution: DistLogNormal, Sigma: 1},
+		Seed:    7,
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d1 := r.Delay(WithKey(context.Background(), "k1"), f)
+	d2 := r.Delay(WithKey(context.Background(), "k1"), f)
+	d3 := r.Delay(WithKey(context.Background(), "k2"), f)
+	if d1 != d2 || d1 == d3 {
+		t.Errorf("delays k1=%s k1=%s k2=%s, want equal for the same key only", d1, d2, d3)
+	}
+}
+
+func TestRoll_RecordsDecisionSource(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("test")
+	r := newTestRegistry()
+	r.SetSeed(1)
+	f := Fault{Target: "payments.charge", Error: "DECLINED", Probability: 0.5}
+
+	for _, key := range []string{"idem-1", ""} {
+		ctx, span := tracer.Start(WithKey(context.Background(), key), "charge")
+		r.Roll(ctx, f)
+		span.End()
+	}
+	spans := rec.Ended()
+	want := []map[attribute.Key]string{
+		{"chaos.decision_source": SourceSeeded, "chaos.decision_key": "idem-1"},
+		{"chaos.decision_source": SourceRandom},
+	}
+	for i, s := range spans {
+		got := map[attribute.Key]string{}
+		for _, kv := range s.Attributes() {
+			got[kv.Key] = kv.Value.AsString()
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want[i]) {
+			t.Errorf("span %d attributes %v, want %v", i, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package chaos
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Injection points of the gRPC interceptors.
+const (
+	TargetGRPCServer = "grpc.server"
+	TargetGRPCClient = "grpc.client"
+)
+
+// Fault errors understood by the gRPC interceptors besides status code names.
+const (
+	// ErrReset resets the connection the call arrived on (server) or fails
+	// the call as if it had been reset (client).
+	ErrReset = "RESET"
+	// ErrDrop lets a unary call run but loses its response; on streams each
+	// message is dropped with the fault's probability.
+	ErrDrop = "DROP"
+)
+
+// grpcErrors are the Fault.Error values of the gRPC targets.
+var grpcErrors = []string{
+	"CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED", "NOT_FOUND",
+	"ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED", "FAILED_PRECONDITION",
+	"ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED", "INTERNAL", "UNAVAILABLE", "DATA_LOSS",
+	"UNAUTHENTICATED", ErrReset, ErrDrop,
+}
+
+func registerGRPCTarget(reg *Registry, name, side string) {
+	reg.RegisterTarget(Target{
+		Name:        name,
+		Description: "every " + side + " gRPC call: latency, status codes, " + ErrReset + " and " + ErrDrop + "; match on method and metadata",
+		Errors:      grpcErrors,
+	})
+}
+
+// exempt reports methods the interceptors never fault: the chaos API itself,
+// so faults can always be cleared, and health checks, which would otherwise
+// take the backend out of every client's pool instead of failing calls.
+func exempt(method string) bool {
+	return strings.HasPrefix(method, "/chaos.Chaos/") || strings.HasPrefix(method, "/grpc.health.v1.Health/")
+}
+
+// plan is what the faults that fired on one call ask for.
+type plan struct {
+	err   error
+	reset bool
+	drop  bool
+	// streamDrops are DROP faults applied per message on streams.
+	streamDrops []Fault
+}
+
+// apply sleeps for the latency of each fault that fires and collects the
+// rest of their effects. DROP faults on streams are not rolled per call.
+// Decisions are keyed by the call's KeyMetadata.
+func apply(ctx context.Context, reg *Registry, target string, call Call, stream bool) plan {
+	var p plan
+	if exempt(call.Method) {
+		return p
+	}
+	ctx = withMetadataKey(ctx, call.Metadata)
+	for _, f := range reg.Match(target, call) {
+		if stream && f.Error == ErrDrop {
+			p.streamDrops = append(p.streamDrops, f)
+			continue
+		}
+		if !reg.Roll(ctx, f) {
+			continue
+		}
+		if err := Sleep(ctx, reg.Delay(ctx, f)); err != nil {
+			p.err = status.FromContextError(err).Err()
+			return p
+		}
+		switch f.Error {
+		case "":
+		case ErrReset:
+			p.reset = true
+		case ErrDrop:
+			p.drop = true
+		default:
+			var code grpccodes.Code
+			_ = code.UnmarshalJSON([]byte(`"` + f.Error + `"`))
+			p.err = status.Errorf(code, "chaos: fault %s injected %s", f.ID, f.Error)
+			return p
+		}
+	}
+	return p
+}
+
+var (
+	errReset   = status.Error(grpccodes.Unavailable, "chaos: connection reset")
+	errDropped = status.Error(grpccodes.Unavailable, "chaos: response dropped")
+)
+
+// UnaryServerInterceptor injects the faults set on grpc.server. RESET closes
+// the caller's connection when conns tracks it.
+func UnaryServerInterceptor(reg *Registry, conns *Conns) grpc.UnaryServerInterceptor {
+	registerGRPCTarget(reg, TargetGRPCServer, "incoming")
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		p := apply(ctx, reg, TargetGRPCServer, Call{Method: info.FullMethod, Metadata: md}, false)
+		switch {
+		case p.err != nil:
+			return nil, p.err
+		case p.reset:
+			conns.reset(ctx)
+			return nil, errReset
+		}
+		resp, err := handler(ctx, req)
+		if p.drop && err == nil {
+			return nil, errDropped
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streams; DROP faults
+// discard sent messages.
+func StreamServerInterceptor(reg *Registry, conns *Conns) grpc.StreamServerInterceptor {
+	registerGRPCTarget(reg, TargetGRPCServer, "incoming")
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		md, _ := metadata.FromIncomingContext(ctx)
+		p := apply(ctx, reg, TargetGRPCServer, Call{Method: info.FullMethod, Metadata: md}, true)
+		switch {
+		case p.err != nil:
+			return p.err
+		case p.reset:
+			conns.reset(ctx)
+			return errReset
+		}
+		if len(p.streamDrops) > 0 {
+			ss = &droppingServerStream{ServerStream: ss, ctx: withMetadataKey(ctx, md), reg: reg, drops: p.streamDrops}
+		}
+		return handler(srv, ss)
+	}
+}
+
+type droppingServerStream struct {
+	grpc.ServerStream
+	// ctx carries the decision key for the per-message rolls.
+	ctx   context.Context
+	reg   *Registry
+	drops []Fault
+}
+
+func (s *droppingServerStream) SendMsg(m interface{}) error {
+	for _, f := range s.drops {
+		if s.reg.Roll(s.ctx, f) {
+			return nil
+		}
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+// UnaryClientInterceptor injects the faults set on grpc.client before the
+// call is sent. RESET fails the call with Unavailable without sending it.
+func UnaryClientInterceptor(reg *Registry) grpc.UnaryClientInterceptor {
+	registerGRPCTarget(reg, TargetGRPCClient, "outgoing")
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		p := apply(ctx, reg, TargetGRPCClient, Call{Method: method, Metadata: md}, false)
+		switch {
+		case p.err != nil:
+			return p.err
+		case p.reset:
+			return errReset
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if p.drop && err == nil {
+			return errDropped
+		}
+		return err
+	}
+}
+
+// StreamClientInterceptor is UnaryClientInterceptor for streams; DROP faults
+// discard received messages.
+func StreamClientInterceptor(reg *Registry) grpc.StreamClientInterceptor {
+	registerGRPCTarget(reg, TargetGRPCClient, "outgoing")
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		p := apply(ctx, reg, TargetGRPCClient, Call{Method: method, Metadata: md}, true)
+		switch {
+		case p.err != nil:
+			return nil, p.err
+		case p.reset:
+			return nil, errReset
+		}
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil || len(p.streamDrops) == 0 {
+			return cs, err
+		}
+		return &droppingClientStream{ClientStream: cs, ctx: withMetadataKey(ctx, md), reg: reg, drops: p.streamDrops}, nil
+	}
+}
+
+type droppingClientStream struct {
+	grpc.ClientStream
+	ctx   context.Context
+	reg   *Registry
+	drops []Fault
+}
+
+func (s *droppingClientStream) RecvMsg(m interface{}) error {
+	for {
+		if err := s.ClientStream.RecvMsg(m); err != nil {
+			return err
+		}
+		dropped := false
+		for _, f := range s.drops {
+			if s.reg.Roll(s.ctx, f) {
+				dropped = true
+				break
+			}
+		}
+		if !dropped {
+			return nil
+		}
+	}
+}
+
+// Conns tracks the server's accepted connections so RESET faults can abort
+// the one a call arrived on.
+type Conns struct {
+	mu    sync.Mutex
+	conns map[string]net.Conn
+}
+
+// NewConns returns an empty tracker.
+func NewConns() *Conns {
+	return &Conns{conns: make(map[string]net.Conn)}
+}
+
+// Listener wraps l so its connections are tracked.
+func (c *Conns) Listener(l net.Listener) net.Listener {
+	return &trackingListener{Listener: l, conns: c}
+}
+
+// reset aborts the connection of the call in ctx with a TCP RST.
+func (c *Conns) reset(ctx context.Context) {
+	p, ok := peer.FromContext(ctx)
+	if c == nil || !ok || p.Addr == nil {
+		return
+	}
+	c.mu.Lock()
+	conn := c.conns[p.Addr.String()]
+	c.mu.Unlock()
+	if conn == nil {
+		return
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	_ = conn.Close()
+}
+
+type trackingListener struct {
+	net.Listener
+	conns *Conns
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	key := conn.RemoteAddr().String()
+	l.conns.mu.Lock()
+	l.conns.conns[key] = conn
+	l.conns.mu.Unlock()
+	return &trackedConn{Conn: conn, conns: l.conns, key: key}, nil
+}
+
+type trackedConn struct {
+	net.Conn
+	conns *Conns
+	key   string
+	once  sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(func() {
+		c.conns.mu.Lock()
+		if c.conns.conns[c.key] == c.Conn {
+			delete(c.conns.conns, c.key)
+		}
+		c.conns.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,133 @@
+package chaos
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reliability-lab/gen/payments"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type countingPayments struct {
+	payments.UnimplementedPaymentsServer
+	calls atomic.Int32
+}
+
+func (s *countingPayments) Charge(context.Context, *payments.ChargeRequest) (*payments.ChargeResponse, error) {
+	s.calls.Add(1)
+	return &payments.ChargeResponse{Success: true, Code: "APPROVED"}, nil
+}
+
+// startPayments serves a fake payments service over TCP with the server
+// interceptors and returns a client with the client interceptors.
+func startPayments(t *testing.T) (server, client *Registry, impl *countingPayments, pc payments.PaymentsClient) {
+	t.Helper()
+	server, client = NewRegistry("payments"), NewRegistry("gateway")
+	conns := NewConns()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptor(server, conns)))
+	impl = &countingPayments{}
+	payments.RegisterPaymentsServer(srv, impl)
+	go func() { _ = srv.Serve(conns.Listener(lis)) }()
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(client)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return server, client, impl, payments.NewPaymentsClient(cc)
+}
+
+func charge(pc payments.PaymentsClient, md ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, md...)
+	_, err := pc.Charge(ctx, &payments.ChargeRequest{OrderId: "o1"})
+	return err
+}
+
+func TestInterceptor_StatusMatchesMethodAndMetadata(t *testing.T) {
+	server, _, impl, pc := startPayments(t)
+	_, err := server.Set(context.Background(), Fault{
+		Target:   TargetGRPCServer,
+		Method:   "/payments.Payments/*",
+		Metadata: map[string]string{"x-tenant": "canary"},
+		Error:    "UNAVAILABLE",
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := charge(pc); err != nil {
+		t.Fatalf("call without matching metadata: %v", err)
+	}
+	if err := charge(pc, "x-tenant", "canary"); status.Code(err) != grpccodes.Unavailable {
+		t.Fatalf("canary call: got %v, want Unavailable", err)
+	}
+	if n := impl.calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
+
+func TestInterceptor_ClientDropRunsCallButLosesResponse(t *testing.T) {
+	_, client, impl, pc := startPayments(t)
+	if _, err := client.Set(context.Background(), Fault{Target: TargetGRPCClient, Method: "/payments.Payments/Charge", Error: ErrDrop}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	err := charge(pc)
+	if status.Code(err) != grpccodes.Unavailable || !strings.Contains(err.Error(), "dropped") {
+		t.Fatalf("got %v, want Unavailable (response dropped)", err)
+	}
+	if n := impl.calls.Load(); n != 1 {
+		t.Errorf("server saw %d calls, want 1", n)
+	}
+}
+
+func TestInterceptor_ServerResetAbortsConnection(t *testing.T) {
+	server, _, impl, pc := startPayments(t)
+	f, err := server.Set(context.Background(), Fault{Target: TargetGRPCServer, Error: ErrReset}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := charge(pc); status.Code(err) != grpccodes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+	server.Clear(context.Background(), f.ID)
+
+	// The client reconnects once the fault is gone.
+	if err := charge(pc); err != nil {
+		t.Fatalf("after clear: %v", err)
+	}
+	if n := impl.calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
+
+func TestInterceptor_LatencyRespectsDeadline(t *testing.T) {
+	server, _, impl, pc := startPayments(t)
+	if _, err := server.Set(context.Background(), Fault{Target: TargetGRPCServer, Latency: time.Minute}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := pc.Charge(ctx, &payments.ChargeRequest{})
+	if status.Code(err) != grpccodes.DeadlineExceeded || time.Since(start) > 5*time.Second {
+		t.Fatalf("got %v after %s, want DeadlineExceeded promptly", err, time.Since(start))
+	}
+	if n := impl.calls.Load(); n != 0 {
+		t.Errorf("handler ran %d times, want 0", n)
+	}
+}
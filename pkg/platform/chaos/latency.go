@@ -0,0 +1,193 @@
+package chaos
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Latency distributions of LatencyModel.
+const (
+	DistFixed     = "fixed"
+	DistUniform   = "uniform"
+	DistNormal    = "normal"
+	DistLogNormal = "lognormal"
+	DistPareto    = "pareto"
+	DistBimodal   = "bimodal"
+)
+
+// LatencyModel shapes the latency drawn for each call; Fault.Latency is the
+// central parameter of every distribution. The zero value is DistUniform,
+// i.e. Latency ± Jitter.
+type LatencyModel struct {
+	Distribution string
+	// StdDev is the standard deviation of DistNormal around Latency.
+	StdDev time.Duration
+	// Sigma is the shape of DistLogNormal, whose median is Latency.
+	Sigma float64
+	// Alpha is the shape of DistPareto, whose minimum is Latency.
+	Alpha float64
+	// Slow and SlowFraction describe the slow mode of DistBimodal.
+	Slow         time.Duration
+	SlowFraction float64
+	// Max bounds every drawn latency; 0 means unbounded.
+	Max time.Duration
+}
+
+// Schedule scales a fault's latency over time; the zero value always applies
+// it in full.
+type Schedule struct {
+	// Ramp grows the latency linearly from zero after the fault is set.
+	Ramp time.Duration
+	// Windows restrict the latency to times of day; none means all day.
+	Windows []Window
+	// Location of the windows; UTC when nil.
+	Location *time.Location
+}
+
+// Window is a daily time range, as offsets from midnight. An End before
+// Start wraps past midnight.
+type Window struct {
+	Start, End time.Duration
+	// Scale multiplies the latency inside the window; 0 means 1.
+	Scale float64
+}
+
+// ParseWindow parses "HH:MM" start and end times.
+func ParseWindow(start, end string, scale float64) (Window, error) {
+	s, err := parseClock(start)
+	if err != nil {
+		return Window{}, err
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return Window{}, err
+	}
+	return Window{Start: s, End: e, Scale: scale}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("time of day %q: want HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// FormatClock formats an offset from midnight as "HH:MM".
+func FormatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+func (m LatencyModel) validate(f Fault) error {
+	switch m.Distribution {
+	case "", DistFixed, DistUniform:
+	case DistNormal:
+		if m.StdDev <= 0 {
+			return fmt.Errorf("normal latency needs a positive stddev")
+		}
+	case DistLogNormal:
+		if f.Latency <= 0 || m.Sigma <= 0 {
+			return fmt.Errorf("lognormal latency needs a positive latency (the median) and sigma")
+		}
+	case DistPareto:
+		if f.Latency <= 0 || m.Alpha <= 0 {
+			return fmt.Errorf("pareto latency needs a positive latency (the minimum) and alpha")
+		}
+	case DistBimodal:
+		if m.Slow <= 0 || m.SlowFraction <= 0 || m.SlowFraction > 1 {
+			return fmt.Errorf("bimodal latency needs a positive slow latency and a slow fraction in (0, 1]")
+		}
+	default:
+		return fmt.Errorf("unknown latency distribution %q (want fixed, uniform, normal, lognormal, pareto or bimodal)", m.Distribution)
+	}
+	if m.StdDev < 0 || m.Slow < 0 || m.Max < 0 {
+		return fmt.Errorf("latency model durations must not be negative")
+	}
+	return nil
+}
+
+func (s Schedule) validate() error {
+	if s.Ramp < 0 {
+		return fmt.Errorf("ramp must not be negative")
+	}
+	for _, w := range s.Windows {
+		if w.Start == w.End {
+			return fmt.Errorf("window %s-%s is empty", FormatClock(w.Start), FormatClock(w.End))
+		}
+		if w.Scale < 0 {
+			return fmt.Errorf("window scale must not be negative")
+		}
+	}
+	return nil
+}
+
+// sample draws one latency from f's model; uniform returns values in [0, 1).
+func (m LatencyModel) sample(f Fault, uniform func() float64) time.Duration {
+	base, jitter := float64(f.Latency), float64(f.Jitter)
+	var d float64
+	switch m.Distribution {
+	case DistFixed:
+		d = base
+	case DistNormal:
+		d = base + normal(uniform)*float64(m.StdDev)
+	case DistLogNormal:
+		d = base * math.Exp(m.Sigma*normal(uniform))
+	case DistPareto:
+		// Inverse CDF; 1-u is in (0, 1] so the result is finite.
+		d = base / math.Pow(1-uniform(), 1/m.Alpha)
+	case DistBimodal:
+		if uniform() < m.SlowFraction {
+			base = float64(m.Slow)
+		}
+		d = base + (uniform()*2-1)*jitter
+	default:
+		d = base
+		if jitter > 0 {
+			d += (uniform()*2 - 1) * jitter
+		}
+	}
+	if m.Max > 0 {
+		d = math.Min(d, float64(m.Max))
+	}
+	// Guards the conversion against +Inf from extreme Pareto draws.
+	return time.Duration(math.Max(0, math.Min(d, float64(MaxTTL))))
+}
+
+// normal returns a standard normal deviate (Box-Muller).
+func normal(uniform func() float64) float64 {
+	u1 := 1 - uniform()
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*uniform())
+}
+
+// scale returns the multiplier of a fault's latency at now.
+func (s Schedule) scale(created, now time.Time) float64 {
+	k := 1.0
+	if s.Ramp > 0 {
+		k = math.Min(1, float64(now.Sub(created))/float64(s.Ramp))
+		k = math.Max(0, k)
+	}
+	if len(s.Windows) == 0 {
+		return k
+	}
+	loc := s.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	tod := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+	for _, w := range s.Windows {
+		in := tod >= w.Start && tod < w.End
+		if w.End < w.Start {
+			in = tod >= w.Start || tod < w.End
+		}
+		if in {
+			if w.Scale > 0 {
+				k *= w.Scale
+			}
+			return k
+		}
+	}
+	return 0
+}
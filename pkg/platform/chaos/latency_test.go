@@ -0,0 +1,109 @@
+package chaos
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+// quantiles draws n delays for f and returns its p50, p99 and max.
+func quantiles(t *testing.T, f Fault, n int) (p50, p99, top time.Duration) {
+	t.Helper()
+	r := newTestRegistry()
+	r.roll = rand.New(rand.NewSource(1)).Float64
+	f.Target = "payments.charge"
+	if err := r.validate(f, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	ds := make([]time.Duration, n)
+	for i := range ds {
+		ds[i] = r.Delay(context.Background(), f)
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	return ds[n/2], ds[n*99/100], ds[n-1]
+}
+
+func within(d, want time.Duration, tol float64) bool {
+	return float64(d) >= float64(want)*(1-tol) && float64(d) <= float64(want)*(1+tol)
+}
+
+func TestDelay_Distributions(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name          string
+		fault         Fault
+		p50, p99, max time.Duration
+	}{
+		{"fixed", Fault{Latency: 100 * ms, Jitter: 50 * ms, Model: LatencyModel{Distribution: DistFixed}}, 100 * ms, 100 * ms, 100 * ms},
+		{"uniform", Fault{Latency: 100 * ms, Jitter: 50 * ms}, 100 * ms, 149 * ms, 150 * ms},
+		// p99 of a normal is mean + 2.33 sd.
+		{"normal", Fault{Latency: 100 * ms, Model: LatencyModel{Distribution: DistNormal, StdDev: 10 * ms}}, 100 * ms, 123 * ms, 0},
+		// p99 of a log-normal is median * e^(2.33 sigma).
+		{"lognormal", Fault{Latency: 50 * ms, Model: LatencyModel{Distribution: DistLogNormal, Sigma: 1}}, 50 * ms, 513 * ms, 0},
+		// Quantile q of a Pareto is min / (1-q)^(1/alpha).
+		{"pareto", Fault{Latency: 10 * ms, Model: LatencyModel{Distribution: DistPareto, Alpha: 2}}, 14 * ms, 100 * ms, 0},
+		{"pareto capped", Fault{Latency: 10 * ms, Model: LatencyModel{Distribution: DistPareto, Alpha: 1, Max: 200 * ms}}, 20 * ms, 200 * ms, 200 * ms},
+		{"bimodal", Fault{Latency: 5 * ms, Model: LatencyModel{Distribution: DistBimodal, Slow: 800 * ms, SlowFraction: 0.1}}, 5 * ms, 800 * ms, 800 * ms},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p50, p99, top := quantiles(t, tt.fault, 20000)
+			if !within(p50, tt.p50, 0.1) || !within(p99, tt.p99, 0.1) {
+				t.Errorf("p50 %s p99 %s, want about %s and %s", p50, p99, tt.p50, tt.p99)
+			}
+			if tt.max > 0 && top > tt.max {
+				t.Errorf("max %s, want at most %s", top, tt.max)
+			}
+		})
+	}
+}
+
+func TestDelay_Schedule(t *testing.T) {
+	r := newTestRegistry()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+	peak, err := ParseWindow("11:30", "13:00", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	night, _ := ParseWindow("22:00", "06:00", 0)
+	f, err := r.Set(context.Background(), Fault{
+		Target:   "payments.charge",
+		Latency:  100 * time.Millisecond,
+		Schedule: Schedule{Ramp: time.Minute, Windows: []Window{peak, night}},
+	}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		after time.Duration
+		want  time.Duration
+	}{
+		{0, 0},
+		{30 * time.Second, 150 * time.Millisecond}, // half ramped, peak x3
+		{45 * time.Minute, 300 * time.Millisecond},
+		{70 * time.Minute, 0},                    // 13:10, between the windows
+		{11 * time.Hour, 100 * time.Millisecond}, // 23:00, night window wraps midnight
+	}
+	for _, s := range steps {
+		now = f.CreatedAt.Add(s.after)
+		if got := r.Delay(context.Background(), f); got != s.want {
+			t.Errorf("at %s: delay %s, want %s", now.Format("15:04:05"), got, s.want)
+		}
+	}
+}
+
+func TestSleep_RespectsContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := Sleep(ctx, time.Minute); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Sleep returned after %s", d)
+	}
+}
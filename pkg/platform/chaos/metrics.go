@@ -0,0 +1,24 @@
+package chaos
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	activeFaults = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "chaos_active_faults",
+			Help: "Active chaos faults by injection point",
+		},
+		[]string{"service", "target"},
+	)
+	injectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chaos_faults_injected_total",
+			Help: "Calls affected by a chaos fault",
+		},
+		[]string{"service", "target"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(activeFaults, injectedTotal)
+}
@@ -0,0 +1,380 @@
+// Package chaos keeps the runtime fault-injection state of a service. Faults
+// are set, listed and cleared through the Chaos gRPC service or the admin
+// HTTP API (see Server), expire after their TTL, and every change is written
+// to the audit log and recorded as a trace event.
+package chaos
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	DefaultTTL = 5 * time.Minute
+	MaxTTL     = time.Hour
+)
+
+// Fault is one active fault on an injection point.
+type Fault struct {
+	ID     string
+	Target string
+	// Method matches the call's method exactly, or by prefix when it ends in
+	// "*"; empty matches every call.
+	Method string
+	// Metadata must all be present on the call; an empty value matches any.
+	Metadata map[string]string
+	// Probability is the fraction of calls affected; 0 means every call.
+	Probability float64
+	Latency     time.Duration
+	// Jitter spreads the latency uniformly over Latency ± Jitter.
+	Jitter time.Duration
+	// Model and Schedule shape the latency; see Delay.
+	Model    LatencyModel
+	Schedule Schedule
+	// Seed overrides the registry's seed for this fault; see SetSeed.
+	Seed      int64
+	Error     string
+	Reason    string
+	Actor     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Target is an injection point a service checks with Hit.
+type Target struct {
+	Name        string
+	Description string
+	// Errors lists the values Fault.Error may take; empty allows latency only.
+	Errors []string
+}
+
+// Call describes the call checked against a fault's matchers. Its decision
+// key travels in the context; see WithKey.
+type Call struct {
+	Method   string
+	Metadata metadata.MD
+}
+
+// InvalidError reports a fault the registry refuses to set.
+type InvalidError struct{ Msg string }
+
+func (e *InvalidError) Error() string { return e.Msg }
+
+type entry struct {
+	fault Fault
+	timer *time.Timer
+}
+
+// Registry holds the targets and active faults of one service.
+type Registry struct {
+	service string
+
+	mu      sync.Mutex
+	targets map[string]Target
+	faults  map[string]*entry
+
+	seed int64
+	now  func() time.Time
+	roll func() float64
+}
+
+// NewRegistry returns an empty registry; service labels metrics and audit
+// entries.
+func NewRegistry(service string) *Registry {
+	return &Registry{
+		service: service,
+		targets: make(map[string]Target),
+		faults:  make(map[string]*entry),
+		now:     time.Now,
+		roll:    mathrand.Float64,
+	}
+}
+
+// RegisterTarget declares an injection point; faults on unknown targets are
+// rejected.
+func (r *Registry) RegisterTarget(t Target) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.targets[t.Name]; !ok {
+		activeFaults.WithLabelValues(r.service, t.Name).Set(0)
+	}
+	r.targets[t.Name] = t
+}
+
+// Targets returns the registered injection points sorted by name.
+func (r *Registry) Targets() []Target {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Target, 0, len(r.targets))
+	for _, t := range r.targets {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Set validates f, stamps it and schedules its expiry after ttl (DefaultTTL
+// when zero). A fault with the ID of an active one replaces it.
+func (r *Registry) Set(ctx context.Context, f Fault, ttl time.Duration) (Fault, error) {
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if err := r.validate(f, ttl); err != nil {
+		return Fault{}, err
+	}
+	if f.ID == "" {
+		f.ID = newID()
+	}
+	f.CreatedAt = r.now().UTC()
+	f.ExpiresAt = f.CreatedAt.Add(ttl)
+
+	r.mu.Lock()
+	if old, ok := r.faults[f.ID]; ok {
+		old.timer.Stop()
+		r.countLocked(old.fault.Target, -1)
+	}
+	e := &entry{fault: f}
+	e.timer = time.AfterFunc(ttl, func() { r.expire(e) })
+	r.faults[f.ID] = e
+	r.countLocked(f.Target, 1)
+	r.mu.Unlock()
+
+	r.audit(ctx, "set", f)
+	return f, nil
+}
+
+func (r *Registry) validate(f Fault, ttl time.Duration) error {
+	r.mu.Lock()
+	t, ok := r.targets[f.Target]
+	r.mu.Unlock()
+	switch {
+	case !ok:
+		return &InvalidError{fmt.Sprintf("unknown target %q", f.Target)}
+	case f.Probability < 0 || f.Probability > 1:
+		return &InvalidError{fmt.Sprintf("probability must be in [0, 1], got %g", f.Probability)}
+	case f.Latency < 0 || f.Jitter < 0:
+		return &InvalidError{"latency and jitter must not be negative"}
+	case f.Method != "" && !strings.HasPrefix(f.Method, "/"):
+		return &InvalidError{fmt.Sprintf("method must be a full method name like /pkg.Service/Method, got %q", f.Method)}
+	case ttl < 0 || ttl > MaxTTL:
+		return &InvalidError{fmt.Sprintf("ttl must be in (0, %s], got %s", MaxTTL, ttl)}
+	case f.Latency == 0 && f.Model.Distribution != DistBimodal && f.Error == "":
+		return &InvalidError{"fault needs a latency or an error"}
+	}
+	if err := f.Model.validate(f); err != nil {
+		return &InvalidError{err.Error()}
+	}
+	if err := f.Schedule.validate(); err != nil {
+		return &InvalidError{err.Error()}
+	}
+	if f.Error != "" && !contains(t.Errors, f.Error) {
+		return &InvalidError{fmt.Sprintf("target %s does not support error %q (want one of %v)", f.Target, f.Error, t.Errors)}
+	}
+	return nil
+}
+
+// List returns the active faults, oldest first.
+func (r *Registry) List() []Fault {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Fault, 0, len(r.faults))
+	for _, e := range r.faults {
+		out = append(out, e.fault)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Clear removes the fault with id, or every fault when id is empty, and
+// returns how many were removed.
+func (r *Registry) Clear(ctx context.Context, id string) int {
+	r.mu.Lock()
+	var cleared []Fault
+	for fid, e := range r.faults {
+		if id == "" || fid == id {
+			e.timer.Stop()
+			delete(r.faults, fid)
+			r.countLocked(e.fault.Target, -1)
+			cleared = append(cleared, e.fault)
+		}
+	}
+	r.mu.Unlock()
+	for _, f := range cleared {
+		r.audit(ctx, "clear", f)
+	}
+	return len(cleared)
+}
+
+// expire removes e unless it was already cleared or replaced.
+func (r *Registry) expire(e *entry) {
+	r.mu.Lock()
+	ok := r.faults[e.fault.ID] == e
+	if ok {
+		delete(r.faults, e.fault.ID)
+		r.countLocked(e.fault.Target, -1)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	ctx, span := otel.Tracer("chaos").Start(context.Background(), "chaos.ExpireFault")
+	defer span.End()
+	r.audit(ctx, "expire", e.fault)
+}
+
+// Match returns the active faults on target whose matchers accept call,
+// without rolling their probability.
+func (r *Registry) Match(target string, call Call) []Fault {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Fault
+	for _, e := range r.faults {
+		if e.fault.Target == target && e.fault.matches(call) {
+			out = append(out, e.fault)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Hit returns the faults on target that match call and fire on this call,
+// each after its own probability roll (see Roll).
+func (r *Registry) Hit(ctx context.Context, target string, call Call) []Fault {
+	var hits []Fault
+	for _, f := range r.Match(target, call) {
+		if r.Roll(ctx, f) {
+			hits = append(hits, f)
+		}
+	}
+	return hits
+}
+
+// Roll decides whether f fires, seeded by the call's key when there is one
+// (see WithKey), and records the decision source on the span in ctx. A fault
+// that fires is counted and adds a chaos.fault.injected event.
+func (r *Registry) Roll(ctx context.Context, f Fault) bool {
+	if f.Probability == 0 {
+		recordSource(ctx, SourceAlways)
+		return r.inject(ctx, f, SourceAlways)
+	}
+	next, src := r.source(ctx, f)
+	recordSource(ctx, src)
+	if next() >= f.Probability {
+		return false
+	}
+	return r.inject(ctx, f, src)
+}
+
+func (r *Registry) inject(ctx context.Context, f Fault, src string) bool {
+	injectedTotal.WithLabelValues(r.service, f.Target).Inc()
+	trace.SpanFromContext(ctx).AddEvent("chaos.fault.injected", trace.WithAttributes(
+		attribute.String("chaos.fault_id", f.ID),
+		attribute.String("chaos.target", f.Target),
+		attribute.String("chaos.method", f.Method),
+		attribute.Int64("chaos.latency_ms", f.Latency.Milliseconds()),
+		attribute.String("chaos.error", f.Error),
+		attribute.String("chaos.decision_source", src),
+	))
+	return true
+}
+
+// Delay returns the latency to inject for f on the call in ctx: a draw from
+// its Model, seeded like Roll, scaled by its Schedule at the current time.
+// Pass it to Sleep.
+func (r *Registry) Delay(ctx context.Context, f Fault) time.Duration {
+	next, _ := r.source(ctx, f)
+	d := f.Model.sample(f, next)
+	return time.Duration(float64(d) * f.Schedule.scale(f.CreatedAt, r.now()))
+}
+
+func (f Fault) matches(call Call) bool {
+	switch {
+	case f.Method == "":
+	case strings.HasSuffix(f.Method, "*"):
+		if !strings.HasPrefix(call.Method, strings.TrimSuffix(f.Method, "*")) {
+			return false
+		}
+	case f.Method != call.Method:
+		return false
+	}
+	for k, want := range f.Metadata {
+		got := call.Metadata.Get(k)
+		if len(got) == 0 || (want != "" && !contains(got, want)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sleep waits for d, returning ctx.Err() if ctx ends first.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Registry) countLocked(target string, delta float64) {
+	activeFaults.WithLabelValues(r.service, target).Add(delta)
+}
+
+// audit writes the change to the log (audit=chaos, for Loki) and as an event
+// on the span in ctx.
+func (r *Registry) audit(ctx context.Context, action string, f Fault) {
+	log.Info().Ctx(ctx).
+		Str("audit", "chaos").
+		Str("action", action).
+		Str("fault_id", f.ID).
+		Str("target", f.Target).
+		Str("method", f.Method).
+		Interface("metadata", f.Metadata).
+		Float64("probability", f.Probability).
+		Dur("latency", f.Latency).
+		Str("latency_model", f.Model.Distribution).
+		Str("error", f.Error).
+		Str("actor", f.Actor).
+		Str("reason", f.Reason).
+		Time("expires_at", f.ExpiresAt).
+		Msg("chaos fault " + action)
+	trace.SpanFromContext(ctx).AddEvent("chaos.fault."+action, trace.WithAttributes(
+		attribute.String("chaos.fault_id", f.ID),
+		attribute.String("chaos.target", f.Target),
+		attribute.String("chaos.actor", f.Actor),
+		attribute.String("chaos.reason", f.Reason),
+	))
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	chaospb "github.com/reliability-lab/gen/chaos"
+	"go.opentelemetry.io/otel"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ActorHeader names the caller in the audit log; the peer address is used
+// when it is missing. With WithAuthorize the authenticated principal is
+// recorded and the header only annotates it.
+const ActorHeader = "x-chaos-actor"
+
+// Authorize maps the Authorization value of a call, e.g. "Bearer <token>",
+// to the principal allowed to change faults; ok false rejects the call.
+type Authorize func(authorization string) (principal string, ok bool)
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithAuthorize makes every call present credentials authorize accepts.
+// Without it the Server trusts its callers.
+func WithAuthorize(authorize Authorize) ServerOption {
+	return func(s *Server) { s.authorize = authorize }
+}
+
+// Server serves a Registry as the chaos.Chaos gRPC service and, through
+// ServeHTTP, as JSON under /chaos/faults:
+//
+//	GET    /chaos/faults       ListFaults
+//	POST   /chaos/faults       SetFault (body: SetFaultRequest)
+//	DELETE /chaos/faults       ClearFaults, all
+//	DELETE /chaos/faults/{id}  ClearFaults, one
+type Server struct {
+	chaospb.UnimplementedChaosServer
+	reg       *Registry
+	authorize Authorize
+}
+
+// NewServer returns a Server for reg.
+func NewServer(reg *Registry, opts ...ServerOption) *Server {
+	s := &Server{reg: reg}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// caller authorizes the call in ctx and returns the actor to audit.
+func (s *Server) caller(ctx context.Context) (string, error) {
+	if s.authorize == nil {
+		return actorFromContext(ctx), nil
+	}
+	var authorization string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			authorization = v[0]
+		}
+	}
+	principal, ok := s.authorize(authorization)
+	if !ok {
+		return "", status.Error(grpccodes.Unauthenticated, "the chaos API needs an operator bearer token")
+	}
+	if claimed := headerActor(ctx); claimed != "" && claimed != principal {
+		return principal + " (" + claimed + ")", nil
+	}
+	return principal, nil
+}
+
+func (s *Server) SetFault(ctx context.Context, req *chaospb.SetFaultRequest) (*chaospb.SetFaultResponse, error) {
+	ctx, span := otel.Tracer("chaos").Start(ctx, "chaos.SetFault")
+	defer span.End()
+
+	actor, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pf := req.GetFault()
+	if pf == nil {
+		return nil, status.Error(grpccodes.InvalidArgument, "fault is required")
+	}
+	sched, err := scheduleFromProto(pf.GetLatencySchedule())
+	if err != nil {
+		return nil, status.Error(grpccodes.InvalidArgument, err.Error())
+	}
+	f := Fault{
+		ID:          pf.Id,
+		Target:      pf.Target,
+		Method:      pf.Method,
+		Metadata:    pf.Metadata,
+		Probability: pf.Probability,
+		Latency:     time.Duration(pf.LatencyMs) * time.Millisecond,
+		Jitter:      time.Duration(pf.LatencyJitterMs) * time.Millisecond,
+		Model:       modelFromProto(pf.GetLatencyModel()),
+		Schedule:    sched,
+		Seed:        pf.Seed,
+		Error:       pf.Error,
+		Reason:      pf.Reason,
+		Actor:       actor,
+	}
+	f, err = s.reg.Set(ctx, f, req.GetTtl().AsDuration())
+	var invalid *InvalidError
+	if errors.As(err, &invalid) {
+		return nil, status.Error(grpccodes.InvalidArgument, invalid.Msg)
+	}
+	if err != nil {
+		return nil, status.Error(grpccodes.Internal, err.Error())
+	}
+	return &chaospb.SetFaultResponse{Fault: toProto(f)}, nil
+}
+
+func (s *Server) ListFaults(ctx context.Context, _ *chaospb.ListFaultsRequest) (*chaospb.ListFaultsResponse, error) {
+	if _, err := s.caller(ctx); err != nil {
+		return nil, err
+	}
+	resp := &chaospb.ListFaultsResponse{}
+	for _, f := range s.reg.List() {
+		resp.Faults = append(resp.Faults, toProto(f))
+	}
+	for _, t := range s.reg.Targets() {
+		resp.Targets = append(resp.Targets, &chaospb.Target{Name: t.Name, Description: t.Description, Errors: t.Errors})
+	}
+	return resp, nil
+}
+
+func (s *Server) ClearFaults(ctx context.Context, req *chaospb.ClearFaultsRequest) (*chaospb.ClearFaultsResponse, error) {
+	ctx, span := otel.Tracer("chaos").Start(ctx, "chaos.ClearFaults")
+	defer span.End()
+
+	if _, err := s.caller(ctx); err != nil {
+		return nil, err
+	}
+	n := s.reg.Clear(ctx, req.GetId())
+	if n == 0 && req.GetId() != "" {
+		return nil, status.Errorf(grpccodes.NotFound, "fault %s not found", req.GetId())
+	}
+	return &chaospb.ClearFaultsResponse{Cleared: int32(n)}, nil
+}
+
+var faultJSON = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/chaos/faults"), "/")
+	md := metadata.Pairs(ActorHeader, httpActor(r))
+	if s.authorize != nil {
+		// The principal names the caller; only an explicit header annotates it.
+		md.Set(ActorHeader, r.Header.Get(ActorHeader))
+	}
+	if a := r.Header.Get("Authorization"); a != "" {
+		md.Set("authorization", a)
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+
+	var resp proto.Message
+	var err error
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		resp, err = s.ListFaults(ctx, &chaospb.ListFaultsRequest{})
+	case r.Method == http.MethodPost && id == "":
+		req := &chaospb.SetFaultRequest{}
+		body, rerr := io.ReadAll(io.LimitReader(r.Body, 1<<16))
+		if rerr == nil {
+			rerr = protojson.Unmarshal(body, req)
+		}
+		if rerr != nil {
+			writeError(w, http.StatusBadRequest, "invalid SetFaultRequest: "+rerr.Error())
+			return
+		}
+		resp, err = s.SetFault(ctx, req)
+	case r.Method == http.MethodDelete:
+		resp, err = s.ClearFaults(ctx, &chaospb.ClearFaultsRequest{Id: id})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch status.Code(err) {
+		case grpccodes.InvalidArgument:
+			code = http.StatusBadRequest
+		case grpccodes.NotFound:
+			code = http.StatusNotFound
+		case grpccodes.Unauthenticated:
+			code = http.StatusUnauthorized
+		}
+		writeError(w, code, status.Convert(err).Message())
+		return
+	}
+	b, _ := faultJSON.Marshal(resp)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+func httpActor(r *http.Request) string {
+	if a := r.Header.Get(ActorHeader); a != "" {
+		return a
+	}
+	return r.RemoteAddr
+}
+
+// headerActor is the ActorHeader of the call, "" without one.
+func headerActor(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(ActorHeader); len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func actorFromContext(ctx context.Context) string {
+	if a := headerActor(ctx); a != "" {
+		return a
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return "unknown"
+}
+
+func toProto(f Fault) *chaospb.Fault {
+	return &chaospb.Fault{
+		Id:              f.ID,
+		Target:          f.Target,
+		Method:          f.Method,
+		Metadata:        f.Metadata,
+		Probability:     f.Probability,
+		LatencyMs:       f.Latency.Milliseconds(),
+		LatencyJitterMs: f.Jitter.Milliseconds(),
+		LatencyModel:    modelToProto(f.Model),
+		LatencySchedule: scheduleToProto(f.Schedule),
+		Seed:            f.Seed,
+		Error:           f.Error,
+		Reason:          f.Reason,
+		Actor:           f.Actor,
+		CreatedAt:       timestamppb.New(f.CreatedAt),
+		ExpiresAt:       timestamppb.New(f.ExpiresAt),
+	}
+}
+
+func modelFromProto(m *chaospb.LatencyModel) LatencyModel {
+	if m == nil {
+		return LatencyModel{}
+	}
+	return LatencyModel{
+		Distribution: m.Distribution,
+		StdDev:       time.Duration(m.StddevMs) * time.Millisecond,
+		Sigma:        m.Sigma,
+		Alpha:        m.Alpha,
+		Slow:         time.Duration(m.SlowMs) * time.Millisecond,
+		SlowFraction: m.SlowFraction,
+		Max:          time.Duration(m.MaxMs) * time.Millisecond,
+	}
+}
+
+func modelToProto(m LatencyModel) *chaospb.LatencyModel {
+	if m == (LatencyModel{}) {
+		return nil
+	}
+	return &chaospb.LatencyModel{
+		Distribution: m.Distribution,
+		StddevMs:     m.StdDev.Milliseconds(),
+		Sigma:        m.Sigma,
+		Alpha:        m.Alpha,
+		SlowMs:       m.Slow.Milliseconds(),
+		SlowFraction: m.SlowFraction,
+		MaxMs:        m.Max.Milliseconds(),
+	}
+}
+
+func scheduleFromProto(s *chaospb.LatencySchedule) (Schedule, error) {
+	if s == nil {
+		return Schedule{}, nil
+	}
+	out := Schedule{Ramp: s.GetRamp().AsDuration()}
+	if s.TimeZone != "" {
+		loc, err := time.LoadLocation(s.TimeZone)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("time_zone: %w", err)
+		}
+		out.Location = loc
+	}
+	for _, w := range s.Windows {
+		win, err := ParseWindow(w.Start, w.End, w.Scale)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("windows: %w", err)
+		}
+		out.Windows = append(out.Windows, win)
+	}
+	return out, nil
+}
+
+func scheduleToProto(s Schedule) *chaospb.LatencySchedule {
+	if s.Ramp == 0 && len(s.Windows) == 0 && s.Location == nil {
+		return nil
+	}
+	out := &chaospb.LatencySchedule{}
+	if s.Ramp > 0 {
+		out.Ramp = durationpb.New(s.Ramp)
+	}
+	if s.Location != nil {
+		out.TimeZone = s.Location.String()
+	}
+	for _, w := range s.Windows {
+		out.Windows = append(out.Windows, &chaospb.TimeWindow{Start: FormatClock(w.Start), End: FormatClock(w.End), Scale: w.Scale})
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Config holds the settings every service shares. Embed it in a service's
+// config struct with the section tag so config.Load fills it, then pass it to
+// New with WithConfig.
+type Config struct {
+	LogLevel         string        `conf:"log_level" env:"LOG_LEVEL" default:"info" desc:"Log level: trace, debug, info, warn or error."`
+	LogFormat        string        `conf:"log_format" env:"LOG_FORMAT" default:"console" desc:"Log output: console or json (for Loki)."`
+	OTLPEndpoint     string        `conf:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" default:"otel-collector:4317" desc:"OTLP gRPC collector for traces and metrics."`
+	OTLPLogsEndpoint string        `conf:"otlp_logs_endpoint" env:"OTEL_EXPORTER_OTLP_LOGS_ENDPOINT" desc:"OTLP HTTP collector for logs, e.g. otel-collector:4318. Empty leaves logs on stderr for Promtail." example:"otel-collector:4318"`
+	ShutdownTimeout  time.Duration `conf:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"10s" desc:"Bound on the whole graceful shutdown."`
+	DrainDelay       time.Duration `conf:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY" default:"0s" desc:"Keep serving this long after /readyz starts failing."`
+	ChaosAPI         bool          `conf:"chaos_api" env:"CHAOS_API_ENABLED" default:"false" desc:"Serve the chaos fault-injection API (gRPC chaos.Chaos and /chaos/faults on the admin port) to operator principals from AUTH_TOKENS."`
+	AuthTokens       []string      `conf:"auth_tokens" env:"AUTH_TOKENS" secret:"true" desc:"Comma-separated token=principal pairs accepted as Authorization: Bearer <token>. operator:<name> principals may act for others and call the chaos API." example:"change-me=operator:alice"`
+	ChaosSeed        int64         `conf:"chaos_seed" env:"CHAOS_SEED" default:"0" desc:"Derive fault decisions from this seed and each request's idempotency key, so runs are reproducible. 0 decides randomly."`
+}
+
+// DefaultConfig is what New uses without WithConfig.
+func DefaultConfig() Config {
+	return Config{LogLevel: "info", LogFormat: "console", OTLPEndpoint: "otel-collector:4317", ShutdownTimeout: defaultShutdownTimeout}
+}
+
+// Validate is called by config.Load.
+func (c *Config) Validate() error {
+	if level, err := zerolog.ParseLevel(strings.ToLower(c.LogLevel)); err != nil || level == zerolog.NoLevel {
+		return fmt.Errorf("LOG_LEVEL: unknown level %q", c.LogLevel)
+	}
+	if c.LogFormat != "console" && c.LogFormat != "json" {
+		return fmt.Errorf("LOG_FORMAT: want console or json, got %q", c.LogFormat)
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_TIMEOUT must be positive, got %s", c.ShutdownTimeout)
+	}
+	if c.DrainDelay < 0 || c.DrainDelay >= c.ShutdownTimeout {
+		return fmt.Errorf("SHUTDOWN_DRAIN_DELAY must be in [0, SHUTDOWN_TIMEOUT), got %s", c.DrainDelay)
+	}
+	auth, err := NewAuthenticator(c.AuthTokens)
+	if err != nil {
+		return err
+	}
+	if c.ChaosAPI && !auth.HasOperator() {
+		return fmt.Errorf("CHAOS_API_ENABLED needs an operator:<name> principal in AUTH_TOKENS")
+	}
+	return nil
+}
+
+// WithConfig applies the shared settings: logging, the OTLP endpoint and the
+// shutdown timings. Later WithShutdownTimeout/WithDrainDelay options win.
+func WithConfig(c Config) Option {
+	return func(o *options) {
+		o.config = c
+		o.shutdownTimeout = c.ShutdownTimeout
+		o.drainDelay = c.DrainDelay
+	}
+}
+
+// ValidPort reports an error unless port is a usable TCP port; services call
+// it from their Validate methods.
+func ValidPort(name string, port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%s: port %d out of range 1-65535", name, port)
+	}
+	return nil
+}
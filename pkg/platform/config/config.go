@@ -0,0 +1,413 @@
+// Package config loads a service's typed configuration from struct tags.
+//
+// Values come from, in increasing precedence: the `default` tag, a YAML or
+// TOML file (--config or CONFIG_FILE), environment variables (`env` tag) and
+// command-line flags. Supported tags on exported fields:
+//
+//	conf:"db_url"        file key; the flag is the key with "_" → "-" (--db-url)
+//	env:"ORDERS_DB_URL"  environment variable
+//	default:"..."        value used when no source sets the field
+//	desc:"..."           help text for --help and .env.example
+//	example:"..."        .env.example value for fields without a default
+//	required:"true"      some source must set the field
+//	secret:"true"        redacted by --print-config
+//	section:"..."        on a nested struct: its .env.example heading
+//
+// Nested structs add their conf key as a prefix ("db.url", --db.url); embedded
+// structs are inlined. After loading, every struct implementing
+// interface{ Validate() error } is validated.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// Result records where each value came from and which print mode, if any,
+// was requested on the command line.
+type Result struct {
+	// File is the config file that was read, if any.
+	File            string
+	PrintConfig     bool
+	PrintEnvExample bool
+
+	fields []*field
+}
+
+type field struct {
+	key      string
+	env      string
+	def      string
+	hasDef   bool
+	desc     string
+	example  string
+	section  string
+	required bool
+	secret   bool
+	value    reflect.Value
+	source   string
+}
+
+func (f *field) flagName() string { return strings.ReplaceAll(f.key, "_", "-") }
+
+// Load fills cfg, a pointer to a struct, from defaults, the config file, env
+// (looked up with lookupEnv) and args, then validates it. If print-env-example
+// was requested validation is skipped, since the example documents unset fields.
+func Load(cfg any, args []string, lookupEnv func(string) (string, bool)) (*Result, error) {
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("config: Load needs a pointer to a struct")
+	}
+	fields, err := collect(rv.Elem(), "", "")
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{fields: fields}
+
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := fs.String("config", "", "YAML or TOML config file (env CONFIG_FILE)")
+	fs.BoolVar(&res.PrintConfig, "print-config", false, "print the effective configuration with secrets redacted, then exit")
+	fs.BoolVar(&res.PrintEnvExample, "print-env-example", false, "print .env.example lines for this service, then exit")
+	flagValues := make(map[*field]string)
+	for _, f := range fields {
+		f := f
+		fs.Func(f.flagName(), usage(f), func(s string) error {
+			flagValues[f] = s
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, f := range fields {
+		if f.hasDef {
+			errs = append(errs, f.set(f.def, sourceDefault))
+		}
+	}
+
+	res.File = *configFile
+	if res.File == "" {
+		res.File, _ = lookupEnv("CONFIG_FILE")
+	}
+	if res.File != "" {
+		values, err := readFile(res.File)
+		if err != nil {
+			return nil, err
+		}
+		byKey := make(map[string]*field, len(fields))
+		for _, f := range fields {
+			byKey[f.key] = f
+		}
+		for k, v := range values {
+			f, ok := byKey[k]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown key %q", res.File, k))
+				continue
+			}
+			errs = append(errs, f.set(v, sourceFile))
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if v, ok := lookupEnv(f.env); ok {
+			errs = append(errs, f.set(v, sourceEnv))
+		}
+	}
+	for f, v := range flagValues {
+		errs = append(errs, f.set(v, sourceFlag))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return res, err
+	}
+	if res.PrintEnvExample {
+		return res, nil
+	}
+	return res, validate(rv.Elem(), fields)
+}
+
+// MustLoad is Load for main: it reads os.Args and the environment, handles
+// --print-config and --print-env-example, and exits on invalid configuration.
+func MustLoad(cfg any, name string) *Result {
+	res, err := Load(cfg, os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if res != nil && res.PrintEnvExample {
+		if err := WriteEnvExample(os.Stdout, name, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if res != nil && res.PrintConfig {
+		res.Print(os.Stdout, name)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: invalid configuration:\n%v\n", name, err)
+		os.Exit(2)
+	}
+	if res.PrintConfig {
+		os.Exit(0)
+	}
+	return res
+}
+
+func usage(f *field) string {
+	u := f.desc
+	if f.env != "" {
+		u += " (env " + f.env + ")"
+	}
+	if f.required {
+		u += " [required]"
+	}
+	return strings.TrimSpace(u)
+}
+
+func collect(v reflect.Value, prefix, section string) ([]*field, error) {
+	var out []*field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if sf.Type.Kind() == reflect.Struct {
+			p, sec := prefix, section
+			if s := sf.Tag.Get("section"); s != "" {
+				sec = s
+			}
+			if !sf.Anonymous {
+				key := sf.Tag.Get("conf")
+				if key == "" {
+					return nil, fmt.Errorf("config: nested struct %s needs a conf tag", sf.Name)
+				}
+				p = prefix + key + "."
+			}
+			nested, err := collect(fv, p, sec)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, nested...)
+			continue
+		}
+		key := sf.Tag.Get("conf")
+		if key == "" {
+			continue
+		}
+		if !settable(sf.Type) {
+			return nil, fmt.Errorf("config: field %s has unsupported type %s", sf.Name, sf.Type)
+		}
+		def, hasDef := sf.Tag.Lookup("default")
+		out = append(out, &field{
+			key:      prefix + key,
+			env:      sf.Tag.Get("env"),
+			def:      def,
+			hasDef:   hasDef,
+			desc:     sf.Tag.Get("desc"),
+			example:  sf.Tag.Get("example"),
+			section:  section,
+			required: sf.Tag.Get("required") == "true",
+			secret:   sf.Tag.Get("secret") == "true",
+			value:    fv,
+		})
+	}
+	return out, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func settable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+func (f *field) set(s, source string) error {
+	v := f.value
+	var err error
+	switch {
+	case v.Type() == durationType:
+		var d time.Duration
+		if d, err = time.ParseDuration(s); err == nil {
+			v.SetInt(int64(d))
+		}
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			v.SetBool(b)
+		}
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(s, 10, 64); err == nil {
+			v.SetInt(n)
+		}
+	case v.Kind() == reflect.Float64:
+		var x float64
+		if x, err = strconv.ParseFloat(s, 64); err == nil {
+			v.SetFloat(x)
+		}
+	case v.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid value %q from %s", f.name(), s, f.describeSource(source))
+	}
+	f.source = source
+	return nil
+}
+
+func (f *field) name() string {
+	if f.env != "" {
+		return f.env + " (" + f.key + ")"
+	}
+	return f.key
+}
+
+func (f *field) describeSource(source string) string {
+	switch source {
+	case sourceEnv:
+		return "env " + f.env
+	case sourceFlag:
+		return "flag --" + f.flagName()
+	}
+	return source
+}
+
+// readFile parses a YAML or TOML file into flattened "a.b" keys.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported extension (want .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	out := make(map[string]string)
+	flatten("", raw, out)
+	return out, nil
+}
+
+func flatten(prefix string, m map[string]any, out map[string]string) {
+	for k, v := range m {
+		key := prefix + k
+		switch x := v.(type) {
+		case map[string]any:
+			flatten(key+".", x, out)
+		case []any:
+			items := make([]string, len(x))
+			for i, item := range x {
+				items[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		default:
+			out[key] = fmt.Sprint(x)
+		}
+	}
+}
+
+type validator interface{ Validate() error }
+
+func validate(v reflect.Value, fields []*field) error {
+	var errs []error
+	for _, f := range fields {
+		if f.required && f.source == "" {
+			errs = append(errs, fmt.Errorf("%s is required", f.name()))
+		}
+	}
+	errs = append(errs, validateStructs(v)...)
+	return errors.Join(errs...)
+}
+
+// validateStructs calls Validate on v and its nested structs, innermost first.
+func validateStructs(v reflect.Value) []error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).IsExported() && v.Field(i).Kind() == reflect.Struct {
+			errs = append(errs, validateStructs(v.Field(i))...)
+		}
+	}
+	if val, ok := v.Addr().Interface().(validator); ok {
+		if err := val.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Print writes the effective configuration as YAML with the source of each
+// value in a comment. Secrets are redacted.
+func (r *Result) Print(w io.Writer, name string) {
+	fmt.Fprintf(w, "# %s configuration (secrets redacted)\n", name)
+	if r.File != "" {
+		fmt.Fprintf(w, "# file: %s\n", r.File)
+	}
+	for _, f := range r.fields {
+		val := formatValue(f.value)
+		if f.secret {
+			val = Redact(val)
+		}
+		src := f.source
+		switch src {
+		case "":
+			src = "unset"
+		case sourceEnv, sourceFlag:
+			src = f.describeSource(src)
+		}
+		fmt.Fprintf(w, "%s: %s  # %s\n", f.key, strconv.Quote(val), src)
+	}
+}
+
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const redacted = "******"
+
+// Redact hides a secret value. URLs keep everything but the password so
+// --print-config still shows which host and database a service uses.
+func Redact(v string) string {
+	if v == "" {
+		return ""
+	}
+	if u, err := url.Parse(v); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "redacted")
+			return u.String()
+		}
+	}
+	return redacted
+}
+
+// WriteEnvExample writes .env.example lines for cfg, one paragraph per
+// section. Fields with a default are commented out; required fields are set
+// to their example value. Identical sections from different services (the
+// shared platform settings) can be de-duplicated by paragraph.
+func WriteEnvExample(w io.Writer, name string, cfg any) error {
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: WriteEnvExample needs a pointer to a struct")
+	}
+	fields, err := collect(rv.Elem(), "", "")
+	if err != nil {
+		return err
+	}
+	var sections []string
+	bySection := make(map[string][]*field)
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		sec := f.section
+		if sec == "" {
+			sec = name
+		}
+		if _, ok := bySection[sec]; !ok {
+			sections = append(sections, sec)
+		}
+		bySection[sec] = append(bySection[sec], f)
+	}
+	for i, sec := range sections {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# --- %s ---\n", sec)
+		for _, f := range bySection[sec] {
+			if f.desc != "" {
+				fmt.Fprintf(w, "# %s\n", f.desc)
+			}
+			switch {
+			case f.hasDef:
+				fmt.Fprintf(w, "# %s=%s\n", f.env, f.def)
+			case f.required:
+				fmt.Fprintf(w, "%s=%s\n", f.env, f.example)
+			default:
+				fmt.Fprintf(w, "# %s=%s\n", f.env, strings.TrimSpace(f.example))
+			}
+		}
+	}
+	return nil
+}
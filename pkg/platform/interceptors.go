@@ -0,0 +1,109 @@
+package platform
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// unaryInterceptors are tracing, then RPC metrics, then panic recovery, so a
+// recovered panic is still counted and traced as Internal, then chaos faults,
+// which are counted and traced like real failures.
+func unaryInterceptors(service string, reg *chaos.Registry, conns *chaos.Conns) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		otelgrpc.UnaryServerInterceptor(),
+		metricsUnaryInterceptor(service),
+		recoveryUnaryInterceptor(),
+		chaos.UnaryServerInterceptor(reg, conns),
+	}
+}
+
+func streamInterceptors(service string, reg *chaos.Registry, conns *chaos.Conns) []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		otelgrpc.StreamServerInterceptor(),
+		metricsStreamInterceptor(service),
+		recoveryStreamInterceptor(),
+		chaos.StreamServerInterceptor(reg, conns),
+	}
+}
+
+// DialOptions are the client options for calls between services: plaintext
+// transport with tracing on unary and streaming calls.
+func DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+	}
+}
+
+// DialOptions are the package DialOptions plus the grpc.client chaos
+// interceptors, so outgoing calls can be faulted through the chaos API, and
+// the service's name as SourceHeader.
+func (s *Service) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor(), sourceUnaryClientInterceptor(s.Name), chaos.UnaryClientInterceptor(s.Chaos)),
+		grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor(), sourceStreamClientInterceptor(s.Name), chaos.StreamClientInterceptor(s.Chaos)),
+	}
+}
+
+func observeRPC(service, fullMethod string, start time.Time, err error) {
+	method := strings.TrimPrefix(fullMethod, "/")
+	rpcRequestsTotal.WithLabelValues(service, method, status.Code(err).String()).Inc()
+	rpcRequestDurationSeconds.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+}
+
+func metricsUnaryInterceptor(service string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeRPC(service, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func metricsStreamInterceptor(service string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeRPC(service, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func recoveryUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recovered(ctx, info.FullMethod, p)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func recoveryStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recovered(ss.Context(), info.FullMethod, p)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recovered(ctx context.Context, method string, p interface{}) error {
+	log.Error().Ctx(ctx).Str("method", method).Interface("panic", p).Bytes("stack", debug.Stack()).Msg("panic in rpc handler")
+	return status.Error(grpccodes.Internal, "internal error")
+}
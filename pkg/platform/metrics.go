@@ -1,4 +1,4 @@
-package main
+package platform
 
 import (
 	"github.com/prometheus/client_golang/prometheus"
@@ -20,14 +20,15 @@ var (
 		},
 		[]string{"service", "method"},
 	)
-	paymentsDeclinedTotal = prometheus.NewCounter(
-		prometheus.CounterOpts{
-			Name: "payments_declined_total",
-			Help: "Total payment declines",
+	readinessCheckStatus = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "readiness_check_status",
+			Help: "Last readiness check result (1 ok, 0 failing)",
 		},
+		[]string{"service", "check", "critical"},
 	)
 )
 
 func init() {
-	prometheus.MustRegister(rpcRequestsTotal, rpcRequestDurationSeconds, paymentsDeclinedTotal)
+	prometheus.MustRegister(rpcRequestsTotal, rpcRequestDurationSeconds, readinessCheckStatus)
 }
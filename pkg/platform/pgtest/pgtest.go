@@ -0,0 +1,44 @@
+// Package pgtest starts throwaway Postgres databases for the services'
+// Postgres-backed tests.
+package pgtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Image is the Postgres the services run against in compose.
+const Image = "postgres:16-alpine"
+
+// Start runs an empty Postgres database for the rest of t and returns its
+// connection string. It skips t when Docker is not available, so the tests
+// that need none still run.
+func Start(t *testing.T) string {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+	c, err := postgres.RunContainer(ctx,
+		testcontainers.WithImage(Image),
+		postgres.WithDatabase("testdb"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		// Postgres restarts once after its init scripts; the second line is
+		// the server that stays up.
+		testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections").
+			WithOccurrence(2).WithStartupTimeout(time.Minute)),
+	)
+	if err != nil {
+		t.Fatalf("postgres: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Terminate(ctx) })
+	url, err := c.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("postgres: %v", err)
+	}
+	return url
+}
@@ -0,0 +1,409 @@
+// Package platform holds the process plumbing shared by every Reliability Lab
+// service: telemetry, a gRPC server with the standard interceptors, an admin
+// HTTP server (/healthz, /readyz, /metrics) and ordered graceful shutdown.
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	chaospb "github.com/reliability-lab/gen/chaos"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	defaultShutdownTimeout   = 10 * time.Second
+	telemetryFlushTimeout    = 5 * time.Second
+	defaultReadinessTimeout  = 2 * time.Second
+	defaultReadinessInterval = 5 * time.Second
+)
+
+// Option configures a Service.
+type Option func(*options)
+
+type options struct {
+	grpcAddr          string
+	grpcListener      net.Listener
+	adminAddr         string
+	unary             []grpc.UnaryServerInterceptor
+	stream            []grpc.StreamServerInterceptor
+	serverOpts        []grpc.ServerOption
+	shutdownTimeout   time.Duration
+	drainDelay        time.Duration
+	readinessInterval time.Duration
+	telemetry         bool
+	keepLogger        bool
+	config            Config
+}
+
+// WithGRPC serves Service.GRPC on addr (e.g. ":50051").
+func WithGRPC(addr string) Option {
+	return func(o *options) { o.grpcAddr = addr }
+}
+
+// WithGRPCListener serves Service.GRPC on lis instead of an address, e.g. an
+// in-process bufconn listener in tests.
+func WithGRPCListener(lis net.Listener) Option {
+	return func(o *options) {
+		o.grpcListener = lis
+		o.grpcAddr = lis.Addr().String()
+	}
+}
+
+// WithAdmin serves the admin mux on addr. Without it the caller mounts
+// Service.Admin on its own server.
+func WithAdmin(addr string) Option {
+	return func(o *options) { o.adminAddr = addr }
+}
+
+// WithUnaryInterceptors appends interceptors after the standard ones.
+func WithUnaryInterceptors(i ...grpc.UnaryServerInterceptor) Option {
+	return func(o *options) { o.unary = append(o.unary, i...) }
+}
+
+// WithStreamInterceptors appends interceptors after the standard ones.
+func WithStreamInterceptors(i ...grpc.StreamServerInterceptor) Option {
+	return func(o *options) { o.stream = append(o.stream, i...) }
+}
+
+// WithServerOptions passes extra options to grpc.NewServer.
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(o *options) { o.serverOpts = append(o.serverOpts, opts...) }
+}
+
+// WithShutdownTimeout bounds the whole shutdown sequence. Default 10s.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(o *options) { o.shutdownTimeout = d }
+}
+
+// WithDrainDelay keeps serving for d after /readyz starts failing, so load
+// balancers stop routing to the instance before its listeners close.
+func WithDrainDelay(d time.Duration) Option {
+	return func(o *options) { o.drainDelay = d }
+}
+
+// WithReadinessInterval sets how often readiness checks run. Default 5s.
+func WithReadinessInterval(d time.Duration) Option {
+	return func(o *options) { o.readinessInterval = d }
+}
+
+// WithoutTelemetry skips exporter setup; used by tests.
+func WithoutTelemetry() Option {
+	return func(o *options) { o.telemetry = false }
+}
+
+// WithoutLogging leaves the global logger alone, for several services in one
+// process.
+func WithoutLogging() Option {
+	return func(o *options) { o.keepLogger = true }
+}
+
+type hook struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// Service is one running process. Register handlers on GRPC and Admin, add
+// readiness checks and shutdown hooks, then call Run.
+type Service struct {
+	Name string
+	// GRPC is nil unless WithGRPC was given.
+	GRPC *grpc.Server
+	// Health serves grpc.health.v1 on GRPC. Its status follows the critical
+	// readiness checks; it is nil without WithGRPC.
+	Health *health.Server
+	// Admin serves /healthz, /readyz and /metrics; services may add routes.
+	Admin *http.ServeMux
+	// Chaos holds the runtime faults. Services register their injection
+	// points on it; with Config.ChaosAPI on and an operator token configured
+	// it is served as chaos.Chaos on GRPC and under /chaos/faults on Admin,
+	// to operator principals only.
+	Chaos *chaos.Registry
+	// Auth resolves the bearer tokens of Config.AuthTokens.
+	Auth *Authenticator
+
+	opts      options
+	telemetry func(context.Context) error
+	draining  atomic.Bool
+
+	readiness *readiness
+	// conns lets grpc.server RESET faults abort the caller's connection.
+	conns *chaos.Conns
+
+	mu      sync.Mutex
+	onStop  []hook
+	onClose []hook
+	servers []*http.Server
+}
+
+// New configures logging and telemetry for the named service and builds its
+// servers. Telemetry failures are logged, not fatal.
+func New(ctx context.Context, name string, opts ...Option) *Service {
+	o := options{shutdownTimeout: defaultShutdownTimeout, readinessInterval: defaultReadinessInterval, telemetry: true, config: DefaultConfig()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !o.keepLogger {
+		setupLogging(name, o.config, nil)
+	}
+
+	s := &Service{Name: name, Admin: http.NewServeMux(), Chaos: chaos.NewRegistry(name), conns: chaos.NewConns(), opts: o, readiness: newReadiness(), telemetry: func(context.Context) error { return nil }}
+	auth, err := NewAuthenticator(o.config.AuthTokens)
+	if err != nil {
+		// Config.Validate reports this at load; fail closed if it was skipped.
+		log.Error().Err(err).Msg("AUTH_TOKENS ignored; no bearer token is accepted")
+		auth = &Authenticator{}
+	}
+	s.Auth = auth
+	s.Chaos.SetSeed(o.config.ChaosSeed)
+	if o.telemetry {
+		endpoint := otlpEndpoint(o.config.OTLPEndpoint)
+		logsEndpoint := otlpEndpoint(o.config.OTLPLogsEndpoint)
+		shutdown, logs, err := setupTelemetry(ctx, name, endpoint, logsEndpoint)
+		if err != nil {
+			log.Warn().Err(err).Msg("telemetry disabled")
+		} else {
+			s.telemetry = shutdown
+			s.AddReadiness("otel-collector", exporterCheck(endpoint), NonCritical())
+			if logs != nil && !o.keepLogger {
+				setupLogging(name, o.config, logs)
+			}
+		}
+	}
+	api := s.chaosAPI()
+	if o.grpcAddr != "" {
+		serverOpts := append([]grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(append(unaryInterceptors(name, s.Chaos, s.conns), o.unary...)...),
+			grpc.ChainStreamInterceptor(append(streamInterceptors(name, s.Chaos, s.conns), o.stream...)...),
+		}, o.serverOpts...)
+		s.GRPC = grpc.NewServer(serverOpts...)
+		s.Health = health.NewServer()
+		s.Health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+		healthpb.RegisterHealthServer(s.GRPC, s.Health)
+		if api != nil {
+			chaospb.RegisterChaosServer(s.GRPC, api)
+		}
+	}
+	s.registerAdmin(api)
+	return s
+}
+
+// OnStop runs fn when shutdown starts, before any server stops. Use it to end
+// long-lived streams that would otherwise hold up a graceful stop. Hooks run
+// in registration order.
+func (s *Service) OnStop(name string, fn func(context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onStop = append(s.onStop, hook{name, fn})
+}
+
+// OnClose runs fn after the servers have stopped, e.g. to drain background
+// work or close the database. Hooks run in reverse registration order.
+func (s *Service) OnClose(name string, fn func(context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onClose = append(s.onClose, hook{name, fn})
+}
+
+// AddHTTPServer has Run serve srv on srv.Addr and shut it down gracefully.
+func (s *Service) AddHTTPServer(srv *http.Server) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.servers = append(s.servers, srv)
+}
+
+// Run starts every server and blocks until ctx is done, SIGINT/SIGTERM
+// arrives or a server fails, then shuts down in order:
+//
+//  1. /readyz reports not ready, then the drain delay passes
+//  2. OnStop hooks
+//  3. HTTP servers, then the gRPC server (forced once the timeout expires)
+//  4. OnClose hooks, newest first
+//  5. the admin server, then telemetry is flushed
+func (s *Service) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var admin *http.Server
+	if s.opts.adminAddr != "" {
+		admin = &http.Server{Addr: s.opts.adminAddr, Handler: s.Admin, ReadHeaderTimeout: 5 * time.Second}
+	}
+	s.mu.Lock()
+	servers := append([]*http.Server(nil), s.servers...)
+	s.mu.Unlock()
+
+	errc := make(chan error, len(servers)+2)
+	serveHTTP := func(name string, srv *http.Server) error {
+		lis, err := net.Listen("tcp", srv.Addr)
+		if err != nil {
+			return fmt.Errorf("%s listen: %w", name, err)
+		}
+		go func() {
+			if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errc <- fmt.Errorf("%s server: %w", name, err)
+			}
+		}()
+		return nil
+	}
+
+	var startErr error
+	if admin != nil {
+		startErr = serveHTTP("admin", admin)
+	}
+	for _, srv := range servers {
+		if startErr == nil {
+			startErr = serveHTTP("http", srv)
+		}
+	}
+	if startErr == nil && s.GRPC != nil {
+		lis := s.opts.grpcListener
+		var err error
+		if lis == nil {
+			lis, err = net.Listen("tcp", s.opts.grpcAddr)
+		}
+		if err != nil {
+			startErr = fmt.Errorf("grpc listen: %w", err)
+		} else {
+			lis = s.conns.Listener(lis)
+			go func() {
+				if err := s.GRPC.Serve(lis); err != nil {
+					errc <- fmt.Errorf("grpc server: %w", err)
+				}
+			}()
+		}
+	}
+
+	runErr := startErr
+	if startErr == nil {
+		go s.runReadiness(ctx)
+		ev := log.Info()
+		if s.GRPC != nil {
+			ev = ev.Str("grpc", s.opts.grpcAddr)
+		}
+		if admin != nil {
+			ev = ev.Str("admin", s.opts.adminAddr)
+		}
+		for _, srv := range servers {
+			ev = ev.Str("http", srv.Addr)
+		}
+		ev.Msg(s.Name + " service started")
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("shutdown signal received")
+		case runErr = <-errc:
+			log.Error().Err(runErr).Msg("server failed")
+		}
+	}
+	return errors.Join(runErr, s.shutdown(servers, admin))
+}
+
+func (s *Service) shutdown(servers []*http.Server, admin *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.shutdownTimeout)
+	defer cancel()
+	s.draining.Store(true)
+	if s.Health != nil {
+		// NOT_SERVING tells health-checking clients to move traffic away.
+		s.Health.Shutdown()
+	}
+
+	if d := s.opts.drainDelay; d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+		}
+	}
+
+	s.mu.Lock()
+	onStop := append([]hook(nil), s.onStop...)
+	onClose := append([]hook(nil), s.onClose...)
+	s.mu.Unlock()
+
+	var errs []error
+	for _, h := range onStop {
+		errs = append(errs, runHook(ctx, "stop", h))
+	}
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("http shutdown: %w", err))
+		}
+	}
+	if s.GRPC != nil {
+		stopGRPC(ctx, s.GRPC)
+	}
+	for i := len(onClose) - 1; i >= 0; i-- {
+		errs = append(errs, runHook(ctx, "close", onClose[i]))
+	}
+	if admin != nil {
+		if err := admin.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("admin shutdown: %w", err))
+		}
+	}
+
+	// Flush with a fresh deadline so an exhausted shutdown budget doesn't
+	// drop the spans that explain why.
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), telemetryFlushTimeout)
+	defer cancelFlush()
+	if err := s.telemetry(flushCtx); err != nil {
+		errs = append(errs, fmt.Errorf("telemetry shutdown: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func runHook(ctx context.Context, phase string, h hook) error {
+	start := time.Now()
+	err := h.fn(ctx)
+	ev := log.Info()
+	if err != nil {
+		ev = log.Warn().Err(err)
+		err = fmt.Errorf("%s hook %q: %w", phase, h.name, err)
+	}
+	ev.Str("hook", h.name).Dur("took", time.Since(start)).Msg(phase + " hook finished")
+	return err
+}
+
+// stopGRPC waits for in-flight RPCs until ctx expires, then closes the rest.
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warn().Msg("grpc graceful stop timed out; closing remaining connections")
+		srv.Stop()
+		<-done
+	}
+}
+
+// WaitGroupHook adapts a WaitGroup for OnClose: it returns when wg is done or
+// ctx expires.
+func WaitGroupHook(wg *sync.WaitGroup) func(context.Context) error {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rs/zerolog"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func readyz(s *Service) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.Admin.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	return rec
+}
+
+func TestRun_ShutsDownInOrder(t *testing.T) {
+	s := New(context.Background(), "test", WithoutTelemetry(), WithGRPC("127.0.0.1:0"), WithAdmin("127.0.0.1:0"))
+	var order []string
+	var readyDuringStop int
+	s.OnStop("streams", func(context.Context) error {
+		readyDuringStop = readyz(s).Code
+		order = append(order, "stop:streams")
+		return nil
+	})
+	s.OnClose("db", func(context.Context) error {
+		order = append(order, "close:db")
+		return nil
+	})
+	s.OnClose("background", func(context.Context) error {
+		order = append(order, "close:background")
+		return errors.New("drain incomplete")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := s.Run(ctx)
+
+	want := []string{"stop:streams", "close:background", "close:db"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("hook order = %v, want %v", order, want)
+	}
+	if readyDuringStop != http.StatusServiceUnavailable {
+		t.Errorf("/readyz during shutdown = %d, want 503", readyDuringStop)
+	}
+	if err == nil || !strings.Contains(err.Error(), "drain incomplete") {
+		t.Errorf("Run error = %v, want hook error", err)
+	}
+}
+
+func TestReadyz_ReportsCachedChecks(t *testing.T) {
+	s := New(context.Background(), "test", WithoutTelemetry())
+	if rec := readyz(s); rec.Code != http.StatusOK {
+		t.Fatalf("no checks: got %d, want 200", rec.Code)
+	}
+	var calls int32
+	s.AddReadiness("postgres", func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("connection refused")
+	})
+	s.AddReadiness("otel-collector", func(context.Context) error { return errors.New("unreachable") }, NonCritical())
+	s.AddReadiness("payments", func(context.Context) error { return nil })
+
+	if rec := readyz(s); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("before first run: got %d, want 503 (pending)", rec.Code)
+	}
+	s.readiness.refresh(context.Background(), s.Name)
+	rec := readyz(s)
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "postgres") || strings.Contains(rec.Body.String(), "otel") {
+		t.Errorf("got %d %q, want 503 naming only postgres", rec.Code, rec.Body.String())
+	}
+	if calls != 1 {
+		t.Errorf("probe ran the check: %d calls, want 1 (cached)", calls)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Admin.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz?verbose", nil))
+	var rep ReadinessReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if rep.Status != "not_ready" || len(rep.Checks) != 3 {
+		t.Fatalf("report = %+v", rep)
+	}
+	for _, c := range rep.Checks {
+		want := map[string]string{"postgres": "failing", "otel-collector": "failing", "payments": "ok"}[c.Name]
+		if c.Status != want || c.CheckedAt == nil {
+			t.Errorf("%s: status %q checked_at %v, want %q", c.Name, c.Status, c.CheckedAt, want)
+		}
+	}
+}
+
+func TestRecoveryInterceptor_ReturnsInternal(t *testing.T) {
+	_, err := recoveryUnaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/x.Y/Z"},
+		func(context.Context, interface{}) (interface{}, error) { panic("boom") })
+	if status.Code(err) != grpccodes.Internal {
+		t.Errorf("got %v, want Internal", err)
+	}
+}
+
+func TestHealth_FollowsReadiness(t *testing.T) {
+	s := New(context.Background(), "test", WithoutTelemetry(), WithGRPC("127.0.0.1:0"))
+	var failing error
+	s.AddReadiness("postgres", func(context.Context) error { return failing })
+
+	healthOf := func() healthpb.HealthCheckResponse_ServingStatus {
+		s.readiness.refresh(context.Background(), s.Name)
+		s.updateHealth()
+		resp, err := s.Health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Status
+	}
+	if got := healthOf(); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("healthy: got %v, want SERVING", got)
+	}
+	failing = errors.New("connection refused")
+	if got := healthOf(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("postgres down: got %v, want NOT_SERVING", got)
+	}
+}
+
+func TestChaosAPI_OperatorOnly(t *testing.T) {
+	faults := func(s *Service, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/chaos/faults", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.Admin.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := faults(New(context.Background(), "test", WithoutTelemetry()), ""); code != http.StatusNotFound {
+		t.Errorf("default config: got %d, want 404 (off)", code)
+	}
+
+	cfg := DefaultConfig()
+	cfg.ChaosAPI = true
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate accepted CHAOS_API_ENABLED without an operator token")
+	}
+	if code := faults(New(context.Background(), "test", WithoutTelemetry(), WithConfig(cfg)), ""); code != http.StatusNotFound {
+		t.Errorf("no operator token: got %d, want 404 (off)", code)
+	}
+
+	cfg.AuthTokens = []string{"op=operator:alice", "u=user:u1"}
+	s := New(context.Background(), "test", WithoutTelemetry(), WithConfig(cfg))
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "u": http.StatusUnauthorized, "op": http.StatusOK} {
+		if code := faults(s, token); code != want {
+			t.Errorf("token %q: got %d, want %d", token, code, want)
+		}
+	}
+}
+
+// memLogExporter keeps exported log records.
+type memLogExporter struct{ records []sdklog.Record }
+
+func (e *memLogExporter) Export(_ context.Context, rs []sdklog.Record) error {
+	e.records = append(e.records, rs...)
+	return nil
+}
+func (e *memLogExporter) Shutdown(context.Context) error   { return nil }
+func (e *memLogExporter) ForceFlush(context.Context) error { return nil }
+
+func TestOTelLogWriter(t *testing.T) {
+	exp := &memLogExporter{}
+	lp := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exp)))
+	logger := zerolog.New(otelLogWriter{logger: lp.Logger("test")})
+
+	logger.Warn().
+		Str("order_id", "o1").
+		Int("attempt", 2).
+		Str("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736").
+		Str("span_id", "00f067aa0ba902b7").
+		Msg("settle failed")
+
+	if len(exp.records) != 1 {
+		t.Fatalf("%d records exported, want 1", len(exp.records))
+	}
+	r := exp.records[0]
+	if r.Body().AsString() != "settle failed" || r.Severity() != otellog.SeverityWarn || r.SeverityText() != "warn" {
+		t.Errorf("record body %q severity %v %q, want settle failed at warn", r.Body().AsString(), r.Severity(), r.SeverityText())
+	}
+	if got := r.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID %s, want the logged trace_id", got)
+	}
+	attrs := map[string]otellog.Value{}
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	if len(attrs) != 2 || attrs["order_id"].AsString() != "o1" || attrs["attempt"].AsInt64() != 2 {
+		t.Errorf("attributes %v, want order_id and attempt only", attrs)
+	}
+}
+
+func TestCaller_FromDialedService(t *testing.T) {
+	s := New(context.Background(), "gateway", WithoutTelemetry())
+	ctx := WithActor(context.Background(), "support:ana")
+	var sent metadata.MD
+	invoker := func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		sent, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	if err := sourceUnaryClientInterceptor(s.Name)(ctx, "/x.Y/Z", nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	actor, source := Caller(metadata.NewIncomingContext(context.Background(), sent))
+	if actor != "support:ana" || source != "gateway" {
+		t.Errorf("Caller = %q, %q; want support:ana, gateway", actor, source)
+	}
+	if actor, source := Caller(context.Background()); actor != "" || source != "" {
+		t.Errorf("Caller without metadata = %q, %q", actor, source)
+	}
+}
@@ -0,0 +1,246 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	checkPending = "pending"
+	checkOK      = "ok"
+	checkFailing = "failing"
+)
+
+// CheckOption configures a readiness check.
+type CheckOption func(*check)
+
+// CheckTimeout bounds a single run of the check. Default 2s.
+func CheckTimeout(d time.Duration) CheckOption {
+	return func(c *check) { c.timeout = d }
+}
+
+// NonCritical reports the check in /readyz?verbose and the metric without
+// failing readiness, for dependencies the service can degrade without.
+func NonCritical() CheckOption {
+	return func(c *check) { c.critical = false }
+}
+
+type check struct {
+	name     string
+	fn       func(context.Context) error
+	timeout  time.Duration
+	critical bool
+}
+
+// CheckResult is the cached outcome of the last run of a check.
+type CheckResult struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	Critical  bool       `json:"critical"`
+	LatencyMS float64    `json:"latency_ms"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	// Since is when the check last changed status.
+	Since *time.Time `json:"since,omitempty"`
+}
+
+// ReadinessReport is the body of /readyz?verbose.
+type ReadinessReport struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// readiness runs checks in the background and keeps their latest results,
+// so probes never wait on a dependency.
+type readiness struct {
+	mu      sync.RWMutex
+	checks  []*check
+	results map[string]CheckResult
+}
+
+func newReadiness() *readiness {
+	return &readiness{results: make(map[string]CheckResult)}
+}
+
+func (r *readiness) add(c *check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, c)
+	r.results[c.name] = CheckResult{Name: c.name, Status: checkPending, Critical: c.critical}
+}
+
+// refresh runs every check concurrently, each under its own timeout, and
+// stores the results.
+func (r *readiness) refresh(ctx context.Context, service string) {
+	r.mu.RLock()
+	checks := append([]*check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c *check) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, c.timeout)
+			start := time.Now()
+			err := c.fn(cctx)
+			cancel()
+			if ctx.Err() != nil {
+				return // shutting down; keep the last real result
+			}
+			now := time.Now().UTC()
+			res := CheckResult{Name: c.name, Status: checkOK, Critical: c.critical, LatencyMS: float64(time.Since(start).Microseconds()) / 1000, CheckedAt: &now}
+			if err != nil {
+				res.Status = checkFailing
+				res.Error = err.Error()
+			}
+			r.store(service, res)
+		}(c)
+	}
+	wg.Wait()
+}
+
+func (r *readiness) store(service string, res CheckResult) {
+	r.mu.Lock()
+	prev := r.results[res.Name]
+	res.Since = prev.Since
+	if prev.Status != res.Status {
+		res.Since = res.CheckedAt
+	}
+	r.results[res.Name] = res
+	r.mu.Unlock()
+
+	if prev.Status != res.Status {
+		ev := log.Info()
+		if res.Status == checkFailing {
+			ev = log.Warn().Str("error", res.Error)
+		}
+		ev.Str("check", res.Name).Bool("critical", res.Critical).Str("status", res.Status).Msg("readiness check changed")
+	}
+	v := 0.0
+	if res.Status == checkOK {
+		v = 1
+	}
+	readinessCheckStatus.WithLabelValues(service, res.Name, boolLabel(res.Critical)).Set(v)
+}
+
+// report returns the cached results; ready is false while any critical
+// check is failing or has not run yet.
+func (r *readiness) report() (ready bool, results []CheckResult) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ready = true
+	for _, res := range r.results {
+		results = append(results, res)
+		if res.Critical && res.Status != checkOK {
+			ready = false
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return ready, results
+}
+
+// AddReadiness registers a readiness check. Checks are critical unless
+// NonCritical is given. They run in the background every readiness interval
+// (see WithReadinessInterval) once Run starts; /readyz and gRPC health
+// serve the cached results.
+func (s *Service) AddReadiness(name string, fn func(context.Context) error, opts ...CheckOption) {
+	c := &check{name: name, fn: fn, timeout: defaultReadinessTimeout, critical: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	s.readiness.add(c)
+}
+
+// Readiness returns the cached readiness report.
+func (s *Service) Readiness() ReadinessReport {
+	ready, results := s.readiness.report()
+	rep := ReadinessReport{Status: "ready", Checks: results}
+	switch {
+	case s.draining.Load():
+		rep.Status = "shutting_down"
+	case !ready:
+		rep.Status = "not_ready"
+	}
+	return rep
+}
+
+// runReadiness refreshes the checks until ctx is done and mirrors the result
+// into gRPC health.
+func (s *Service) runReadiness(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.readinessInterval)
+	defer ticker.Stop()
+	for {
+		s.readiness.refresh(ctx, s.Name)
+		s.updateHealth()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateHealth publishes readiness as the status of the server ("") and of
+// every registered gRPC service.
+func (s *Service) updateHealth() {
+	if s.Health == nil {
+		return
+	}
+	st := healthpb.HealthCheckResponse_SERVING
+	if ready, _ := s.readiness.report(); !ready {
+		st = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.Health.SetServingStatus("", st)
+	for svc := range s.GRPC.GetServiceInfo() {
+		if svc != healthpb.Health_ServiceDesc.ServiceName {
+			s.Health.SetServingStatus(svc, st)
+		}
+	}
+}
+
+// handleReady serves /readyz from cached results: 503 once shutdown has begun
+// or while a critical check fails. ?verbose returns the full JSON report.
+func (s *Service) handleReady(w http.ResponseWriter, r *http.Request) {
+	rep := s.Readiness()
+	code := http.StatusOK
+	if rep.Status != "ready" {
+		code = http.StatusServiceUnavailable
+	}
+	if _, verbose := r.URL.Query()["verbose"]; verbose {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(rep)
+		return
+	}
+	w.WriteHeader(code)
+	switch rep.Status {
+	case "ready":
+		_, _ = w.Write([]byte("ok"))
+	case "shutting_down":
+		_, _ = w.Write([]byte("shutting down"))
+	default:
+		var failing []string
+		for _, c := range rep.Checks {
+			if c.Critical && c.Status != checkOK {
+				failing = append(failing, c.Name+": "+c.Status)
+			}
+		}
+		_, _ = w.Write([]byte(strings.Join(failing, "\n")))
+	}
+}
+
+func boolLabel(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,243 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otlpEndpoint strips the scheme the OTEL_EXPORTER_OTLP_ENDPOINT convention
+// allows; the gRPC exporters want host:port.
+func otlpEndpoint(endpoint string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://")
+}
+
+// setupTelemetry installs global OTLP trace and metric providers and the W3C
+// propagators. With a logsEndpoint it also returns a logger provider that
+// exports logs over OTLP HTTP; setupLogging feeds it. The returned func
+// flushes and stops them all.
+func setupTelemetry(ctx context.Context, name, endpoint, logsEndpoint string) (func(context.Context) error, *sdklog.LoggerProvider, error) {
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(name))
+
+	traceExporter, err := otlptracegrpc.New(ctx,
+		otlptracegrpc.WithEndpoint(endpoint),
+		otlptracegrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	)
+
+	metricExporter, err := otlpmetricgrpc.New(ctx,
+		otlpmetricgrpc.WithEndpoint(endpoint),
+		otlpmetricgrpc.WithInsecure(),
+	)
+	if err != nil {
+		_ = tp.Shutdown(ctx)
+		return nil, nil, err
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(15*time.Second))),
+		sdkmetric.WithResource(res),
+	)
+
+	var lp *sdklog.LoggerProvider
+	if logsEndpoint != "" {
+		logExporter, err := otlploghttp.New(ctx,
+			otlploghttp.WithEndpoint(logsEndpoint),
+			otlploghttp.WithInsecure(),
+		)
+		if err != nil {
+			_ = tp.Shutdown(ctx)
+			_ = mp.Shutdown(ctx)
+			return nil, nil, err
+		}
+		lp = sdklog.NewLoggerProvider(
+			sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)),
+			sdklog.WithResource(res),
+		)
+	}
+
+	otel.SetTracerProvider(tp)
+	otel.SetMeterProvider(mp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return func(ctx context.Context) error {
+		errs := []error{tp.Shutdown(ctx), mp.Shutdown(ctx)}
+		if lp != nil {
+			errs = append(errs, lp.Shutdown(ctx))
+		}
+		return errors.Join(errs...)
+	}, lp, nil
+}
+
+// exporterCheck reports whether the OTLP collector accepts connections.
+// Exporters buffer and retry, so it is registered as non-critical.
+func exporterCheck(endpoint string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", endpoint)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// setupLogging configures the global zerolog logger from cfg.LogLevel and
+// cfg.LogFormat (console or json). With a non-nil logs provider every event
+// is also emitted as an OTel log record.
+// Events logged with .Ctx(ctx) carry trace_id and span_id so Loki lines link
+// to Tempo traces.
+func setupLogging(name string, cfg Config, logs otellog.LoggerProvider) {
+	zerolog.TimeFieldFormat = time.RFC3339
+	level, err := zerolog.ParseLevel(strings.ToLower(cfg.LogLevel))
+	if err != nil || level == zerolog.NoLevel {
+		level = zerolog.InfoLevel
+	}
+	var out io.Writer = zerolog.ConsoleWriter{Out: os.Stderr}
+	if strings.EqualFold(cfg.LogFormat, "json") {
+		out = os.Stderr
+	}
+	if logs != nil {
+		out = zerolog.MultiLevelWriter(out, otelLogWriter{logger: logs.Logger(name)})
+	}
+	log.Logger = zerolog.New(out).Level(level).With().Timestamp().Str("service", name).Logger().Hook(traceHook{})
+}
+
+// otelLogWriter turns zerolog's JSON events into OTel log records. The
+// message becomes the body, trace_id and span_id the record's span context,
+// and the other fields its attributes.
+type otelLogWriter struct {
+	logger otellog.Logger
+}
+
+func (w otelLogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w otelLogWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(p, &fields); err != nil {
+		// Logging must not fail because the export path cannot parse a line.
+		return len(p), nil
+	}
+	var r otellog.Record
+	r.SetTimestamp(time.Now())
+	if ts, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if t, err := time.Parse(zerolog.TimeFieldFormat, ts); err == nil {
+			r.SetTimestamp(t)
+		}
+	}
+	r.SetSeverity(otelSeverity(level))
+	r.SetSeverityText(level.String())
+	msg, _ := fields[zerolog.MessageFieldName].(string)
+	r.SetBody(otellog.StringValue(msg))
+
+	ctx := context.Background()
+	var sc trace.SpanContextConfig
+	if s, ok := fields["trace_id"].(string); ok {
+		sc.TraceID, _ = trace.TraceIDFromHex(s)
+	}
+	if s, ok := fields["span_id"].(string); ok {
+		sc.SpanID, _ = trace.SpanIDFromHex(s)
+	}
+	if spanCtx := trace.NewSpanContext(sc); spanCtx.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, spanCtx)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		switch k {
+		case zerolog.MessageFieldName, zerolog.LevelFieldName, zerolog.TimestampFieldName, "trace_id", "span_id":
+		default:
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		r.AddAttributes(otellog.KeyValue{Key: k, Value: otelValue(fields[k])})
+	}
+	w.logger.Emit(ctx, r)
+	return len(p), nil
+}
+
+// otelSeverity maps a zerolog level to the OTel severity number.
+func otelSeverity(level zerolog.Level) otellog.Severity {
+	switch level {
+	case zerolog.TraceLevel:
+		return otellog.SeverityTrace
+	case zerolog.DebugLevel:
+		return otellog.SeverityDebug
+	case zerolog.InfoLevel:
+		return otellog.SeverityInfo
+	case zerolog.WarnLevel:
+		return otellog.SeverityWarn
+	case zerolog.ErrorLevel:
+		return otellog.SeverityError
+	case zerolog.FatalLevel, zerolog.PanicLevel:
+		return otellog.SeverityFatal
+	}
+	return otellog.SeverityUndefined
+}
+
+// otelValue converts a decoded JSON value to a log attribute value.
+func otelValue(v any) otellog.Value {
+	switch v := v.(type) {
+	case string:
+		return otellog.StringValue(v)
+	case bool:
+		return otellog.BoolValue(v)
+	case float64:
+		if v == float64(int64(v)) {
+			return otellog.Int64Value(int64(v))
+		}
+		return otellog.Float64Value(v)
+	case []any:
+		vs := make([]otellog.Value, len(v))
+		for i, e := range v {
+			vs[i] = otelValue(e)
+		}
+		return otellog.SliceValue(vs...)
+	case map[string]any:
+		kvs := make([]otellog.KeyValue, 0, len(v))
+		for k, e := range v {
+			kvs = append(kvs, otellog.KeyValue{Key: k, Value: otelValue(e)})
+		}
+		slices.SortFunc(kvs, func(a, b otellog.KeyValue) int { return strings.Compare(a.Key, b.Key) })
+		return otellog.MapValue(kvs...)
+	}
+	return otellog.Value{}
+}
+
+type traceHook struct{}
+
+func (traceHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	sc := trace.SpanContextFromContext(e.GetCtx())
+	if sc.IsValid() {
+		e.Str("trace_id", sc.TraceID().String()).Str("span_id", sc.SpanID().String())
+	}
+}
@@ -0,0 +1,40 @@
+#!/usr/bin/env bash
+# Regenerate .env.example from the services' config structs. Run from repo root.
+# Each service prints its section with --print-env-example; the shared
+# platform section is printed by every service and kept once.
+set -eo pipefail
+ROOT="$(cd "$(dirname "$0")/.." && pwd)"
+cd "$ROOT"
+{
+	cat <<'HEAD'
+# Reliability Lab - Environment defaults
+# Copy to .env and adjust as needed. Generated by `make env-example` from the
+# services' config structs; commented lines show the default.
+
+# --- Postgres (compose) ---
+POSTGRES_USER=reliability
+POSTGRES_PASSWORD=reliability_secret
+POSTGRES_DB=reliability_lab
+POSTGRES_HOST=postgres
+POSTGRES_PORT=5432
+
+HEAD
+	for svc in gateway orders payments notifications catalog inventory; do
+		(cd "services/$svc" && go run . --print-env-example)
+		echo
+	done | awk 'BEGIN { RS = ""; ORS = "\n\n" } !seen[$0]++'
+	cat <<'TAIL'
+# --- Observability (compose; usually not overridden) ---
+PROMETHEUS_PORT=9090
+GRAFANA_PORT=3000
+TEMPO_PORT=3200
+LOKI_PORT=3100
+
+# --- k6 load test (make load) ---
+# K6_DURATION=60
+# K6_VUS=5
+# GATEWAY_URL=http://localhost:8080
+TAIL
+} > .env.example.tmp
+mv .env.example.tmp .env.example
+echo "Wrote $ROOT/.env.example"
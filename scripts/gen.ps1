@@ -2,5 +2,5 @@
 $ErrorActionPreference = "Stop"
 $Root = Split-Path -Parent (Split-Path -Parent $MyInvocation.MyCommand.Path)
 Set-Location (Join-Path $Root "proto")
-docker run --rm -v "${Root}:/workspace" -w /workspace/proto bufbuild/buf:latest generate
+docker run --rm -v "${Root}:/workspace" -w /workspace/proto --entrypoint sh bufbuild/buf:latest -c "buf dep update && buf generate"
 Write-Host "Generated code in $Root\gen"
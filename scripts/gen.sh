@@ -3,5 +3,6 @@
 set -e
 ROOT="$(cd "$(dirname "$0")/.." && pwd)"
 cd "$ROOT/proto"
-docker run --rm -v "$ROOT:/workspace" -w /workspace/proto bufbuild/buf:latest generate
+# buf dep update resolves buf.build/googleapis/googleapis (google.api.http annotations) into buf.lock.
+docker run --rm -v "$ROOT:/workspace" -w /workspace/proto --entrypoint sh bufbuild/buf:latest -c "buf dep update && buf generate"
 echo "Generated code in $ROOT/gen"
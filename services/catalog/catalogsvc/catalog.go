@@ -0,0 +1,107 @@
+package catalogsvc
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/reliability-lab/gen/catalog"
+	"github.com/reliability-lab/pkg/money"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed catalog.yaml
+var defaultCatalog []byte
+
+// Catalog is an immutable set of products and their SKUs.
+type Catalog struct {
+	products []*catalog.Product
+	byID     map[string]*catalog.Product
+	bySku    map[string]*catalog.Sku
+}
+
+// file is the YAML layout of a catalog file; see catalog.yaml.
+type file struct {
+	Products []struct {
+		ID          string `yaml:"id"`
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+		Skus        []struct {
+			Sku         string `yaml:"sku"`
+			Name        string `yaml:"name"`
+			PriceCents  int64  `yaml:"price_cents"`
+			Currency    string `yaml:"currency"`
+			TaxCategory string `yaml:"tax_category"`
+		} `yaml:"skus"`
+	} `yaml:"products"`
+}
+
+// Default returns the catalog built into the binary.
+func Default() *Catalog {
+	c, err := Parse(defaultCatalog)
+	if err != nil {
+		panic("catalogsvc: built-in catalog: " + err.Error())
+	}
+	return c
+}
+
+// Load reads a catalog file.
+func Load(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("catalog %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Parse decodes a catalog file. Product IDs and SKU codes must be unique,
+// prices positive and currencies three upper-case letters; every problem is
+// reported.
+func Parse(data []byte) (*Catalog, error) {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	c := &Catalog{byID: make(map[string]*catalog.Product), bySku: make(map[string]*catalog.Sku)}
+	var errs []error
+	for i, fp := range f.Products {
+		if fp.ID == "" {
+			errs = append(errs, fmt.Errorf("product %d: id required", i))
+			continue
+		}
+		if _, dup := c.byID[fp.ID]; dup {
+			errs = append(errs, fmt.Errorf("product %s: duplicate id", fp.ID))
+			continue
+		}
+		p := &catalog.Product{ProductId: fp.ID, Name: fp.Name, Description: fp.Description}
+		for _, fs := range fp.Skus {
+			switch {
+			case fs.Sku == "":
+				errs = append(errs, fmt.Errorf("product %s: sku required", fp.ID))
+				continue
+			case c.bySku[fs.Sku] != nil:
+				errs = append(errs, fmt.Errorf("sku %s: duplicate", fs.Sku))
+				continue
+			case fs.PriceCents <= 0:
+				errs = append(errs, fmt.Errorf("sku %s: price_cents must be positive", fs.Sku))
+			}
+			if err := money.ValidateCurrency(fs.Currency); err != nil {
+				errs = append(errs, fmt.Errorf("sku %s: currency: %w", fs.Sku, err))
+			}
+			s := &catalog.Sku{Sku: fs.Sku, ProductId: fp.ID, Name: fs.Name, PriceCents: fs.PriceCents, Currency: fs.Currency, TaxCategory: fs.TaxCategory}
+			p.Skus = append(p.Skus, s)
+			c.bySku[s.Sku] = s
+		}
+		c.products = append(c.products, p)
+		c.byID[p.ProductId] = p
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return c, nil
+}
@@ -0,0 +1,63 @@
+// Package catalogsvc implements the Catalog API: products, their SKUs and
+// unit prices, read from a catalog file.
+package catalogsvc
+
+import (
+	"context"
+
+	"github.com/reliability-lab/gen/catalog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxSkusPerRequest bounds GetSkus, which orders calls once per order.
+const maxSkusPerRequest = 100
+
+// Server implements catalog.CatalogServer on a Catalog.
+type Server struct {
+	catalog.UnimplementedCatalogServer
+	catalog *Catalog
+}
+
+// NewServer serves c.
+func NewServer(c *Catalog) *Server {
+	return &Server{catalog: c}
+}
+
+func (s *Server) ListProducts(ctx context.Context, _ *catalog.ListProductsRequest) (*catalog.ListProductsResponse, error) {
+	return &catalog.ListProductsResponse{Products: s.catalog.products}, nil
+}
+
+func (s *Server) GetProduct(ctx context.Context, req *catalog.GetProductRequest) (*catalog.GetProductResponse, error) {
+	if req.ProductId == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "product_id required")
+	}
+	p, ok := s.catalog.byID[req.ProductId]
+	if !ok {
+		return nil, status.Errorf(grpccodes.NotFound, "product %s not found", req.ProductId)
+	}
+	return &catalog.GetProductResponse{Product: p}, nil
+}
+
+func (s *Server) GetSkus(ctx context.Context, req *catalog.GetSkusRequest) (*catalog.GetSkusResponse, error) {
+	_, span := otel.Tracer("catalog").Start(ctx, "GetSkus")
+	defer span.End()
+	span.SetAttributes(attribute.Int("skus", len(req.Skus)))
+
+	if len(req.Skus) == 0 || len(req.Skus) > maxSkusPerRequest {
+		return nil, status.Errorf(grpccodes.InvalidArgument, "between 1 and %d skus required", maxSkusPerRequest)
+	}
+	out := make([]*catalog.Sku, len(req.Skus))
+	for i, code := range req.Skus {
+		sku, ok := s.catalog.bySku[code]
+		if !ok {
+			return nil, status.Errorf(grpccodes.NotFound, "sku %q not found", code)
+		}
+		out[i] = sku
+	}
+	return &catalog.GetSkusResponse{Skus: out}, nil
+}
+
+var _ catalog.CatalogServer = (*Server)(nil)
@@ -0,0 +1,86 @@
+package catalogsvc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/reliability-lab/gen/catalog"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDefaultCatalog(t *testing.T) {
+	resp, err := NewServer(Default()).ListProducts(context.Background(), &catalog.ListProductsRequest{})
+	if err != nil || len(resp.Products) == 0 {
+		t.Fatalf("ListProducts = %v, %v; want the built-in products", resp, err)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte(`
+products:
+  - id: a
+    skus:
+      - {sku: A-1, price_cents: 100, currency: USD}
+      - {sku: A-1, price_cents: 100, currency: USD}
+      - {sku: A-2, price_cents: 0, currency: USD}
+      - {sku: A-3, price_cents: 100, currency: usd}
+  - id: a
+`))
+	if err == nil {
+		t.Fatal("Parse accepted an invalid catalog")
+	}
+	for _, want := range []string{"sku A-1: duplicate", "sku A-2: price_cents", "sku A-3: currency", "product a: duplicate id"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestGetSkus(t *testing.T) {
+	c, err := Parse([]byte(`
+products:
+  - id: shirt
+    name: Shirt
+    skus:
+      - {sku: SHIRT-S, name: Small, price_cents: 1299, currency: USD}
+      - {sku: SHIRT-L, name: Large, price_cents: 1499, currency: USD}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(c)
+	ctx := context.Background()
+
+	resp, err := s.GetSkus(ctx, &catalog.GetSkusRequest{Skus: []string{"SHIRT-L", "SHIRT-S", "SHIRT-L"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, sku := range resp.Skus {
+		got = append(got, sku.Sku)
+	}
+	if strings.Join(got, ",") != "SHIRT-L,SHIRT-S,SHIRT-L" || resp.Skus[0].PriceCents != 1499 || resp.Skus[0].ProductId != "shirt" {
+		t.Errorf("GetSkus = %v, want the SKUs in request order", resp.Skus)
+	}
+
+	tests := []struct {
+		name string
+		skus []string
+		code grpccodes.Code
+	}{
+		{"unknown", []string{"SHIRT-S", "HAT"}, grpccodes.NotFound},
+		{"empty", nil, grpccodes.InvalidArgument},
+		{"too many", make([]string, maxSkusPerRequest+1), grpccodes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if _, err := s.GetSkus(ctx, &catalog.GetSkusRequest{Skus: tt.skus}); status.Code(err) != tt.code {
+			t.Errorf("%s: GetSkus error %v, want %s", tt.name, err, tt.code)
+		}
+	}
+
+	if _, err := s.GetProduct(ctx, &catalog.GetProductRequest{ProductId: "hat"}); status.Code(err) != grpccodes.NotFound {
+		t.Errorf("GetProduct(unknown) error %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/reliability-lab/pkg/platform"
+)
+
+// serviceConfig is the catalog configuration; see pkg/platform/config for the
+// tag syntax and precedence.
+type serviceConfig struct {
+	platform.Config `section:"Platform (all services)"`
+
+	GRPCPort int    `conf:"grpc_port" env:"CATALOG_GRPC_PORT" default:"50054" desc:"gRPC listen port."`
+	HTTPPort int    `conf:"http_port" env:"CATALOG_HTTP_PORT" default:"8084" desc:"Admin port for /healthz, /readyz and /metrics."`
+	File     string `conf:"file" env:"CATALOG_FILE" desc:"Catalog YAML file; empty serves the built-in catalog." example:"/etc/catalog/catalog.yaml"`
+}
+
+func (c *serviceConfig) Validate() error {
+	return errors.Join(platform.ValidPort("CATALOG_GRPC_PORT", c.GRPCPort), platform.ValidPort("CATALOG_HTTP_PORT", c.HTTPPort))
+}
@@ -0,0 +1,19 @@
+module github.com/reliability-lab/services/catalog
+
+go 1.22
+
+require (
+	github.com/reliability-lab/gen v0.0.0
+	github.com/reliability-lab/pkg/money v0.0.0
+	github.com/reliability-lab/pkg/platform v0.0.0
+	github.com/rs/zerolog v1.32.0
+	go.opentelemetry.io/otel v1.24.0
+	google.golang.org/grpc v1.62.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/reliability-lab/gen => ../../gen
+
+replace github.com/reliability-lab/pkg/money => ../../pkg/money
+
+replace github.com/reliability-lab/pkg/platform => ../../pkg/platform
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/reliability-lab/gen/catalog"
+	"github.com/reliability-lab/pkg/platform"
+	"github.com/reliability-lab/pkg/platform/config"
+	"github.com/reliability-lab/services/catalog/catalogsvc"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	var cfg serviceConfig
+	config.MustLoad(&cfg, "catalog")
+
+	ctx := context.Background()
+	svc := platform.New(ctx, "catalog", platform.WithConfig(cfg.Config),
+		platform.WithGRPC(fmt.Sprintf(":%d", cfg.GRPCPort)), platform.WithAdmin(fmt.Sprintf(":%d", cfg.HTTPPort)))
+
+	c := catalogsvc.Default()
+	if cfg.File != "" {
+		var err error
+		if c, err = catalogsvc.Load(cfg.File); err != nil {
+			log.Fatal().Err(err).Msg("load catalog failed")
+		}
+	}
+	catalog.RegisterCatalogServer(svc.GRPC, catalogsvc.NewServer(c))
+
+	if err := svc.Run(ctx); err != nil {
+		log.Error().Err(err).Msg("catalog service stopped with error")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/reliability-lab/pkg/platform"
+)
+
+// serviceConfig is the gateway configuration; see pkg/platform/config for the
+// tag syntax and precedence.
+type serviceConfig struct {
+	platform.Config `section:"Platform (all services)"`
+
+	HTTPPort          int           `conf:"http_port" env:"GATEWAY_HTTP_PORT" default:"8080" desc:"Port for the API, /healthz, /readyz and /metrics."`
+	AdminPort         int           `conf:"admin_port" env:"GATEWAY_ADMIN_PORT" default:"8086" desc:"Port for the admin routes, including the chaos API; keep it off the public network."`
+	OrdersAddr        string        `conf:"orders_addr" env:"ORDERS_GRPC_ADDR" default:"orders:50051" desc:"Orders gRPC target."`
+	PaymentsAddr      string        `conf:"payments_addr" env:"PAYMENTS_GRPC_ADDR" default:"payments:50052" desc:"Payments gRPC target."`
+	NotificationsAddr string        `conf:"notifications_addr" env:"NOTIFICATIONS_GRPC_ADDR" default:"notifications:50053" desc:"Notifications gRPC target."`
+	CatalogAddr       string        `conf:"catalog_addr" env:"CATALOG_GRPC_ADDR" default:"catalog:50054" desc:"Catalog gRPC target for /v1/products; empty disables the routes."`
+	InventoryAddr     string        `conf:"inventory_addr" env:"INVENTORY_GRPC_ADDR" default:"inventory:50055" desc:"Inventory gRPC target for reserving stock at checkout; empty sells items without reserving."`
+	CORSOrigins       []string      `conf:"cors_origins" env:"GATEWAY_CORS_ORIGINS" desc:"Comma-separated browser origins allowed to call the API." example:"http://localhost:3000"`
+	IdempotencyTTL    time.Duration `conf:"idempotency_ttl" env:"GATEWAY_IDEMPOTENCY_TTL" default:"24h" desc:"How long Idempotency-Key responses are replayed."`
+}
+
+func (c *serviceConfig) Validate() error {
+	if c.IdempotencyTTL <= 0 {
+		return fmt.Errorf("GATEWAY_IDEMPOTENCY_TTL must be positive, got %s", c.IdempotencyTTL)
+	}
+	if err := platform.ValidPort("GATEWAY_ADMIN_PORT", c.AdminPort); err != nil {
+		return err
+	}
+	if c.AdminPort == c.HTTPPort {
+		return fmt.Errorf("GATEWAY_ADMIN_PORT must differ from GATEWAY_HTTP_PORT (%d)", c.HTTPPort)
+	}
+	return platform.ValidPort("GATEWAY_HTTP_PORT", c.HTTPPort)
+}
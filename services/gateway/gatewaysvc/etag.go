@@ -0,0 +1,79 @@
+package gatewaysvc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/reliability-lab/gen/orders"
+	"google.golang.org/protobuf/proto"
+)
+
+// An order's ETag is its version, quoted. GET /orders/{id} sends it and
+// answers a matching If-None-Match with 304; POST /orders/{id}/cancel turns
+// If-Match into expected_version, so orders rejects a stale one.
+
+// errNotModified makes restErrorHandler answer 304.
+var errNotModified = errors.New("not modified")
+
+type ifNoneMatchKey struct{}
+
+func orderETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// withIfNoneMatch hands the request's If-None-Match to setOrderETag, which
+// only sees the context.
+func withIfNoneMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get("If-None-Match"); v != "" {
+			r = r.WithContext(context.WithValue(r.Context(), ifNoneMatchKey{}, v))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setOrderETag is a forward response option for the transcoded GetOrder.
+func setOrderETag(ctx context.Context, w http.ResponseWriter, m proto.Message) error {
+	o, ok := m.(*orders.GetOrderResponse)
+	if !ok {
+		return nil
+	}
+	etag := orderETag(o.Version)
+	w.Header().Set("ETag", etag)
+	if v, _ := ctx.Value(ifNoneMatchKey{}).(string); etagListMatches(v, etag) {
+		return errNotModified
+	}
+	return nil
+}
+
+// etagListMatches reports whether an If-None-Match value names etag, using
+// the weak comparison RFC 9110 prescribes for it.
+func etagListMatches(list, etag string) bool {
+	for _, t := range strings.Split(list, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the order version an If-Match value requires, 0 for
+// none or "*". ok is false for anything but a single strong order ETag.
+func ifMatchVersion(v string) (version int64, ok bool) {
+	v = strings.TrimSpace(v)
+	if v == "" || v == "*" {
+		return 0, true
+	}
+	if len(v) < 3 || v[0] != '"' || v[len(v)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(v[1:len(v)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
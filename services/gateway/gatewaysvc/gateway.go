@@ -0,0 +1,165 @@
+// Package gatewaysvc implements the public HTTP API in front of the orders,
+// payments, notifications, catalog and inventory services.
+package gatewaysvc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/reliability-lab/gen/catalog"
+	"github.com/reliability-lab/gen/inventory"
+	"github.com/reliability-lab/gen/notifications"
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/gen/payments"
+	"github.com/reliability-lab/pkg/platform"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Config wires a Gateway to its backends.
+type Config struct {
+	Orders   orders.OrdersClient
+	Payments payments.PaymentsClient
+	// Notifications is optional; without it receipts are skipped.
+	Notifications notifications.NotificationsClient
+	// Catalog is optional; without it /v1/products is not served.
+	Catalog catalog.CatalogClient
+	// Inventory is optional; without it checkout does not reserve stock and
+	// /v1/inventory is not served.
+	Inventory inventory.InventoryClient
+	// Admin serves /healthz, /readyz and /metrics, which share the API port.
+	// The rest of it, including /chaos/faults, stays on the admin port.
+	Admin http.Handler
+	// Auth authenticates bearer tokens; nil accepts none.
+	Auth *platform.Authenticator
+
+	CORSOrigins    []string
+	IdempotencyTTL time.Duration
+}
+
+type handler struct {
+	ordersClient        orders.OrdersClient
+	paymentsClient      payments.PaymentsClient
+	notificationsClient notifications.NotificationsClient
+	catalogClient       catalog.CatalogClient
+	auth                *platform.Authenticator
+	inventoryClient     inventory.InventoryClient
+
+	operations *operationStore
+	// background tracks async order settlement so shutdown can drain it.
+	background sync.WaitGroup
+
+	corsOrigins []string
+	// shutdown is cancelled when the server starts shutting down so event
+	// streams end and background settlement stops retrying; streams tracks
+	// WebSocket connections, which Shutdown doesn't wait for once hijacked.
+	shutdown    context.Context
+	stopStreams context.CancelFunc
+	streams     sync.WaitGroup
+}
+
+// Gateway is the gateway's HTTP handler. On shutdown call StopStreams when
+// the server stops accepting requests, then WaitStreams and WaitBackground
+// once it has stopped.
+type Gateway struct {
+	h       *handler
+	handler http.Handler
+}
+
+// New builds the gateway routes on top of cfg's clients.
+func New(ctx context.Context, cfg Config) (*Gateway, error) {
+	streamsCtx, stopStreams := context.WithCancel(context.Background())
+	h := &handler{
+		ordersClient:        cfg.Orders,
+		paymentsClient:      cfg.Payments,
+		notificationsClient: cfg.Notifications,
+		catalogClient:       cfg.Catalog,
+		auth:                cfg.Auth,
+		inventoryClient:     cfg.Inventory,
+		operations:          newOperationStore(),
+		corsOrigins:         cfg.CORSOrigins,
+		shutdown:            streamsCtx,
+		stopStreams:         stopStreams,
+	}
+
+	mux := http.NewServeMux()
+	if cfg.Admin != nil {
+		for _, path := range []string{"/healthz", "/readyz", "/metrics"} {
+			mux.Handle(path, cfg.Admin)
+		}
+	}
+	// POST /v1/orders is the same checkout as POST /orders, not a transcoded
+	// orders.CreateOrder, which would leave the order unreserved and unpaid.
+	checkout := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			h.handleCreateOrder(w, r)
+			return
+		}
+		httpRequestsTotal.WithLabelValues("", r.Method, "405").Inc()
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+	mux.HandleFunc("/orders", checkout)
+	mux.HandleFunc("/v1/orders", checkout)
+
+	restMux, err := newRESTMux(ctx, h, false)
+	if err != nil {
+		stopStreams()
+		return nil, err
+	}
+	legacyMux, err := newRESTMux(ctx, h, true)
+	if err != nil {
+		stopStreams()
+		return nil, err
+	}
+	mux.Handle("/orders/", h.routeOrders(legacyMux))
+	mux.HandleFunc("/users/", h.handleUserEvents)
+	mux.HandleFunc("/ws/orders", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			h.handleEventsWebSocket(w, r)
+			return
+		}
+		httpRequestsTotal.WithLabelValues("GET /ws/orders", r.Method, "405").Inc()
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	mux.HandleFunc("/operations/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			h.handleGetOperation(w, r)
+			return
+		}
+		httpRequestsTotal.WithLabelValues("GET /operations/:id", r.Method, "405").Inc()
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	mux.Handle("/v1/", restMux)
+
+	registerConnect(mux, h, cfg.CORSOrigins)
+
+	idemStore := newIdempotencyStore(cfg.IdempotencyTTL)
+
+	// h2c lets gRPC clients reach the Connect handlers over cleartext HTTP/2.
+	return &Gateway{
+		h:       h,
+		handler: h2c.NewHandler(otelhttp.NewHandler(withIdempotency(idemStore, cfg.Auth, withTrustedActor(cfg.Auth, mux)), "gateway"), &http2.Server{}),
+	}, nil
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.handler.ServeHTTP(w, r)
+}
+
+// StopStreams ends the open SSE and WebSocket event streams.
+func (g *Gateway) StopStreams() { g.h.stopStreams() }
+
+// WaitStreams waits for the WebSocket connections to close; it is a platform
+// OnClose hook.
+func (g *Gateway) WaitStreams(ctx context.Context) error {
+	return platform.WaitGroupHook(&g.h.streams)(ctx)
+}
+
+// WaitBackground waits for async orders to settle; it is a platform OnClose
+// hook.
+func (g *Gateway) WaitBackground(ctx context.Context) error {
+	return platform.WaitGroupHook(&g.h.background)(ctx)
+}
@@ -0,0 +1,736 @@
+package gatewaysvc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/reliability-lab/gen/inventory"
+	moneypb "github.com/reliability-lab/gen/money"
+	"github.com/reliability-lab/gen/notifications"
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/gen/payments"
+	"github.com/reliability-lab/pkg/platform"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	grpcTimeout = 10 * time.Second
+	// asyncSettleTimeout bounds the retries of a background settlement
+	// before the order is abandoned.
+	asyncSettleTimeout = 2 * time.Minute
+	// maxSettleBackoff caps the wait between background settle attempts.
+	maxSettleBackoff = 15 * time.Second
+
+	orderStatusPaid          = "PAID"
+	orderStatusPaymentFailed = "PAYMENT_FAILED"
+	orderStatusOutOfStock    = "OUT_OF_STOCK"
+	orderStatusCancelled     = "CANCELLED"
+
+	// paymentNothingDue is the payment code of an order whose coupons took
+	// off the whole total; payments only charges positive amounts.
+	paymentNothingDue = "NOTHING_DUE"
+)
+
+type createOrderRequest struct {
+	UserID             string     `json:"user_id"`
+	AmountCents        int64      `json:"amount_cents"`
+	Currency           string     `json:"currency"`
+	SettlementCurrency string     `json:"settlement_currency,omitempty"`
+	IdempotencyKey     string     `json:"idempotency_key"`
+	Items              []lineItem `json:"items,omitempty"`
+	CouponCodes        []string   `json:"coupon_codes,omitempty"`
+	TaxJurisdiction    string     `json:"tax_jurisdiction,omitempty"`
+}
+
+type lineItem struct {
+	SKU      string `json:"sku"`
+	Quantity int32  `json:"quantity"`
+}
+
+type asyncOrderResponse struct {
+	OrderID     string `json:"order_id"`
+	OrderStatus string `json:"order_status"`
+	Operation   string `json:"operation"`
+}
+
+type createOrderResponse struct {
+	OrderID        string `json:"order_id"`
+	OrderStatus    string `json:"order_status"`
+	PaymentSuccess bool   `json:"payment_success"`
+	PaymentCode    string `json:"payment_code"`
+}
+
+func (h *handler) handleCreateOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("gateway").Start(r.Context(), "POST /orders")
+	defer span.End()
+	start := time.Now()
+	route := "POST /orders"
+	method := "POST"
+
+	var req createOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		recordHTTP(route, method, "400")
+		return
+	}
+	if hdr := r.Header.Get(idempotencyHeader); hdr != "" {
+		if req.IdempotencyKey != "" && req.IdempotencyKey != hdr {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "idempotency_key does not match Idempotency-Key header"})
+			recordHTTP(route, method, "400")
+			return
+		}
+		req.IdempotencyKey = hdr
+	}
+	if req.UserID == "" || req.Currency == "" || req.IdempotencyKey == "" ||
+		req.AmountCents < 0 || (req.AmountCents == 0 && len(req.Items) == 0) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing or invalid required fields: user_id, amount_cents (>0) or items, currency, idempotency_key (or Idempotency-Key header)"})
+		recordHTTP(route, method, "400")
+		return
+	}
+
+	// Keys belong to the client that sent them, as in withIdempotency: orders
+	// and payments see the key of an authenticated caller prefixed with its
+	// principal, so another principal reusing it gets an order of its own.
+	if p, ok := h.auth.Principal(r.Header.Get("Authorization")); ok {
+		req.IdempotencyKey = url.PathEscape(p) + "/" + req.IdempotencyKey
+	}
+
+	// Orders records the caller, or whoever an operator named in X-Actor, as
+	// the actor of every change this checkout makes.
+	ctx = platform.WithActor(ctx, h.actor(r, "user:"+req.UserID))
+	orderCtx, cancel := context.WithTimeout(ctx, grpcTimeout)
+	defer cancel()
+	// The amount goes in both forms while orders replicas that predate
+	// Money may still be running.
+	createReq := &orders.CreateOrderRequest{
+		UserId:             req.UserID,
+		AmountCents:        req.AmountCents,
+		Currency:           req.Currency,
+		IdempotencyKey:     req.IdempotencyKey,
+		SettlementCurrency: req.SettlementCurrency,
+		Amount:             &moneypb.Money{Currency: req.Currency, MinorUnits: req.AmountCents},
+		CouponCodes:        req.CouponCodes,
+		TaxJurisdiction:    req.TaxJurisdiction,
+	}
+	for _, it := range req.Items {
+		createReq.Items = append(createReq.Items, &orders.LineItem{Sku: it.SKU, Quantity: it.Quantity})
+	}
+	createResp, err := h.ordersClient.CreateOrder(orderCtx, createReq)
+	if err != nil {
+		span.RecordError(err)
+		// Orders rejects bad line items, totals, currencies, coupons and tax
+		// jurisdictions with InvalidArgument, and a key already used for
+		// another order with AlreadyExists.
+		code := http.StatusInternalServerError
+		switch status.Code(err) {
+		case grpccodes.InvalidArgument:
+			code = http.StatusBadRequest
+		case grpccodes.AlreadyExists:
+			code = http.StatusUnprocessableEntity
+		}
+		writeJSON(w, code, errorBody(err))
+		recordHTTP(route, method, strconv.Itoa(code))
+		return
+	}
+	// Charge what orders priced, not what the client sent. Orders that
+	// predate charge only set amount, orders that predate line items leave
+	// the amount unset, and orders that predate Money only set amount_cents
+	// and currency.
+	if c := createResp.Charge; c != nil {
+		req.AmountCents, req.Currency = c.MinorUnits, c.Currency
+	} else if a := createResp.Amount; a != nil {
+		req.AmountCents, req.Currency = a.MinorUnits, a.Currency
+	} else if createResp.AmountCents > 0 {
+		req.AmountCents, req.Currency = createResp.AmountCents, createResp.Currency
+	}
+	// A retry of an order that could not be stocked or was cancelled has
+	// nothing left to do.
+	if createResp.Status == orderStatusOutOfStock || createResp.Status == orderStatusCancelled {
+		writeJSON(w, http.StatusOK, createOrderResponse{OrderID: createResp.OrderId, OrderStatus: createResp.Status})
+		recordHTTP(route, method, "200")
+		httpRequestDurationSeconds.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+		return
+	}
+
+	if wantsAsync(r) {
+		op := h.operations.start(createResp.OrderId)
+		span.SetAttributes(attribute.String("operation_id", op.ID), attribute.Bool("async", true))
+		h.settleInBackground(ctx, op.ID, createResp, req)
+		w.Header().Set("Location", "/orders/"+createResp.OrderId)
+		w.Header().Set("Preference-Applied", "respond-async")
+		writeJSON(w, http.StatusAccepted, asyncOrderResponse{
+			OrderID:     createResp.OrderId,
+			OrderStatus: createResp.Status,
+			Operation:   "/operations/" + op.ID,
+		})
+		recordHTTP(route, method, "202")
+		httpRequestDurationSeconds.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+		return
+	}
+
+	resp, err := h.settleOrder(ctx, createResp, req)
+	if errors.Is(err, errStatusNotRecorded) {
+		// The charge is decided; finish recording it in the background and
+		// answer as for an async request rather than fail a charged order.
+		span.RecordError(err)
+		op := h.operations.start(createResp.OrderId)
+		h.settleInBackground(ctx, op.ID, createResp, req)
+		w.Header().Set("Location", "/orders/"+createResp.OrderId)
+		writeJSON(w, http.StatusAccepted, asyncOrderResponse{
+			OrderID:     createResp.OrderId,
+			OrderStatus: resp.OrderStatus,
+			Operation:   "/operations/" + op.ID,
+		})
+		recordHTTP(route, method, "202")
+		httpRequestDurationSeconds.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+		return
+	}
+	if err != nil {
+		// The client may retry with the same key; if it does not, the
+		// background settlement keeps the order from staying CREATED.
+		span.RecordError(err)
+		h.settleInBackground(ctx, "", createResp, req)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		recordHTTP(route, method, "500")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+	recordHTTP(route, method, "200")
+	httpRequestDurationSeconds.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+}
+
+// errStatusNotRecorded is returned by settleOrder when the order was charged
+// or declined but orders did not take the outcome.
+var errStatusNotRecorded = errors.New("charge outcome not recorded on the order")
+
+// settleOrder reserves a created order's items, charges it, records the
+// outcome on the order and sends the receipt. It is shared by the
+// synchronous and async create paths, and every step is safe to repeat. When
+// only the outcome could not be recorded it returns the charge with the
+// order's current status and errStatusNotRecorded.
+func (h *handler) settleOrder(ctx context.Context, created *orders.CreateOrderResponse, req createOrderRequest) (createOrderResponse, error) {
+	orderID := created.OrderId
+	reserved, reason, err := h.reserveItems(ctx, orderID, req.Items)
+	if err != nil {
+		return createOrderResponse{}, err
+	}
+	if !reserved {
+		updResp, err := h.updateOrderStatus(ctx, orderID, orderStatusOutOfStock, reason)
+		if status.Code(err) == grpccodes.FailedPrecondition {
+			// A lapsed hold of an order that was settled before.
+			updResp, err = h.currentStatus(ctx, orderID, err)
+		}
+		if err != nil {
+			return createOrderResponse{}, err
+		}
+		return createOrderResponse{OrderID: orderID, OrderStatus: updResp.Status}, nil
+	}
+
+	// If the charge fails outright the hold stays until it expires, so a
+	// retry of the request can still use it.
+	chargeResp, err := h.chargeWithRetry(ctx, orderID, req.AmountCents, req.Currency, req.IdempotencyKey)
+	if err != nil {
+		return createOrderResponse{}, err
+	}
+
+	orderStatus := orderStatusPaid
+	if !chargeResp.Success {
+		orderStatus = orderStatusPaymentFailed
+	}
+	if err := h.settleReservation(ctx, orderID, req.Items, chargeResp.Success); err != nil {
+		// A paid order must not go out without its stock. Fail the settle
+		// without the charge, so a retry commits again or abandoning the
+		// order reverses the payment and releases the hold.
+		return createOrderResponse{}, fmt.Errorf("committing the stock of a paid order: %w", err)
+	}
+	updResp, err := h.updateOrderStatus(ctx, orderID, orderStatus, chargeResp.Code)
+	if status.Code(err) == grpccodes.FailedPrecondition {
+		// An earlier attempt settled the order, or it was cancelled while it
+		// was being charged and payments voided or refunded the charge;
+		// report the order as it is.
+		updResp, err = h.currentStatus(ctx, orderID, err)
+	}
+	if err != nil {
+		return createOrderResponse{
+			OrderID:        orderID,
+			OrderStatus:    created.Status,
+			PaymentSuccess: chargeResp.Success,
+			PaymentCode:    chargeResp.Code,
+		}, fmt.Errorf("%w: %w", errStatusNotRecorded, err)
+	}
+
+	if h.notificationsClient != nil {
+		notifCtx, notifCancel := context.WithTimeout(ctx, grpcTimeout)
+		_, _ = h.notificationsClient.SendReceipt(notifCtx, receipt(created, req.UserID))
+		notifCancel()
+	}
+
+	return createOrderResponse{
+		OrderID:        orderID,
+		OrderStatus:    updResp.Status,
+		PaymentSuccess: chargeResp.Success,
+		PaymentCode:    chargeResp.Code,
+	}, nil
+}
+
+// receipt returns the receipt of a created order. Orders that predate tax
+// leave the amounts unset.
+func receipt(created *orders.CreateOrderResponse, userID string) *notifications.SendReceiptRequest {
+	r := &notifications.SendReceiptRequest{OrderId: created.OrderId, UserId: userID,
+		Subtotal: created.Subtotal, Tax: created.Tax, Total: created.Total}
+	if created.Subtotal != nil {
+		r.Discount = &moneypb.Money{Currency: created.Subtotal.Currency}
+		for _, d := range created.Discounts {
+			r.Discount.MinorUnits += d.Amount.GetMinorUnits()
+		}
+	}
+	return r
+}
+
+// currentStatus reports the order's status after writing one failed with
+// FailedPrecondition because the order moved on; it returns writeErr if the
+// order cannot be read.
+func (h *handler) currentStatus(ctx context.Context, orderID string, writeErr error) (*orders.UpdateOrderStatusResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, grpcTimeout)
+	defer cancel()
+	order, err := h.ordersClient.GetOrder(ctx, &orders.GetOrderRequest{OrderId: orderID})
+	if err != nil {
+		return nil, writeErr
+	}
+	return &orders.UpdateOrderStatusResponse{OrderId: orderID, Status: order.Status}, nil
+}
+
+// updateOrderStatus records the checkout outcome, retrying while orders is
+// unavailable; reason goes into the order's history.
+func (h *handler) updateOrderStatus(ctx context.Context, orderID, orderStatus, reason string) (*orders.UpdateOrderStatusResponse, error) {
+	const maxRetries = 2
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		callCtx, cancel := context.WithTimeout(ctx, grpcTimeout)
+		var resp *orders.UpdateOrderStatusResponse
+		resp, err = h.ordersClient.UpdateOrderStatus(callCtx, &orders.UpdateOrderStatusRequest{
+			OrderId: orderID,
+			Status:  orderStatus,
+			Reason:  reason,
+		})
+		cancel()
+		if err == nil || !transient(err) {
+			return resp, err
+		}
+		if attempt < maxRetries && !sleepCtx(ctx, time.Duration(1<<uint(attempt))*100*time.Millisecond) {
+			break
+		}
+	}
+	return nil, err
+}
+
+// Reasons recorded when an order's items cannot be reserved.
+const (
+	reasonInsufficientStock = "INSUFFICIENT_STOCK"
+	reasonUnknownSKU        = "UNKNOWN_SKU"
+	// reasonReservationLapsed is an earlier attempt's hold that was released
+	// or expired before this one got to charge.
+	reasonReservationLapsed = "RESERVATION_LAPSED"
+)
+
+// Reservation statuses reported by inventory.
+const (
+	reservationHeld      = "HELD"
+	reservationCommitted = "COMMITTED"
+)
+
+// reserveItems holds stock for an order's items, using the order ID as the
+// reservation ID so retries find the same hold. It reports false and why
+// when the items are out of stock or unknown to inventory, or when a retry
+// finds the hold released or expired. Orders without items, or a gateway
+// without inventory, reserve nothing.
+func (h *handler) reserveItems(ctx context.Context, orderID string, items []lineItem) (bool, string, error) {
+	if h.inventoryClient == nil || len(items) == 0 {
+		return true, "", nil
+	}
+	ctx, span := otel.Tracer("gateway").Start(ctx, "inventory.Reserve")
+	defer span.End()
+	req := &inventory.ReserveRequest{ReservationId: orderID}
+	for _, it := range items {
+		req.Items = append(req.Items, &inventory.ReservationItem{Sku: it.SKU, Quantity: int64(it.Quantity)})
+	}
+	callCtx, cancel := context.WithTimeout(ctx, grpcTimeout)
+	defer cancel()
+	resp, err := h.inventoryClient.Reserve(callCtx, req)
+	switch status.Code(err) {
+	case grpccodes.OK:
+		span.SetAttributes(attribute.String("reservation_status", resp.Status))
+		// A retry of a paid order finds its hold already committed.
+		if resp.Status != reservationHeld && resp.Status != reservationCommitted {
+			return false, reasonReservationLapsed, nil
+		}
+		return true, "", nil
+	case grpccodes.FailedPrecondition:
+		span.SetAttributes(attribute.String("reservation_status", orderStatusOutOfStock))
+		return false, reasonInsufficientStock, nil
+	case grpccodes.NotFound:
+		span.SetAttributes(attribute.String("reservation_status", orderStatusOutOfStock))
+		return false, reasonUnknownSKU, nil
+	}
+	span.RecordError(err)
+	return false, "", err
+}
+
+// settleReservation commits an order's hold after a successful charge and
+// releases it after a decline. A commit is retried while inventory is
+// unavailable and its failure returned: the stock of a paid order must be
+// taken. A failed release is only logged, since an unreleased hold expires.
+func (h *handler) settleReservation(ctx context.Context, orderID string, items []lineItem, paid bool) error {
+	if h.inventoryClient == nil || len(items) == 0 {
+		return nil
+	}
+	if !paid {
+		callCtx, cancel := context.WithTimeout(ctx, grpcTimeout)
+		defer cancel()
+		if _, err := h.inventoryClient.Release(callCtx, &inventory.ReleaseRequest{ReservationId: orderID}); err != nil {
+			log.Error().Err(err).Str("order_id", orderID).Msg("releasing inventory reservation failed")
+		}
+		return nil
+	}
+	const maxRetries = 2
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		callCtx, cancel := context.WithTimeout(ctx, grpcTimeout)
+		_, err = h.inventoryClient.Commit(callCtx, &inventory.CommitRequest{ReservationId: orderID})
+		cancel()
+		if err == nil || !transient(err) {
+			break
+		}
+		if attempt < maxRetries && !sleepCtx(ctx, time.Duration(1<<uint(attempt))*100*time.Millisecond) {
+			break
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Str("order_id", orderID).Msg("committing inventory reservation failed")
+	}
+	return err
+}
+
+type cancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+type cancelOrderResponse struct {
+	OrderID       string `json:"order_id"`
+	OrderStatus   string `json:"order_status"`
+	Reason        string `json:"reason"`
+	PaymentStatus string `json:"payment_status"`
+	RefundedCents int64  `json:"refunded_cents"`
+}
+
+// handleCancelOrder serves POST /orders/{id}/cancel. Orders validates the
+// reason and the order's status and reverses the payment; a status that
+// cannot be cancelled is a 409. If-Match takes the ETag of GET /orders/{id},
+// and a stale one is a 412.
+func (h *handler) handleCancelOrder(w http.ResponseWriter, r *http.Request, orderID string) {
+	ctx, span := otel.Tracer("gateway").Start(r.Context(), "POST /orders/:id/cancel")
+	defer span.End()
+	span.SetAttributes(attribute.String("order_id", orderID))
+	start := time.Now()
+	route := "POST /orders/:id/cancel"
+	method := "POST"
+
+	ctx = platform.WithActor(ctx, h.actor(r, "anonymous"))
+	version, ok := ifMatchVersion(r.Header.Get("If-Match"))
+	if !ok {
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "If-Match must be a single order ETag"})
+		recordHTTP(route, method, "412")
+		return
+	}
+	var req cancelOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		recordHTTP(route, method, "400")
+		return
+	}
+	callCtx, cancel := context.WithTimeout(ctx, grpcTimeout)
+	defer cancel()
+	resp, err := h.ordersClient.CancelOrder(callCtx, &orders.CancelOrderRequest{OrderId: orderID, Reason: req.Reason, ExpectedVersion: version})
+	if err != nil {
+		span.RecordError(err)
+		st := status.Convert(err)
+		code := runtime.HTTPStatusFromCode(st.Code())
+		switch st.Code() {
+		case grpccodes.FailedPrecondition:
+			code = http.StatusConflict
+		case grpccodes.Aborted:
+			code = http.StatusPreconditionFailed
+		}
+		writeJSON(w, code, map[string]string{"error": st.Message()})
+		recordHTTP(route, method, strconv.Itoa(code))
+		return
+	}
+
+	w.Header().Set("ETag", orderETag(resp.Version))
+	out := cancelOrderResponse{
+		OrderID:       resp.OrderId,
+		OrderStatus:   resp.Status,
+		Reason:        resp.Reason,
+		PaymentStatus: resp.PaymentStatus,
+		RefundedCents: resp.RefundedCents,
+	}
+	if r := resp.Refunded; r != nil {
+		out.RefundedCents = r.MinorUnits
+	}
+	writeJSON(w, http.StatusOK, out)
+	recordHTTP(route, method, "200")
+	httpRequestDurationSeconds.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+}
+
+// settleInBackground runs settleOrder after the response has been sent,
+// retrying with backoff for up to asyncSettleTimeout and then abandoning the
+// order. The work keeps the request's trace but not its cancellation, and is
+// tracked so that shutdown can wait for it; shutdown cuts the retries short.
+// opID may be empty when no operation tracks the order.
+func (h *handler) settleInBackground(ctx context.Context, opID string, created *orders.CreateOrderResponse, req createOrderRequest) {
+	orderID := created.OrderId
+	bgCtx := context.WithoutCancel(ctx)
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		ctx, span := otel.Tracer("gateway").Start(bgCtx, "settle order")
+		defer span.End()
+		span.SetAttributes(attribute.String("order_id", orderID), attribute.String("operation_id", opID))
+		retryCtx, cancel := context.WithTimeout(ctx, asyncSettleTimeout)
+		defer cancel()
+		stop := context.AfterFunc(h.shutdown, cancel)
+		defer stop()
+
+		var resp createOrderResponse
+		var err error
+		for attempt := 0; ; attempt++ {
+			resp, err = h.settleOrder(retryCtx, created, req)
+			if err == nil {
+				break
+			}
+			span.RecordError(err)
+			log.Warn().Err(err).Str("order_id", orderID).Str("operation_id", opID).Int("attempt", attempt).Msg("settle failed; retrying")
+			backoff := min(time.Duration(1<<uint(min(attempt, 10)))*time.Second, maxSettleBackoff)
+			if !sleepCtx(retryCtx, backoff) {
+				break
+			}
+		}
+		if err != nil {
+			log.Error().Err(err).Str("order_id", orderID).Str("operation_id", opID).Msg("async settle failed; abandoning the order")
+			if aResp, aErr := h.abandonOrder(ctx, resp, orderID, req.Items); aErr != nil {
+				log.Error().Err(aErr).Str("order_id", orderID).Msg("abandoning the order failed; it stays CREATED")
+			} else {
+				resp = aResp
+			}
+		}
+		h.operations.finish(opID, resp, err)
+	}()
+}
+
+// Reason recorded when an order is abandoned after its settlement failed.
+const reasonSettleFailed = "SETTLE_FAILED"
+
+// abandonOrder gives up on settling an order so it does not stay CREATED.
+// An order whose charge succeeded only lacks its status and is recorded as
+// PAID. Otherwise it is recorded as PAYMENT_FAILED first, which fails if a
+// retry settled the order meanwhile, and then its payment is reversed so a
+// late charge cannot capture, and its hold is released.
+func (h *handler) abandonOrder(ctx context.Context, last createOrderResponse, orderID string, items []lineItem) (createOrderResponse, error) {
+	if last.PaymentSuccess {
+		updResp, err := h.updateOrderStatus(ctx, orderID, orderStatusPaid, last.PaymentCode)
+		if status.Code(err) == grpccodes.FailedPrecondition {
+			updResp, err = h.currentStatus(ctx, orderID, err)
+		}
+		if err != nil {
+			return createOrderResponse{}, err
+		}
+		last.OrderStatus = updResp.Status
+		return last, nil
+	}
+	updResp, err := h.updateOrderStatus(ctx, orderID, orderStatusPaymentFailed, reasonSettleFailed)
+	if status.Code(err) == grpccodes.FailedPrecondition {
+		// A retry or a cancellation settled the order meanwhile.
+		updResp, err = h.currentStatus(ctx, orderID, err)
+		if err != nil {
+			return createOrderResponse{}, err
+		}
+		return createOrderResponse{OrderID: orderID, OrderStatus: updResp.Status}, nil
+	}
+	if err != nil {
+		return createOrderResponse{}, err
+	}
+	ordersAbandonedTotal.Inc()
+	revCtx, cancel := context.WithTimeout(ctx, grpcTimeout)
+	_, err = h.paymentsClient.Reverse(revCtx, &payments.ReverseRequest{OrderId: orderID, Reason: reasonSettleFailed})
+	cancel()
+	if err != nil {
+		log.Error().Err(err).Str("order_id", orderID).Msg("reversing the payment of an abandoned order failed")
+	}
+	_ = h.settleReservation(ctx, orderID, items, false)
+	return createOrderResponse{OrderID: orderID, OrderStatus: updResp.Status, PaymentCode: reasonSettleFailed}, nil
+}
+
+// sleepCtx waits for d and reports false if ctx is done first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// transient reports whether err is a gRPC error worth retrying.
+func transient(err error) bool {
+	switch status.Code(err) {
+	case grpccodes.Unavailable, grpccodes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// actorHeader lets operator tools name who a request is made for; orders
+// records it in the order's history. withTrustedActor drops it from every
+// other request.
+const actorHeader = "X-Actor"
+
+// withTrustedActor removes X-Actor from requests that do not authenticate as
+// an operator, so only operators can act for someone else.
+func withTrustedActor(auth *platform.Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(actorHeader) != "" {
+			if _, ok := auth.Operator(r.Header.Get("Authorization")); !ok {
+				r.Header.Del(actorHeader)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// actor is who orders records as making r: the X-Actor an operator named,
+// the authenticated principal, or fallback for unauthenticated requests.
+func (h *handler) actor(r *http.Request, fallback string) string {
+	if a := r.Header.Get(actorHeader); a != "" {
+		return a
+	}
+	if p, ok := h.auth.Principal(r.Header.Get("Authorization")); ok {
+		return p
+	}
+	return fallback
+}
+
+// wantsAsync reports whether the client asked for async processing with
+// "Prefer: respond-async" (RFC 7240) or ?async=true.
+func wantsAsync(r *http.Request) bool {
+	if v := r.URL.Query().Get("async"); v == "true" || v == "1" {
+		return true
+	}
+	for _, pref := range r.Header.Values("Prefer") {
+		for _, token := range strings.FieldsFunc(pref, func(c rune) bool { return c == ',' || c == ';' }) {
+			if strings.EqualFold(strings.TrimSpace(token), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// chargeWithRetry charges an order through payments, retrying transient
+// failures. An order with nothing to charge is approved without a charge.
+func (h *handler) chargeWithRetry(ctx context.Context, orderID string, amountCents int64, currency, idemKey string) (*payments.ChargeResponse, error) {
+	if amountCents == 0 {
+		return &payments.ChargeResponse{Success: true, Code: paymentNothingDue}, nil
+	}
+	ctx, span := otel.Tracer("gateway").Start(ctx, "payments.Charge")
+	defer span.End()
+	const maxRetries = 2
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		callCtx, cancel := context.WithTimeout(ctx, grpcTimeout)
+		// Seeds chaos decisions per attempt, so a replayed load script
+		// sees the same faults on the same retries.
+		callCtx = metadata.AppendToOutgoingContext(callCtx, chaos.KeyMetadata, fmt.Sprintf("%s#%d", idemKey, attempt))
+		// The amount goes in both forms while payments replicas that
+		// predate Money may still be running.
+		resp, err := h.paymentsClient.Charge(callCtx, &payments.ChargeRequest{
+			OrderId:        orderID,
+			AmountCents:    amountCents,
+			Currency:       currency,
+			IdempotencyKey: idemKey,
+			Amount:         &moneypb.Money{Currency: currency, MinorUnits: amountCents},
+		})
+		cancel()
+		if err == nil {
+			if !resp.Success && resp.Code == "DECLINED" {
+				return resp, nil
+			}
+			return resp, nil
+		}
+		lastErr = err
+		if _, ok := status.FromError(err); !ok || !transient(err) {
+			return nil, err
+		}
+		if attempt < maxRetries {
+			jitter := time.Duration(rand.Intn(50)+25) * time.Millisecond
+			backoff := time.NewTimer(time.Duration(1<<uint(attempt))*100*time.Millisecond + jitter)
+			select {
+			case <-backoff.C:
+			case <-ctx.Done():
+				backoff.Stop()
+				return nil, lastErr
+			}
+		}
+	}
+	return nil, lastErr
+}
+
+func recordHTTP(route, method, status string) {
+	httpRequestsTotal.WithLabelValues(route, method, status).Inc()
+}
+
+type fieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// errorBody is the JSON error for err, with the fields a BadRequest detail
+// names.
+func errorBody(err error) any {
+	body := struct {
+		Error  string           `json:"error"`
+		Fields []fieldViolation `json:"fields,omitempty"`
+	}{Error: err.Error()}
+	for _, d := range status.Convert(err).Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				body.Fields = append(body.Fields, fieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+	return body
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,279 @@
+package gatewaysvc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/reliability-lab/pkg/platform"
+)
+
+const (
+	idempotencyHeader   = "Idempotency-Key"
+	maxIdempotencyKey   = 255
+	maxIdempotentBody   = 1 << 20
+	defaultIdemKeyTTL   = 24 * time.Hour
+	idempotencyReplayed = "Idempotent-Replayed"
+)
+
+// idemOutcome is the result of claiming an Idempotency-Key.
+type idemOutcome int
+
+const (
+	idemStarted  idemOutcome = iota // first request; caller must complete or release
+	idemReplay                      // a stored response exists for the same request
+	idemInFlight                    // the first request is still running
+	idemMismatch                    // key was reused with a different request
+)
+
+type idemRecord struct {
+	requestHash string
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+	expiresAt   time.Time
+}
+
+// idempotencyStore keeps the final response of mutating requests keyed by
+// (client, Idempotency-Key) together with a hash of the request, so
+// retries are answered byte-for-byte without re-running the handler.
+type idempotencyStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	records   map[string]*idemRecord
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newIdempotencyStore(ttl time.Duration) *idempotencyStore {
+	if ttl <= 0 {
+		ttl = defaultIdemKeyTTL
+	}
+	return &idempotencyStore{ttl: ttl, records: make(map[string]*idemRecord), now: time.Now}
+}
+
+// begin claims key for a request with the given hash. For idemReplay the
+// stored record is returned.
+func (s *idempotencyStore) begin(key, requestHash string) (idemOutcome, *idemRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweepLocked(now)
+	if rec, ok := s.records[key]; ok && now.Before(rec.expiresAt) {
+		switch {
+		case rec.requestHash != requestHash:
+			return idemMismatch, nil
+		case !rec.done:
+			return idemInFlight, nil
+		default:
+			return idemReplay, rec
+		}
+	}
+	s.records[key] = &idemRecord{requestHash: requestHash, expiresAt: now.Add(s.ttl)}
+	return idemStarted, nil
+}
+
+// complete stores the final response for a key claimed with begin.
+func (s *idempotencyStore) complete(key string, status int, header http.Header, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[key]
+	if !ok {
+		return
+	}
+	rec.done = true
+	rec.status = status
+	rec.header = header
+	rec.body = body
+	rec.expiresAt = s.now().Add(s.ttl)
+}
+
+// release forgets a claimed key so the request can be retried.
+func (s *idempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+}
+
+func (s *idempotencyStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for k, rec := range s.records {
+		if !now.Before(rec.expiresAt) {
+			delete(s.records, k)
+		}
+	}
+}
+
+// withIdempotency honors the Idempotency-Key header, or an idempotency_key in
+// a JSON body without one, on mutating requests. Responses below 500 are
+// stored and replayed for retries of the same request by the same client; a
+// concurrent duplicate gets 409 and reusing a key for a different request
+// gets 422. 5xx responses release the key so the client can retry.
+func withIdempotency(store *idempotencyStore, auth *platform.Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := r.Header.Get(idempotencyHeader)
+		if len(key) > maxIdempotencyKey {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Idempotency-Key too long"})
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "request body unreadable"})
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			if key != "" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "request body too large for an idempotent request"})
+				return
+			}
+			// Too large to hold a replayable request; hand the whole body on.
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			next.ServeHTTP(w, r)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fromBody := false
+		if key == "" {
+			key, fromBody = bodyKey(body), true
+		}
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "idempotency key too long"})
+			return
+		}
+
+		storeKey := clientIdentity(r, auth) + "\x00" + key
+		outcome, rec := store.begin(storeKey, requestHash(r, body))
+		switch outcome {
+		case idemReplay:
+			for k, v := range rec.header {
+				w.Header()[k] = v
+			}
+			w.Header().Set(idempotencyReplayed, "true")
+			w.WriteHeader(rec.status)
+			_, _ = w.Write(rec.body)
+			return
+		case idemInFlight:
+			if fromBody {
+				// Duplicates of a body key have always converged on one
+				// order in the orders service; let them through unstored.
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "a request with this Idempotency-Key is already in progress"})
+			return
+		case idemMismatch:
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "Idempotency-Key was already used for a different request"})
+			return
+		}
+
+		cw := &capturingWriter{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			if !completed {
+				store.release(storeKey)
+			}
+		}()
+		next.ServeHTTP(cw, r)
+		if cw.status >= http.StatusInternalServerError {
+			return
+		}
+		store.complete(storeKey, cw.status, cw.header, cw.body.Bytes())
+		completed = true
+	})
+}
+
+// capturingWriter passes the response through while keeping a copy of the
+// status, headers and body.
+type capturingWriter struct {
+	http.ResponseWriter
+	status      int
+	header      http.Header
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (c *capturingWriter) WriteHeader(code int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	c.status = code
+	c.header = c.ResponseWriter.Header().Clone()
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *capturingWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// clientIdentity is who an idempotency key belongs to, so stored responses
+// are never replayed to another client: the principal of a valid bearer
+// token, else a hash of whatever Authorization was sent, else the client's
+// address.
+func clientIdentity(r *http.Request, auth *platform.Authenticator) string {
+	authz := r.Header.Get("Authorization")
+	if p, ok := auth.Principal(authz); ok {
+		return "principal:" + p
+	}
+	if authz != "" {
+		sum := sha256.Sum256([]byte(authz))
+		return "authorization:" + hex.EncodeToString(sum[:])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
+
+// bodyKey returns the idempotency_key of a JSON body, "" if there is none.
+func bodyKey(body []byte) string {
+	var v struct {
+		IdempotencyKey string `json:"idempotency_key"`
+	}
+	if json.Unmarshal(body, &v) != nil {
+		return ""
+	}
+	return v.IdempotencyKey
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n"+strconv.Itoa(len(body))+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
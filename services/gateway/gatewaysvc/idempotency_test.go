@@ -0,0 +1,159 @@
+package gatewaysvc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reliability-lab/pkg/platform"
+)
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	var calls int32
+	h := withIdempotency(newIdempotencyStore(time.Hour), nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"call": n})
+	}))
+
+	do := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"user_id":"u1"}`))
+		req.Header.Set(idempotencyHeader, "key-1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	first := do()
+	second := do()
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("body mismatch: first=%q second=%q", first.Body.String(), second.Body.String())
+	}
+	if second.Header().Get(idempotencyReplayed) != "true" {
+		t.Errorf("expected %s header on replay", idempotencyReplayed)
+	}
+	if second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type not replayed: %q", second.Header().Get("Content-Type"))
+	}
+}
+
+func TestIdempotency_ConcurrentDuplicateConflicts(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := withIdempotency(newIdempotencyStore(time.Hour), nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	newReq := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+		req.Header.Set(idempotencyHeader, "key-2")
+		return req
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), newReq())
+		close(done)
+	}()
+	<-started
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newReq())
+	close(release)
+	<-done
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for in-flight duplicate, got %d", rec.Code)
+	}
+}
+
+func TestIdempotency_DifferentRequestRejected(t *testing.T) {
+	h := withIdempotency(newIdempotencyStore(time.Hour), nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for i, body := range []string{`{"amount_cents":100}`, `{"amount_cents":200}`} {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		req.Header.Set(idempotencyHeader, "key-3")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		want := http.StatusOK
+		if i == 1 {
+			want = http.StatusUnprocessableEntity
+		}
+		if rec.Code != want {
+			t.Errorf("request %d: got %d, want %d", i, rec.Code, want)
+		}
+	}
+}
+
+func TestIdempotency_ServerErrorIsNotStored(t *testing.T) {
+	var calls int32
+	h := withIdempotency(newIdempotencyStore(time.Hour), nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+		req.Header.Set(idempotencyHeader, "key-4")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2 (5xx must not be replayed)", calls)
+	}
+}
+
+func TestIdempotency_BodyKeyIsReplayed(t *testing.T) {
+	var calls int32
+	h := withIdempotency(newIdempotencyStore(time.Hour), nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"user_id":"u1","idempotency_key":"key-5"}`))
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestIdempotency_ScopedByClient(t *testing.T) {
+	auth, err := platform.NewAuthenticator([]string{"t1=user:u1", "t2=user:u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int32
+	h := withIdempotency(newIdempotencyStore(time.Hour), auth, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	do := func(remoteAddr, authorization string) {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+		req.Header.Set(idempotencyHeader, "key-6")
+		req.RemoteAddr = remoteAddr
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	do("10.0.0.1:1000", "")
+	do("10.0.0.2:1000", "")
+	if calls != 2 {
+		t.Errorf("anonymous clients at different addresses: handler called %d times, want 2", calls)
+	}
+	do("10.0.0.3:1000", "Bearer t1")
+	do("10.0.0.4:1000", "Bearer t2")
+	if calls != 3 {
+		t.Errorf("tokens of one principal: handler called %d times, want 3", calls)
+	}
+}
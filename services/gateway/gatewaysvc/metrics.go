@@ -1,4 +1,4 @@
-package main
+package gatewaysvc
 
 import (
 	"github.com/prometheus/client_golang/prometheus"
@@ -20,8 +20,21 @@ var (
 		},
 		[]string{"route", "method"},
 	)
+	activeStreams = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gateway_active_event_streams",
+			Help: "Open order event streams by transport",
+		},
+		[]string{"transport"},
+	)
+	ordersAbandonedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_orders_abandoned_total",
+			Help: "Orders recorded as PAYMENT_FAILED after their settlement kept failing",
+		},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(httpRequestsTotal, httpRequestDurationSeconds)
+	prometheus.MustRegister(httpRequestsTotal, httpRequestDurationSeconds, activeStreams, ordersAbandonedTotal)
 }
@@ -0,0 +1,110 @@
+package gatewaysvc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	opRunning   = "RUNNING"
+	opSucceeded = "SUCCEEDED"
+	opFailed    = "FAILED"
+
+	// operationTTL is how long finished operations stay queryable.
+	operationTTL = time.Hour
+)
+
+// operation tracks an async POST /orders until the order settles.
+type operation struct {
+	ID        string               `json:"id"`
+	OrderID   string               `json:"order_id"`
+	Status    string               `json:"status"`
+	Done      bool                 `json:"done"`
+	Result    *createOrderResponse `json:"result,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// operationStore is an in-memory registry of async operations. Operations
+// are lost on restart; the order itself is durable in the orders service.
+type operationStore struct {
+	mu  sync.Mutex
+	ops map[string]*operation
+}
+
+func newOperationStore() *operationStore {
+	return &operationStore{ops: make(map[string]*operation)}
+}
+
+func (s *operationStore) start(orderID string) operation {
+	now := time.Now().UTC()
+	op := &operation{ID: newOperationID(), OrderID: orderID, Status: opRunning, CreatedAt: now, UpdatedAt: now}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, o := range s.ops {
+		if o.Done && now.Sub(o.UpdatedAt) > operationTTL {
+			delete(s.ops, id)
+		}
+	}
+	s.ops[op.ID] = op
+	return *op
+}
+
+func (s *operationStore) finish(id string, res createOrderResponse, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op, ok := s.ops[id]
+	if !ok {
+		return
+	}
+	op.Done = true
+	op.UpdatedAt = time.Now().UTC()
+	if err != nil {
+		op.Status = opFailed
+		op.Error = err.Error()
+		return
+	}
+	op.Status = opSucceeded
+	op.Result = &res
+}
+
+func (s *operationStore) get(id string) (operation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op, ok := s.ops[id]
+	if !ok {
+		return operation{}, false
+	}
+	return *op, true
+}
+
+func newOperationID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (h *handler) handleGetOperation(w http.ResponseWriter, r *http.Request) {
+	route := "GET /operations/:id"
+	method := "GET"
+
+	id := strings.TrimPrefix(r.URL.Path, "/operations/")
+	if id == "" || strings.Contains(id, "/") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid operation id"})
+		recordHTTP(route, method, "400")
+		return
+	}
+	op, ok := h.operations.get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "operation not found"})
+		recordHTTP(route, method, "404")
+		return
+	}
+	writeJSON(w, http.StatusOK, op)
+	recordHTTP(route, method, "200")
+}
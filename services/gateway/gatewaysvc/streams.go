@@ -0,0 +1,398 @@
+package gatewaysvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/reliability-lab/gen/orders"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// streamHeartbeat is how often idle SSE and WebSocket streams send a
+	// keep-alive so proxies don't time them out.
+	streamHeartbeat = 15 * time.Second
+	// streamWriteTimeout bounds each write; the server-wide WriteTimeout
+	// would otherwise cut long-lived streams.
+	streamWriteTimeout = 10 * time.Second
+	sseRetryMillis     = 3000
+)
+
+var eventJSON = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 4096}
+
+// streamContext returns a context that ends when either the request does or
+// the gateway starts shutting down.
+func (h *handler) streamContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	stop := context.AfterFunc(h.shutdown, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// openWatch starts Orders.WatchOrders and waits for the response headers so
+// that NotFound and InvalidArgument surface before anything is sent to the client.
+func (h *handler) openWatch(ctx context.Context, req *orders.WatchOrdersRequest) (orders.Orders_WatchOrdersClient, error) {
+	stream, err := h.ordersClient.WatchOrders(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	md, err := stream.Header()
+	if err != nil {
+		return nil, err
+	}
+	if md == nil {
+		// Terminated without headers: the status is only available from Recv.
+		if _, err := stream.Recv(); err != nil {
+			return nil, err
+		}
+	}
+	return stream, nil
+}
+
+// pumpEvents forwards events from stream to out. When the stream breaks it
+// resumes Orders.WatchOrders after the last sequence with backoff, until ctx
+// is done or the orders service rejects the request.
+func (h *handler) pumpEvents(ctx context.Context, stream orders.Orders_WatchOrdersClient, req *orders.WatchOrdersRequest, out chan<- *orders.OrderEvent) error {
+	backoff := 200 * time.Millisecond
+	for {
+		for {
+			ev, err := stream.Recv()
+			if err != nil {
+				break
+			}
+			backoff = 200 * time.Millisecond
+			req.AfterSequence = ev.Sequence
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			if backoff < 5*time.Second {
+				backoff *= 2
+			}
+			var err error
+			stream, err = h.openWatch(ctx, req)
+			if err == nil {
+				break
+			}
+			if c := status.Code(err); c == grpccodes.NotFound || c == grpccodes.InvalidArgument {
+				return err
+			}
+		}
+	}
+}
+
+// parseWatchRequest builds the WatchOrders request, resuming after
+// Last-Event-ID (header, or last_event_id query for clients that can't set it).
+func parseWatchRequest(r *http.Request, orderID, userID string) (*orders.WatchOrdersRequest, error) {
+	req := &orders.WatchOrdersRequest{OrderId: orderID, UserId: userID}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	if lastID != "" {
+		seq, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || seq < 0 {
+			return nil, fmt.Errorf("invalid Last-Event-ID %q", lastID)
+		}
+		req.AfterSequence = seq
+	}
+	return req, nil
+}
+
+func writeWatchError(w http.ResponseWriter, route string, err error) {
+	code := http.StatusBadGateway
+	switch status.Code(err) {
+	case grpccodes.NotFound:
+		code = http.StatusNotFound
+	case grpccodes.InvalidArgument:
+		code = http.StatusBadRequest
+	case grpccodes.Unavailable:
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, map[string]string{"error": status.Convert(err).Message()})
+	recordHTTP(route, "GET", strconv.Itoa(code))
+}
+
+// handleEventsSSE serves GET /orders/{id}/events and GET /users/{id}/events
+// as Server-Sent Events.
+func (h *handler) handleEventsSSE(w http.ResponseWriter, r *http.Request, route, orderID, userID string) {
+	ctx, cancel := h.streamContext(r.Context())
+	defer cancel()
+	ctx, span := otel.Tracer("gateway").Start(ctx, route)
+	defer span.End()
+	span.SetAttributes(attribute.String("order_id", orderID), attribute.String("user_id", userID))
+
+	req, err := parseWatchRequest(r, orderID, userID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		recordHTTP(route, "GET", "400")
+		return
+	}
+	stream, err := h.openWatch(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		writeWatchError(w, route, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	recordHTTP(route, "GET", "200")
+	activeStreams.WithLabelValues("sse").Inc()
+	defer activeStreams.WithLabelValues("sse").Dec()
+
+	write := func(frame string) error {
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprint(w, frame); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	if err := write(fmt.Sprintf("retry: %d\n\n", sseRetryMillis)); err != nil {
+		return
+	}
+
+	events := make(chan *orders.OrderEvent)
+	pumpErr := make(chan error, 1)
+	go func() { pumpErr <- h.pumpEvents(ctx, stream, req, events) }()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-pumpErr:
+			if ctx.Err() == nil {
+				span.RecordError(err)
+				_ = write(fmt.Sprintf("event: error\ndata: %s\n\n", jsonString(status.Convert(err).Message())))
+			}
+			return
+		case ev := <-events:
+			data, err := eventJSON.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if err := write(fmt.Sprintf("id: %d\nevent: order_status\ndata: %s\n\n", ev.Sequence, data)); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// wsMessage is the envelope sent to WebSocket clients.
+type wsMessage struct {
+	ID   int64           `json:"id,omitempty"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// handleEventsWebSocket serves GET /ws/orders?order_id=... or ?user_id=...
+// Resume with ?last_event_id=<sequence>. Heartbeats are WebSocket pings.
+func (h *handler) handleEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	route := "GET /ws/orders"
+	ctx, cancel := h.streamContext(r.Context())
+	defer cancel()
+	ctx, span := otel.Tracer("gateway").Start(ctx, route)
+	defer span.End()
+
+	q := r.URL.Query()
+	orderID, userID := q.Get("order_id"), q.Get("user_id")
+	if (orderID == "") == (userID == "") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "exactly one of order_id or user_id required"})
+		recordHTTP(route, "GET", "400")
+		return
+	}
+	span.SetAttributes(attribute.String("order_id", orderID), attribute.String("user_id", userID))
+	req, err := parseWatchRequest(r, orderID, userID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		recordHTTP(route, "GET", "400")
+		return
+	}
+	stream, err := h.openWatch(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		writeWatchError(w, route, err)
+		return
+	}
+
+	up := upgrader
+	up.CheckOrigin = func(r *http.Request) bool { return sameOriginOrAllowed(r, h.corsOrigins) }
+	conn, err := up.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already wrote the error response.
+		recordHTTP(route, "GET", "400")
+		return
+	}
+	// The connection is hijacked, so http.Server.Shutdown no longer tracks it.
+	h.streams.Add(1)
+	defer h.streams.Done()
+	defer conn.Close()
+	recordHTTP(route, "GET", "101")
+	activeStreams.WithLabelValues("websocket").Inc()
+	defer activeStreams.WithLabelValues("websocket").Dec()
+
+	// Reader: handles pongs and notices when the client goes away.
+	conn.SetReadLimit(1024)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeat))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	events := make(chan *orders.OrderEvent)
+	pumpErr := make(chan error, 1)
+	go func() { pumpErr <- h.pumpEvents(ctx, stream, req, events) }()
+
+	closeWith := func(code int, reason string) {
+		msg := websocket.FormatCloseMessage(code, reason)
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	}
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if h.shutdown.Err() != nil {
+				closeWith(websocket.CloseGoingAway, "gateway shutting down")
+			}
+			return
+		case err := <-pumpErr:
+			if ctx.Err() == nil {
+				span.RecordError(err)
+				closeWith(websocket.CloseInternalServerErr, status.Convert(err).Message())
+			} else if h.shutdown.Err() != nil {
+				closeWith(websocket.CloseGoingAway, "gateway shutting down")
+			}
+			return
+		case ev := <-events:
+			data, err := eventJSON.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteJSON(wsMessage{ID: ev.Sequence, Type: "order_status", Data: data}); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sameOriginOrAllowed accepts non-browser clients, same-origin pages and the
+// configured CORS origins.
+func sameOriginOrAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return corsAllowed(allowed, origin)
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// eventsPath extracts {id} from /<prefix>/{id}/events.
+func eventsPath(path, prefix string) (string, bool) {
+	return idPath(path, prefix, "/events")
+}
+
+// idPath extracts {id} from prefix + "{id}" + suffix.
+func idPath(path, prefix, suffix string) (string, bool) {
+	rest := strings.TrimPrefix(path, prefix)
+	id, ok := strings.CutSuffix(rest, suffix)
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return "", false
+	}
+	return id, true
+}
+
+// routeOrders sends GET /orders/{id}/events to the SSE handler, POST
+// /orders/{id}/cancel to the cancel handler and everything else under
+// /orders/ to the transcoded REST routes.
+func (h *handler) routeOrders(rest http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := idPath(r.URL.Path, "/orders/", "/cancel"); ok {
+			if r.Method != http.MethodPost {
+				httpRequestsTotal.WithLabelValues("POST /orders/:id/cancel", r.Method, "405").Inc()
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			h.handleCancelOrder(w, r, id)
+			return
+		}
+		if id, ok := eventsPath(r.URL.Path, "/orders/"); ok {
+			if r.Method != http.MethodGet {
+				httpRequestsTotal.WithLabelValues("GET /orders/:id/events", r.Method, "405").Inc()
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			h.handleEventsSSE(w, r, "GET /orders/:id/events", id, "")
+			return
+		}
+		rest.ServeHTTP(w, r)
+	}
+}
+
+func (h *handler) handleUserEvents(w http.ResponseWriter, r *http.Request) {
+	route := "GET /users/:id/events"
+	id, ok := eventsPath(r.URL.Path, "/users/")
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		recordHTTP("", r.Method, "404")
+		return
+	}
+	if r.Method != http.MethodGet {
+		httpRequestsTotal.WithLabelValues(route, r.Method, "405").Inc()
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	h.handleEventsSSE(w, r, route, "", id)
+}
@@ -0,0 +1,124 @@
+package gatewaysvc
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reliability-lab/gen/orders"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeWatchClient serves WatchOrders from a channel; other RPCs are unused.
+type fakeWatchClient struct {
+	orders.OrdersClient
+	events chan *orders.OrderEvent
+	reqs   chan *orders.WatchOrdersRequest
+	err    error
+}
+
+func (f *fakeWatchClient) WatchOrders(ctx context.Context, in *orders.WatchOrdersRequest, _ ...grpc.CallOption) (orders.Orders_WatchOrdersClient, error) {
+	f.reqs <- in
+	return &fakeWatchStream{ctx: ctx, events: f.events, err: f.err}, nil
+}
+
+type fakeWatchStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	events chan *orders.OrderEvent
+	err    error
+}
+
+func (s *fakeWatchStream) Header() (metadata.MD, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return metadata.MD{}, nil
+}
+
+func (s *fakeWatchStream) Recv() (*orders.OrderEvent, error) {
+	select {
+	case ev := <-s.events:
+		return ev, nil
+	case <-s.ctx.Done():
+		return nil, status.FromContextError(s.ctx.Err()).Err()
+	}
+}
+
+func newStreamHandler(client orders.OrdersClient) *handler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &handler{ordersClient: client, shutdown: ctx, stopStreams: cancel}
+}
+
+func TestOrderEventsSSE_ResumesAndClosesOnShutdown(t *testing.T) {
+	fake := &fakeWatchClient{events: make(chan *orders.OrderEvent, 1), reqs: make(chan *orders.WatchOrdersRequest, 1)}
+	h := newStreamHandler(fake)
+	srv := httptest.NewServer(h.routeOrders(http.NotFoundHandler()))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/orders/o1/events", nil)
+	req.Header.Set("Last-Event-ID", "41")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if got := <-fake.reqs; got.OrderId != "o1" || got.AfterSequence != 41 {
+		t.Fatalf("WatchOrders request = %+v, want order o1 after 41", got)
+	}
+
+	fake.events <- &orders.OrderEvent{Sequence: 42, OrderId: "o1", Status: "PAID"}
+	r := bufio.NewReader(resp.Body)
+	var frame []string
+	for len(frame) < 3 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "retry:") {
+			frame = append(frame, line)
+		}
+	}
+	if frame[0] != "id: 42" || frame[1] != "event: order_status" || !strings.Contains(frame[2], `"status":"PAID"`) {
+		t.Fatalf("unexpected frame %q", frame)
+	}
+
+	h.stopStreams()
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, r)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("stream ended uncleanly: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream still open after shutdown")
+	}
+}
+
+func TestOrderEventsSSE_NotFound(t *testing.T) {
+	fake := &fakeWatchClient{
+		reqs: make(chan *orders.WatchOrdersRequest, 1),
+		err:  status.Error(grpccodes.NotFound, "order not found"),
+	}
+	h := newStreamHandler(fake)
+	rec := httptest.NewRecorder()
+	h.routeOrders(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/missing/events", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("got %d, want 404", rec.Code)
+	}
+}
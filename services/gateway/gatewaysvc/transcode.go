@@ -0,0 +1,273 @@
+package gatewaysvc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/reliability-lab/gen/catalog"
+	"github.com/reliability-lab/gen/catalog/catalogconnect"
+	"github.com/reliability-lab/gen/inventory"
+	"github.com/reliability-lab/gen/inventory/inventoryconnect"
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/gen/orders/ordersconnect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// newRESTMux builds the REST endpoints transcoded from the google.api.http
+// annotations in proto/*.proto. JSON keeps the snake_case field names the
+// hand-written routes use. With legacy set, int64 fields are JSON numbers as
+// on the unversioned routes; otherwise they are strings per the proto3 JSON
+// mapping, as on /v1/.
+func newRESTMux(ctx context.Context, h *handler, legacy bool) (http.Handler, error) {
+	jsonpb := runtime.JSONPb{
+		MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+		UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+	}
+	var marshaler runtime.Marshaler = &jsonpb
+	if legacy {
+		marshaler = &legacyJSON{JSONPb: jsonpb}
+	}
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, marshaler),
+		runtime.WithErrorHandler(restErrorHandler),
+		runtime.WithForwardResponseOption(recordRoute),
+		runtime.WithForwardResponseOption(setOrderETag),
+	)
+	if err := orders.RegisterOrdersHandlerClient(ctx, mux, h.ordersClient); err != nil {
+		return nil, err
+	}
+	if h.catalogClient != nil {
+		if err := catalog.RegisterCatalogHandlerClient(ctx, mux, h.catalogClient); err != nil {
+			return nil, err
+		}
+	}
+	if h.inventoryClient != nil {
+		if err := inventory.RegisterInventoryHandlerClient(ctx, mux, h.inventoryClient); err != nil {
+			return nil, err
+		}
+	}
+	return instrumentREST(withIfNoneMatch(mux)), nil
+}
+
+// legacyJSON writes responses as JSONPb does but with int64 fields as JSON
+// numbers, the shape GET /orders/{id} had before it was transcoded.
+type legacyJSON struct {
+	runtime.JSONPb
+}
+
+func (m *legacyJSON) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return m.JSONPb.Marshal(v)
+	}
+	return json.Marshal(jsonObject(msg.ProtoReflect()))
+}
+
+// jsonObject renders every field of m under its proto name; unset messages
+// are null, as with EmitUnpopulated.
+func jsonObject(m protoreflect.Message) map[string]any {
+	out := make(map[string]any)
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := string(fd.Name())
+		switch {
+		case fd.IsList():
+			list := m.Get(fd).List()
+			items := make([]any, list.Len())
+			for j := range items {
+				items[j] = jsonValue(fd, list.Get(j))
+			}
+			out[name] = items
+		case fd.Message() != nil && !m.Has(fd):
+			out[name] = nil
+		default:
+			out[name] = jsonValue(fd, m.Get(fd))
+		}
+	}
+	return out
+}
+
+func jsonValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return jsonObject(v.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return v.Enum()
+	default:
+		return v.Interface()
+	}
+}
+
+// routeRecorder captures the status code and the matched path pattern so that
+// transcoded routes report the same http_requests_total labels as the
+// hand-written ones.
+type routeRecorder struct {
+	http.ResponseWriter
+	status int
+	route  string
+}
+
+func (r *routeRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *routeRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func instrumentREST(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &routeRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		route := ""
+		if rec.route != "" {
+			route = r.Method + " " + rec.route
+		}
+		recordHTTP(route, r.Method, strconv.Itoa(rec.status))
+		if route != "" {
+			httpRequestDurationSeconds.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		}
+	})
+}
+
+func recordRoute(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	setRoute(ctx, w)
+	return nil
+}
+
+func setRoute(ctx context.Context, w http.ResponseWriter) {
+	rec, ok := w.(*routeRecorder)
+	if !ok {
+		return
+	}
+	if pattern, ok := runtime.HTTPPathPattern(ctx); ok {
+		rec.route = pattern
+	}
+}
+
+// restErrorHandler maps gRPC errors to the {"error": "..."} body used by the
+// rest of the gateway instead of the grpc-gateway status envelope, and
+// errNotModified to an empty 304.
+func restErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+	setRoute(ctx, w)
+	if errors.Is(err, errNotModified) {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	st := status.Convert(err)
+	writeJSON(w, runtime.HTTPStatusFromCode(st.Code()), map[string]string{"error": st.Message()})
+}
+
+// registerConnect mounts Connect, gRPC and gRPC-Web handlers on mux so
+// browser clients can call them on the gateway port. Only the public reads
+// are served; checkout, payments, notifications and order writes stay behind
+// the gateway's own routes.
+func registerConnect(mux *http.ServeMux, h *handler, corsOrigins []string) {
+	path, hdl := ordersconnect.NewOrdersHandler(&ordersConnect{client: h.ordersClient})
+	mux.Handle(path, withCORS(corsOrigins, hdl))
+	if h.catalogClient != nil {
+		path, hdl = catalogconnect.NewCatalogHandler(&catalogConnect{client: h.catalogClient})
+		mux.Handle(path, withCORS(corsOrigins, hdl))
+	}
+	if h.inventoryClient != nil {
+		path, hdl = inventoryconnect.NewInventoryHandler(&inventoryConnect{client: h.inventoryClient})
+		mux.Handle(path, withCORS(corsOrigins, hdl))
+	}
+}
+
+type ordersConnect struct {
+	ordersconnect.UnimplementedOrdersHandler
+	client orders.OrdersClient
+}
+
+func (c *ordersConnect) GetOrder(ctx context.Context, req *connect.Request[orders.GetOrderRequest]) (*connect.Response[orders.GetOrderResponse], error) {
+	return forward(ctx, req, c.client.GetOrder)
+}
+
+func (c *ordersConnect) GetOrderHistory(ctx context.Context, req *connect.Request[orders.GetOrderHistoryRequest]) (*connect.Response[orders.GetOrderHistoryResponse], error) {
+	return forward(ctx, req, c.client.GetOrderHistory)
+}
+
+type catalogConnect struct {
+	catalogconnect.UnimplementedCatalogHandler
+	client catalog.CatalogClient
+}
+
+func (c *catalogConnect) ListProducts(ctx context.Context, req *connect.Request[catalog.ListProductsRequest]) (*connect.Response[catalog.ListProductsResponse], error) {
+	return forward(ctx, req, c.client.ListProducts)
+}
+
+func (c *catalogConnect) GetProduct(ctx context.Context, req *connect.Request[catalog.GetProductRequest]) (*connect.Response[catalog.GetProductResponse], error) {
+	return forward(ctx, req, c.client.GetProduct)
+}
+
+type inventoryConnect struct {
+	inventoryconnect.UnimplementedInventoryHandler
+	client inventory.InventoryClient
+}
+
+func (c *inventoryConnect) GetStock(ctx context.Context, req *connect.Request[inventory.GetStockRequest]) (*connect.Response[inventory.GetStockResponse], error) {
+	return forward(ctx, req, c.client.GetStock)
+}
+
+// forward relays a Connect request to the backend gRPC client, bounded by grpcTimeout.
+func forward[Req, Res any](ctx context.Context, req *connect.Request[Req], call func(context.Context, *Req, ...grpc.CallOption) (*Res, error)) (*connect.Response[Res], error) {
+	callCtx, cancel := context.WithTimeout(ctx, grpcTimeout)
+	defer cancel()
+	resp, err := call(callCtx, req.Msg)
+	if err != nil {
+		st := status.Convert(err)
+		return nil, connect.NewError(connect.Code(st.Code()), errors.New(st.Message()))
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// withCORS allows the listed browser origins ("*" for any) to call the
+// Connect/gRPC-Web endpoints.
+func withCORS(allowed []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && corsAllowed(allowed, origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+			w.Header().Set("Access-Control-Expose-Headers", "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin")
+			if r.Method == http.MethodOptions {
+				w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms, Grpc-Timeout, X-Grpc-Web, X-User-Agent")
+				w.Header().Set("Access-Control-Max-Age", "7200")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func corsAllowed(allowed []string, origin string) bool {
+	for _, o := range allowed {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
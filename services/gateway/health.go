@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// dialGRPC opens a non-blocking connection that balances over every address
+// of target and only uses backends whose grpc.health.v1 status for service
+// is SERVING. opts are the platform client options (svc.DialOptions()).
+func dialGRPC(target, service string, opts []grpc.DialOption) (*grpc.ClientConn, error) {
+	cfg := fmt.Sprintf(`{"loadBalancingConfig":[{"round_robin":{}}],"healthCheckConfig":{"serviceName":%q}}`, service)
+	return grpc.Dial("dns:///"+target, append(opts, grpc.WithDefaultServiceConfig(cfg))...)
+}
+
+// upstream caches the connectivity state of a client connection so /readyz
+// answers from memory instead of dialing on every probe. With health-checked
+// connections READY means at least one backend reports SERVING.
+type upstream struct {
+	name  string
+	conn  *grpc.ClientConn
+	state atomic.Value // connectivity.State
+}
+
+func watchUpstream(ctx context.Context, name string, conn *grpc.ClientConn) *upstream {
+	u := &upstream{name: name, conn: conn}
+	u.state.Store(conn.GetState())
+	go u.watch(ctx)
+	return u
+}
+
+func (u *upstream) watch(ctx context.Context) {
+	for {
+		st := u.conn.GetState()
+		if prev := u.state.Swap(st); prev != st {
+			log.Info().Str("upstream", u.name).Str("state", st.String()).Msg("upstream state changed")
+		}
+		if st == connectivity.Idle {
+			u.conn.Connect()
+		}
+		if !u.conn.WaitForStateChange(ctx, st) {
+			return
+		}
+	}
+}
+
+// check is a platform readiness check.
+func (u *upstream) check(context.Context) error {
+	if st := u.state.Load().(connectivity.State); st != connectivity.Ready {
+		return fmt.Errorf("%s connection %s", u.name, st)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+module github.com/reliability-lab/services/inventory
+
+go 1.22
+
+require (
+	github.com/reliability-lab/gen v0.0.0
+	github.com/reliability-lab/pkg/platform v0.0.0
+	github.com/jackc/pgx/v5 v5.5.2
+	github.com/prometheus/client_golang v1.18.0
+	github.com/rs/zerolog v1.32.0
+	github.com/testcontainers/testcontainers-go/modules/postgres v0.28.0
+	go.opentelemetry.io/otel v1.24.0
+	google.golang.org/grpc v1.62.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/reliability-lab/gen => ../../gen
+
+replace github.com/reliability-lab/pkg/platform => ../../pkg/platform
@@ -0,0 +1,199 @@
+package inventorysvc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store held in process memory, for tests and local runs.
+type MemoryStore struct {
+	mu           sync.Mutex
+	stock        map[string]*Stock
+	reservations map[string]*Reservation
+}
+
+// NewMemoryStore returns a store that tracks no SKUs.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{stock: make(map[string]*Stock), reservations: make(map[string]*Reservation)}
+}
+
+func (m *MemoryStore) SetStock(ctx context.Context, sku string, onHand int64) (Stock, error) {
+	if err := ctx.Err(); err != nil {
+		return Stock{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.stock[sku]
+	if !ok {
+		s = &Stock{SKU: sku}
+		m.stock[sku] = s
+	}
+	if onHand < s.Reserved {
+		return Stock{}, fmt.Errorf("%w: sku %s has %d reserved", ErrInsufficientStock, sku, s.Reserved)
+	}
+	s.OnHand = onHand
+	return *s, nil
+}
+
+func (m *MemoryStore) Stock(ctx context.Context, sku string) (Stock, error) {
+	if err := ctx.Err(); err != nil {
+		return Stock{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.stock[sku]
+	if !ok {
+		return Stock{}, fmt.Errorf("%w: %s", ErrUnknownSKU, sku)
+	}
+	return *s, nil
+}
+
+// checkAvailableLocked fails unless every item can be taken from stock.
+func (m *MemoryStore) checkAvailableLocked(items []Item) error {
+	for _, it := range items {
+		s, ok := m.stock[it.SKU]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownSKU, it.SKU)
+		}
+		if s.Available() < it.Quantity {
+			return fmt.Errorf("%w: sku %s has %d available", ErrInsufficientStock, it.SKU, s.Available())
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) Reserve(ctx context.Context, r Reservation) (Reservation, error) {
+	if err := ctx.Err(); err != nil {
+		return Reservation{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.reservations[r.ID]; ok {
+		return copyReservation(existing), nil
+	}
+	r.Items = mergeItems(r.Items)
+	if err := m.checkAvailableLocked(r.Items); err != nil {
+		return Reservation{}, err
+	}
+	for _, it := range r.Items {
+		m.stock[it.SKU].Reserved += it.Quantity
+	}
+	r.Status = statusHeld
+	m.reservations[r.ID] = &r
+	return copyReservation(&r), nil
+}
+
+func (m *MemoryStore) Commit(ctx context.Context, id string) (Reservation, error) {
+	if err := ctx.Err(); err != nil {
+		return Reservation{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.reservations[id]
+	if !ok {
+		return Reservation{}, ErrNotFound
+	}
+	switch r.Status {
+	case statusHeld:
+		for _, it := range r.Items {
+			s := m.stock[it.SKU]
+			s.OnHand -= it.Quantity
+			s.Reserved -= it.Quantity
+		}
+	case statusExpired:
+		if err := m.checkAvailableLocked(r.Items); err != nil {
+			return Reservation{}, err
+		}
+		for _, it := range r.Items {
+			m.stock[it.SKU].OnHand -= it.Quantity
+		}
+	case statusCommitted:
+		return copyReservation(r), nil
+	default:
+		return Reservation{}, fmt.Errorf("%w: it is %s", ErrNotHeld, r.Status)
+	}
+	r.Status = statusCommitted
+	return copyReservation(r), nil
+}
+
+func (m *MemoryStore) Release(ctx context.Context, id string) (Reservation, error) {
+	if err := ctx.Err(); err != nil {
+		return Reservation{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.reservations[id]
+	if !ok {
+		return Reservation{}, ErrNotFound
+	}
+	switch r.Status {
+	case statusHeld:
+		m.releaseLocked(r, statusReleased)
+	case statusCommitted:
+		return Reservation{}, fmt.Errorf("%w: it is %s", ErrNotHeld, r.Status)
+	}
+	return copyReservation(r), nil
+}
+
+func (m *MemoryStore) Return(ctx context.Context, id string) (Reservation, error) {
+	if err := ctx.Err(); err != nil {
+		return Reservation{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.reservations[id]
+	if !ok {
+		r = &Reservation{ID: id, Status: statusReleased, ExpiresAt: time.Now().UTC().Truncate(time.Microsecond)}
+		m.reservations[id] = r
+	}
+	switch r.Status {
+	case statusHeld:
+		m.releaseLocked(r, statusReleased)
+	case statusCommitted:
+		for _, it := range r.Items {
+			m.stock[it.SKU].OnHand += it.Quantity
+		}
+		r.Status = statusReturned
+	}
+	return copyReservation(r), nil
+}
+
+func (m *MemoryStore) releaseLocked(r *Reservation, status string) {
+	for _, it := range r.Items {
+		m.stock[it.SKU].Reserved -= it.Quantity
+	}
+	r.Status = status
+}
+
+func (m *MemoryStore) ExpireHolds(ctx context.Context, now time.Time, limit int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []*Reservation
+	for _, r := range m.reservations {
+		if r.Status == statusHeld && !r.ExpiresAt.After(now) {
+			due = append(due, r)
+		}
+	}
+	slices.SortFunc(due, func(a, b *Reservation) int { return a.ExpiresAt.Compare(b.ExpiresAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for _, r := range due {
+		m.releaseLocked(r, statusExpired)
+	}
+	return len(due), nil
+}
+
+func copyReservation(r *Reservation) Reservation {
+	out := *r
+	out.Items = slices.Clone(r.Items)
+	return out
+}
+
+var _ Store = (*MemoryStore)(nil)
@@ -0,0 +1,25 @@
+package inventorysvc
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	inventoryReservationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "inventory_reservations_total",
+			Help: "Reserve calls by result: ok (held or replayed), insufficient or unknown_sku",
+		},
+		[]string{"result"},
+	)
+	inventoryHoldsExpiredTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "inventory_holds_expired_total",
+			Help: "Reservations released by the sweeper after their hold expired",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(inventoryReservationsTotal, inventoryHoldsExpiredTotal)
+}
@@ -0,0 +1,317 @@
+package inventorysvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore is the Store on Postgres. Stock rows change only through
+// conditional UPDATEs, which Postgres re-checks against the latest row
+// version after waiting for a lock, and a transaction locks its SKUs in
+// sorted order.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore keeps inventory in pool, which InitDB has migrated.
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+// InitDB connects to connStr and creates the inventory schema if needed.
+func InitDB(ctx context.Context, connStr string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, connStr)
+	if err != nil {
+		return nil, err
+	}
+	q := `CREATE TABLE IF NOT EXISTS stock (
+		sku TEXT PRIMARY KEY,
+		on_hand BIGINT NOT NULL CHECK (on_hand >= 0),
+		reserved BIGINT NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+		CHECK (reserved <= on_hand)
+	);
+	CREATE TABLE IF NOT EXISTS reservations (
+		id TEXT PRIMARY KEY,
+		status TEXT NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX IF NOT EXISTS reservations_held_expires_at ON reservations (expires_at) WHERE status = 'HELD';
+	CREATE TABLE IF NOT EXISTS reservation_items (
+		reservation_id TEXT NOT NULL REFERENCES reservations (id),
+		sku TEXT NOT NULL,
+		quantity BIGINT NOT NULL CHECK (quantity > 0),
+		PRIMARY KEY (reservation_id, sku)
+	);`
+	if _, err := pool.Exec(ctx, q); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// inTx runs fn in a transaction and commits if it succeeds.
+func (p *PostgresStore) inTx(ctx context.Context, fn func(pgx.Tx) error) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (p *PostgresStore) SetStock(ctx context.Context, sku string, onHand int64) (Stock, error) {
+	s := Stock{SKU: sku}
+	err := p.pool.QueryRow(ctx, `INSERT INTO stock (sku, on_hand) VALUES ($1, $2)
+		ON CONFLICT (sku) DO UPDATE SET on_hand = EXCLUDED.on_hand
+		RETURNING on_hand, reserved`, sku, onHand).Scan(&s.OnHand, &s.Reserved)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23514" { // check_violation
+		return Stock{}, fmt.Errorf("%w: sku %s has more than %d reserved", ErrInsufficientStock, sku, onHand)
+	}
+	return s, err
+}
+
+func (p *PostgresStore) Stock(ctx context.Context, sku string) (Stock, error) {
+	return stockRow(ctx, p.pool, sku)
+}
+
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func stockRow(ctx context.Context, q queryRower, sku string) (Stock, error) {
+	s := Stock{SKU: sku}
+	err := q.QueryRow(ctx, `SELECT on_hand, reserved FROM stock WHERE sku = $1`, sku).Scan(&s.OnHand, &s.Reserved)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Stock{}, fmt.Errorf("%w: %s", ErrUnknownSKU, sku)
+	}
+	return s, err
+}
+
+// take moves items into or out of stock with conditional updates, in the
+// items' (sorted) order. With check set, an item that is not available
+// fails the whole call with ErrUnknownSKU or ErrInsufficientStock.
+func take(ctx context.Context, tx pgx.Tx, items []Item, update string, check bool) error {
+	for _, it := range items {
+		tag, err := tx.Exec(ctx, update, it.SKU, it.Quantity)
+		if err != nil {
+			return err
+		}
+		if check && tag.RowsAffected() == 0 {
+			s, err := stockRow(ctx, tx, it.SKU)
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: sku %s has %d available", ErrInsufficientStock, it.SKU, s.Available())
+		}
+	}
+	return nil
+}
+
+const (
+	reserveStock    = `UPDATE stock SET reserved = reserved + $2 WHERE sku = $1 AND on_hand - reserved >= $2`
+	releaseStock    = `UPDATE stock SET reserved = reserved - $2 WHERE sku = $1`
+	commitHeldStock = `UPDATE stock SET on_hand = on_hand - $2, reserved = reserved - $2 WHERE sku = $1`
+	commitFreeStock = `UPDATE stock SET on_hand = on_hand - $2 WHERE sku = $1 AND on_hand - reserved >= $2`
+	returnStock     = `UPDATE stock SET on_hand = on_hand + $2 WHERE sku = $1`
+)
+
+func (p *PostgresStore) Reserve(ctx context.Context, r Reservation) (Reservation, error) {
+	r.Items = mergeItems(r.Items)
+	r.Status = statusHeld
+	out := r
+	err := p.inTx(ctx, func(tx pgx.Tx) error {
+		// A concurrent Reserve with the same ID waits here for this one.
+		tag, err := tx.Exec(ctx, `INSERT INTO reservations (id, status, expires_at) VALUES ($1, $2, $3)
+			ON CONFLICT (id) DO NOTHING`, r.ID, r.Status, r.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			out, err = loadReservation(ctx, tx, r.ID, false)
+			return err
+		}
+		if err := take(ctx, tx, r.Items, reserveStock, true); err != nil {
+			return err
+		}
+		for _, it := range r.Items {
+			if _, err := tx.Exec(ctx, `INSERT INTO reservation_items (reservation_id, sku, quantity) VALUES ($1, $2, $3)`,
+				r.ID, it.SKU, it.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Reservation{}, err
+	}
+	return out, nil
+}
+
+// loadReservation reads a reservation and its items, locking the
+// reservation row with forUpdate.
+func loadReservation(ctx context.Context, tx pgx.Tx, id string, forUpdate bool) (Reservation, error) {
+	q := `SELECT status, expires_at FROM reservations WHERE id = $1`
+	if forUpdate {
+		q += ` FOR UPDATE`
+	}
+	r := Reservation{ID: id}
+	err := tx.QueryRow(ctx, q, id).Scan(&r.Status, &r.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Reservation{}, ErrNotFound
+	}
+	if err != nil {
+		return Reservation{}, err
+	}
+	rows, err := tx.Query(ctx, `SELECT sku, quantity FROM reservation_items WHERE reservation_id = $1 ORDER BY sku`, id)
+	if err != nil {
+		return Reservation{}, err
+	}
+	r.Items, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Item, error) {
+		var it Item
+		return it, row.Scan(&it.SKU, &it.Quantity)
+	})
+	return r, err
+}
+
+func setStatus(ctx context.Context, tx pgx.Tx, id, status string) error {
+	_, err := tx.Exec(ctx, `UPDATE reservations SET status = $2 WHERE id = $1`, id, status)
+	return err
+}
+
+func (p *PostgresStore) Commit(ctx context.Context, id string) (Reservation, error) {
+	var r Reservation
+	err := p.inTx(ctx, func(tx pgx.Tx) (err error) {
+		if r, err = loadReservation(ctx, tx, id, true); err != nil {
+			return err
+		}
+		switch r.Status {
+		case statusHeld:
+			err = take(ctx, tx, r.Items, commitHeldStock, false)
+		case statusExpired:
+			err = take(ctx, tx, r.Items, commitFreeStock, true)
+		case statusCommitted:
+			return nil
+		default:
+			return fmt.Errorf("%w: it is %s", ErrNotHeld, r.Status)
+		}
+		if err != nil {
+			return err
+		}
+		r.Status = statusCommitted
+		return setStatus(ctx, tx, id, r.Status)
+	})
+	if err != nil {
+		return Reservation{}, err
+	}
+	return r, nil
+}
+
+func (p *PostgresStore) Release(ctx context.Context, id string) (Reservation, error) {
+	var r Reservation
+	err := p.inTx(ctx, func(tx pgx.Tx) (err error) {
+		if r, err = loadReservation(ctx, tx, id, true); err != nil {
+			return err
+		}
+		switch r.Status {
+		case statusHeld:
+			if err := take(ctx, tx, r.Items, releaseStock, false); err != nil {
+				return err
+			}
+			r.Status = statusReleased
+			return setStatus(ctx, tx, id, r.Status)
+		case statusCommitted:
+			return fmt.Errorf("%w: it is %s", ErrNotHeld, r.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		return Reservation{}, err
+	}
+	return r, nil
+}
+
+func (p *PostgresStore) Return(ctx context.Context, id string) (Reservation, error) {
+	var r Reservation
+	err := p.inTx(ctx, func(tx pgx.Tx) (err error) {
+		// The tombstone of an unknown id makes a concurrent Reserve wait and
+		// then find it.
+		if _, err := tx.Exec(ctx, `INSERT INTO reservations (id, status, expires_at) VALUES ($1, $2, now())
+			ON CONFLICT (id) DO NOTHING`, id, statusReleased); err != nil {
+			return err
+		}
+		if r, err = loadReservation(ctx, tx, id, true); err != nil {
+			return err
+		}
+		switch r.Status {
+		case statusHeld:
+			err = take(ctx, tx, r.Items, releaseStock, false)
+			r.Status = statusReleased
+		case statusCommitted:
+			err = take(ctx, tx, r.Items, returnStock, false)
+			r.Status = statusReturned
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return setStatus(ctx, tx, id, r.Status)
+	})
+	if err != nil {
+		return Reservation{}, err
+	}
+	return r, nil
+}
+
+// ExpireHolds skips holds another transaction has locked, so several
+// replicas can sweep at once. The released quantities are summed per SKU
+// first to keep the lock order sorted.
+func (p *PostgresStore) ExpireHolds(ctx context.Context, now time.Time, limit int) (int, error) {
+	var n int
+	err := p.inTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `WITH due AS (
+				SELECT id FROM reservations WHERE status = $1 AND expires_at <= $2
+				ORDER BY expires_at LIMIT $3 FOR UPDATE SKIP LOCKED
+			)
+			UPDATE reservations r SET status = $4 FROM due WHERE r.id = due.id RETURNING r.id`,
+			statusHeld, now, limit, statusExpired)
+		if err != nil {
+			return err
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		n = len(ids)
+		rows, err = tx.Query(ctx, `SELECT sku, sum(quantity)::bigint FROM reservation_items
+			WHERE reservation_id = ANY($1) GROUP BY sku ORDER BY sku`, ids)
+		if err != nil {
+			return err
+		}
+		items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Item, error) {
+			var it Item
+			return it, row.Scan(&it.SKU, &it.Quantity)
+		})
+		if err != nil {
+			return err
+		}
+		return take(ctx, tx, items, releaseStock, false)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+var _ Store = (*PostgresStore)(nil)
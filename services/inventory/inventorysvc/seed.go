@@ -0,0 +1,69 @@
+package inventorysvc
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed stock.yaml
+var defaultStock []byte
+
+// DefaultStock returns the starting stock built into the binary.
+func DefaultStock() map[string]int64 {
+	stock, err := parseStock(defaultStock)
+	if err != nil {
+		panic("inventorysvc: built-in stock: " + err.Error())
+	}
+	return stock
+}
+
+// LoadStock reads a seed file with the layout of stock.yaml.
+func LoadStock(path string) (map[string]int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	stock, err := parseStock(data)
+	if err != nil {
+		return nil, fmt.Errorf("stock %s: %w", path, err)
+	}
+	return stock, nil
+}
+
+func parseStock(data []byte) (map[string]int64, error) {
+	var f struct {
+		Stock map[string]int64 `yaml:"stock"`
+	}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	for sku, n := range f.Stock {
+		if n < 0 {
+			return nil, fmt.Errorf("sku %s: negative stock %d", sku, n)
+		}
+	}
+	return f.Stock, nil
+}
+
+// Seed sets the stock of the SKUs store does not track yet, leaving the
+// others alone so restarts keep the current levels.
+func Seed(ctx context.Context, store Store, stock map[string]int64) error {
+	for sku, n := range stock {
+		_, err := store.Stock(ctx, sku)
+		if !errors.Is(err, ErrUnknownSKU) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := store.SetStock(ctx, sku, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package inventorysvc implements the Inventory API: stock per SKU and
+// time-limited reservations against it.
+package inventorysvc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/reliability-lab/gen/inventory"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultHoldTTL       = 15 * time.Minute
+	maxHoldTTL           = 24 * time.Hour
+	defaultSweepInterval = 30 * time.Second
+	// sweepBatch bounds one ExpireHolds transaction.
+	sweepBatch         = 500
+	maxItemsPerReserve = 100
+)
+
+// Server implements inventory.InventoryServer on a Store and expires
+// overdue holds in the background until Close.
+type Server struct {
+	inventory.UnimplementedInventoryServer
+	store         Store
+	holdTTL       time.Duration
+	sweepInterval time.Duration
+
+	stopSweeper context.CancelFunc
+	sweeperDone chan struct{}
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithHoldTTL sets how long a reservation holds stock when the request does
+// not say; the default is 15 minutes.
+func WithHoldTTL(d time.Duration) Option {
+	return func(s *Server) { s.holdTTL = d }
+}
+
+// WithSweepInterval sets how often expired holds are released; the default
+// is 30 seconds.
+func WithSweepInterval(d time.Duration) Option {
+	return func(s *Server) { s.sweepInterval = d }
+}
+
+// NewServer serves the inventory in store.
+func NewServer(store Store, opts ...Option) *Server {
+	s := &Server{store: store, holdTTL: defaultHoldTTL, sweepInterval: defaultSweepInterval, sweeperDone: make(chan struct{})}
+	for _, opt := range opts {
+		opt(s)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	s.stopSweeper = stop
+	go s.sweep(ctx)
+	return s
+}
+
+// Close stops the sweeper and waits for it to finish.
+func (s *Server) Close() {
+	s.stopSweeper()
+	<-s.sweeperDone
+}
+
+func (s *Server) sweep(ctx context.Context) {
+	defer close(s.sweeperDone)
+	ticker := time.NewTicker(s.sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := s.ExpireHolds(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("expiring inventory holds failed")
+		}
+	}
+}
+
+// ExpireHolds releases every hold that expired by now, in batches, and
+// returns how many there were. The sweeper calls it periodically.
+func (s *Server) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	total := 0
+	for {
+		n, err := s.store.ExpireHolds(ctx, now, sweepBatch)
+		total += n
+		inventoryHoldsExpiredTotal.Add(float64(n))
+		if err != nil || n < sweepBatch {
+			return total, err
+		}
+	}
+}
+
+func (s *Server) Reserve(ctx context.Context, req *inventory.ReserveRequest) (*inventory.ReserveResponse, error) {
+	ctx, span := otel.Tracer("inventory").Start(ctx, "Reserve")
+	defer span.End()
+	span.SetAttributes(attribute.String("reservation_id", req.ReservationId))
+
+	if req.ReservationId == "" || len(req.Items) == 0 || len(req.Items) > maxItemsPerReserve {
+		return nil, status.Errorf(grpccodes.InvalidArgument, "reservation_id and between 1 and %d items required", maxItemsPerReserve)
+	}
+	items := make([]Item, len(req.Items))
+	for i, it := range req.Items {
+		if it.Sku == "" || it.Quantity <= 0 {
+			return nil, status.Errorf(grpccodes.InvalidArgument, "item %d: sku and a positive quantity required", i)
+		}
+		items[i] = Item{SKU: it.Sku, Quantity: it.Quantity}
+	}
+	ttl := s.holdTTL
+	if req.TtlSeconds != 0 {
+		ttl = time.Duration(req.TtlSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > maxHoldTTL {
+		return nil, status.Errorf(grpccodes.InvalidArgument, "ttl_seconds must be between 1 and %d", int64(maxHoldTTL/time.Second))
+	}
+
+	r, err := s.store.Reserve(ctx, Reservation{
+		ID:        req.ReservationId,
+		Items:     items,
+		ExpiresAt: time.Now().Add(ttl).UTC().Truncate(time.Microsecond),
+	})
+	switch {
+	case errors.Is(err, ErrInsufficientStock):
+		inventoryReservationsTotal.WithLabelValues("insufficient").Inc()
+	case errors.Is(err, ErrUnknownSKU):
+		inventoryReservationsTotal.WithLabelValues("unknown_sku").Inc()
+	case err == nil:
+		inventoryReservationsTotal.WithLabelValues("ok").Inc()
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, storeStatus(err, "failed to reserve")
+	}
+	span.SetAttributes(attribute.String("status", r.Status))
+	return &inventory.ReserveResponse{ReservationId: r.ID, Status: r.Status, ExpiresAt: r.ExpiresAt.UTC().Format(time.RFC3339)}, nil
+}
+
+func (s *Server) Commit(ctx context.Context, req *inventory.CommitRequest) (*inventory.CommitResponse, error) {
+	ctx, span := otel.Tracer("inventory").Start(ctx, "Commit")
+	defer span.End()
+	span.SetAttributes(attribute.String("reservation_id", req.ReservationId))
+
+	if req.ReservationId == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "reservation_id required")
+	}
+	r, err := s.store.Commit(ctx, req.ReservationId)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, storeStatus(err, "failed to commit")
+	}
+	return &inventory.CommitResponse{ReservationId: r.ID, Status: r.Status}, nil
+}
+
+func (s *Server) Release(ctx context.Context, req *inventory.ReleaseRequest) (*inventory.ReleaseResponse, error) {
+	ctx, span := otel.Tracer("inventory").Start(ctx, "Release")
+	defer span.End()
+	span.SetAttributes(attribute.String("reservation_id", req.ReservationId))
+
+	if req.ReservationId == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "reservation_id required")
+	}
+	r, err := s.store.Release(ctx, req.ReservationId)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, storeStatus(err, "failed to release")
+	}
+	return &inventory.ReleaseResponse{ReservationId: r.ID, Status: r.Status}, nil
+}
+
+func (s *Server) Return(ctx context.Context, req *inventory.ReturnRequest) (*inventory.ReturnResponse, error) {
+	ctx, span := otel.Tracer("inventory").Start(ctx, "Return")
+	defer span.End()
+	span.SetAttributes(attribute.String("reservation_id", req.ReservationId))
+
+	if req.ReservationId == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "reservation_id required")
+	}
+	r, err := s.store.Return(ctx, req.ReservationId)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, storeStatus(err, "failed to return")
+	}
+	span.SetAttributes(attribute.String("status", r.Status))
+	return &inventory.ReturnResponse{ReservationId: r.ID, Status: r.Status}, nil
+}
+
+func (s *Server) GetStock(ctx context.Context, req *inventory.GetStockRequest) (*inventory.GetStockResponse, error) {
+	if req.Sku == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "sku required")
+	}
+	st, err := s.store.Stock(ctx, req.Sku)
+	if err != nil {
+		return nil, storeStatus(err, "failed to read stock")
+	}
+	return &inventory.GetStockResponse{Sku: st.SKU, OnHand: st.OnHand, Reserved: st.Reserved, Available: st.Available()}, nil
+}
+
+func (s *Server) SetStock(ctx context.Context, req *inventory.SetStockRequest) (*inventory.SetStockResponse, error) {
+	if req.Sku == "" || req.OnHand < 0 {
+		return nil, status.Error(grpccodes.InvalidArgument, "sku and a non-negative on_hand required")
+	}
+	st, err := s.store.SetStock(ctx, req.Sku, req.OnHand)
+	if err != nil {
+		return nil, storeStatus(err, "failed to set stock")
+	}
+	log.Info().Ctx(ctx).Str("sku", st.SKU).Int64("on_hand", st.OnHand).Msg("stock set")
+	return &inventory.SetStockResponse{Sku: st.SKU, OnHand: st.OnHand, Reserved: st.Reserved, Available: st.Available()}, nil
+}
+
+// storeStatus maps Store errors to gRPC statuses; anything unexpected is
+// Internal with msg.
+func storeStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrUnknownSKU):
+		return status.Error(grpccodes.NotFound, err.Error())
+	case errors.Is(err, ErrInsufficientStock), errors.Is(err, ErrNotHeld):
+		return status.Error(grpccodes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(grpccodes.DeadlineExceeded, msg+": deadline exceeded")
+	case errors.Is(err, context.Canceled):
+		return status.Error(grpccodes.Canceled, msg+": canceled")
+	}
+	log.Error().Err(err).Msg(msg)
+	return status.Error(grpccodes.Internal, msg)
+}
+
+var _ inventory.InventoryServer = (*Server)(nil)
@@ -0,0 +1,88 @@
+package inventorysvc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/reliability-lab/gen/inventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+	s := NewServer(NewMemoryStore(), opts...)
+	t.Cleanup(s.Close)
+	if _, err := s.SetStock(context.Background(), &inventory.SetStockRequest{Sku: "MUG", OnHand: 2}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func reserveReq(id string, qty int64) *inventory.ReserveRequest {
+	return &inventory.ReserveRequest{ReservationId: id, Items: []*inventory.ReservationItem{{Sku: "MUG", Quantity: qty}}}
+}
+
+func TestReserve_Codes(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		req  *inventory.ReserveRequest
+		want codes.Code
+	}{
+		{"ok", reserveReq("r1", 2), codes.OK},
+		{"replay", reserveReq("r1", 2), codes.OK},
+		{"short", reserveReq("r2", 1), codes.FailedPrecondition},
+		{"unknown sku", &inventory.ReserveRequest{ReservationId: "r3", Items: []*inventory.ReservationItem{{Sku: "POSTER", Quantity: 1}}}, codes.NotFound},
+		{"no id", reserveReq("", 1), codes.InvalidArgument},
+		{"zero quantity", reserveReq("r4", 0), codes.InvalidArgument},
+		{"ttl too long", &inventory.ReserveRequest{ReservationId: "r5", Items: reserveReq("", 1).Items, TtlSeconds: 90000}, codes.InvalidArgument},
+	} {
+		_, err := s.Reserve(ctx, tc.req)
+		if got := status.Code(err); got != tc.want {
+			t.Errorf("%s: code %v (%v), want %v", tc.name, got, err, tc.want)
+		}
+	}
+
+	if _, err := s.Release(ctx, &inventory.ReleaseRequest{ReservationId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Release of unknown reservation: %v, want NotFound", err)
+	}
+	if _, err := s.Commit(ctx, &inventory.CommitRequest{ReservationId: "r1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Release(ctx, &inventory.ReleaseRequest{ReservationId: "r1"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Release of committed reservation: %v, want FailedPrecondition", err)
+	}
+	got, err := s.GetStock(ctx, &inventory.GetStockRequest{Sku: "MUG"})
+	if err != nil || got.OnHand != 0 || got.Available != 0 {
+		t.Errorf("GetStock = %v, %v; want nothing left", got, err)
+	}
+}
+
+func TestSweeper_ExpiresHolds(t *testing.T) {
+	s := newTestServer(t, WithSweepInterval(10*time.Millisecond))
+	ctx := context.Background()
+	if _, err := s.Reserve(ctx, &inventory.ReserveRequest{ReservationId: "r1", Items: reserveReq("", 2).Items, TtlSeconds: 1}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := s.GetStock(ctx, &inventory.GetStockRequest{Sku: "MUG"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Available == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("hold not expired: %v", got)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	r, err := s.Reserve(ctx, reserveReq("r1", 2))
+	if err != nil || r.Status != statusExpired {
+		t.Errorf("Reserve replay after expiry = %v, %v; want EXPIRED", r, err)
+	}
+}
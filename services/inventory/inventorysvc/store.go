@@ -0,0 +1,99 @@
+package inventorysvc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Reservation statuses. A reservation is HELD until it is committed,
+// released or, once ExpiresAt passes, expired by the sweeper. A committed
+// reservation is RETURNED when its order is cancelled.
+const (
+	statusHeld      = "HELD"
+	statusCommitted = "COMMITTED"
+	statusReleased  = "RELEASED"
+	statusExpired   = "EXPIRED"
+	statusReturned  = "RETURNED"
+)
+
+var (
+	// ErrNotFound is returned for unknown reservation IDs.
+	ErrNotFound = errors.New("reservation not found")
+	// ErrUnknownSKU is returned for SKUs the store does not track.
+	ErrUnknownSKU = errors.New("unknown sku")
+	// ErrInsufficientStock is returned when a SKU has too little available.
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrNotHeld is returned when a reservation's status forbids the change,
+	// e.g. releasing a committed one.
+	ErrNotHeld = errors.New("reservation is not held")
+)
+
+// Stock is the level of one SKU. Reserved never exceeds OnHand.
+type Stock struct {
+	SKU      string
+	OnHand   int64
+	Reserved int64
+}
+
+// Available is what can still be reserved.
+func (s Stock) Available() int64 { return s.OnHand - s.Reserved }
+
+// Item is a quantity of one SKU.
+type Item struct {
+	SKU      string
+	Quantity int64
+}
+
+// Reservation is a hold on stock for one order.
+type Reservation struct {
+	ID        string
+	Items     []Item
+	Status    string
+	ExpiresAt time.Time
+}
+
+// Store keeps stock levels and reservations. However callers interleave,
+// reserved stock must never exceed the stock on hand.
+type Store interface {
+	// SetStock sets sku's quantity on hand, adding the SKU if needed. It
+	// fails with ErrInsufficientStock below the reserved quantity.
+	SetStock(ctx context.Context, sku string, onHand int64) (Stock, error)
+	// Stock returns ErrUnknownSKU for an untracked SKU.
+	Stock(ctx context.Context, sku string) (Stock, error)
+	// Reserve holds all of r's items until r.ExpiresAt, or none of them. If a
+	// reservation with r's ID exists it is returned unchanged instead.
+	Reserve(ctx context.Context, r Reservation) (Reservation, error)
+	// Commit takes a held reservation's items out of stock. It is a no-op on
+	// a committed reservation; an expired one is committed only if its items
+	// are still available.
+	Commit(ctx context.Context, id string) (Reservation, error)
+	// Release returns a held reservation's items to stock. It is a no-op on a
+	// released or expired reservation.
+	Release(ctx context.Context, id string) (Reservation, error)
+	// Return gives back the stock of a cancelled order: a held reservation
+	// is released and a committed one put back on hand as RETURNED. It is a
+	// no-op on a reservation that holds nothing. An unknown id is recorded as
+	// released, so a Reserve that arrives late holds nothing either.
+	Return(ctx context.Context, id string) (Reservation, error)
+	// ExpireHolds releases up to limit holds whose ExpiresAt is not after now,
+	// marks them EXPIRED and returns how many there were.
+	ExpireHolds(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+// mergeItems sums the quantities per SKU and sorts by SKU. Stores take row
+// locks in that order, so concurrent reservations cannot deadlock.
+func mergeItems(items []Item) []Item {
+	out := make([]Item, 0, len(items))
+	for _, it := range items {
+		if i := slices.IndexFunc(out, func(o Item) bool { return o.SKU == it.SKU }); i >= 0 {
+			out[i].Quantity += it.Quantity
+			continue
+		}
+		out = append(out, it)
+	}
+	slices.SortFunc(out, func(a, b Item) int { return strings.Compare(a.SKU, b.SKU) })
+	return out
+}
@@ -0,0 +1,277 @@
+package inventorysvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testStore is the conformance suite every Store must pass. newStore returns
+// an empty store.
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("SetStock", func(t *testing.T) { testSetStock(t, newStore(t)) })
+	t.Run("ReserveAllOrNothing", func(t *testing.T) { testReserveAllOrNothing(t, newStore(t)) })
+	t.Run("ReserveIsIdempotent", func(t *testing.T) { testReserveIsIdempotent(t, newStore(t)) })
+	t.Run("CommitAndRelease", func(t *testing.T) { testCommitAndRelease(t, newStore(t)) })
+	t.Run("Return", func(t *testing.T) { testReturn(t, newStore(t)) })
+	t.Run("ExpireHolds", func(t *testing.T) { testExpireHolds(t, newStore(t)) })
+	t.Run("NoOversell", func(t *testing.T) { testNoOversell(t, newStore(t)) })
+}
+
+func mustSetStock(t *testing.T, store Store, sku string, onHand int64) {
+	t.Helper()
+	if _, err := store.SetStock(context.Background(), sku, onHand); err != nil {
+		t.Fatalf("SetStock(%s, %d): %v", sku, onHand, err)
+	}
+}
+
+func wantStock(t *testing.T, store Store, sku string, onHand, reserved int64) {
+	t.Helper()
+	s, err := store.Stock(context.Background(), sku)
+	if err != nil || s.OnHand != onHand || s.Reserved != reserved {
+		t.Errorf("Stock(%s) = %+v, %v; want on_hand %d, reserved %d", sku, s, err, onHand, reserved)
+	}
+}
+
+func hold(id string, ttl time.Duration, items ...Item) Reservation {
+	return Reservation{ID: id, Items: items, ExpiresAt: time.Now().Add(ttl).UTC().Truncate(time.Microsecond)}
+}
+
+func testSetStock(t *testing.T, store Store) {
+	ctx := context.Background()
+	if _, err := store.Stock(ctx, "MUG"); !errors.Is(err, ErrUnknownSKU) {
+		t.Errorf("Stock of untracked SKU: %v, want ErrUnknownSKU", err)
+	}
+	mustSetStock(t, store, "MUG", 5)
+	if _, err := store.Reserve(ctx, hold("r1", time.Minute, Item{"MUG", 3})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SetStock(ctx, "MUG", 2); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("SetStock below reserved: %v, want ErrInsufficientStock", err)
+	}
+	mustSetStock(t, store, "MUG", 3)
+	wantStock(t, store, "MUG", 3, 3)
+}
+
+func testReserveAllOrNothing(t *testing.T, store Store) {
+	ctx := context.Background()
+	mustSetStock(t, store, "MUG", 5)
+	mustSetStock(t, store, "TSHIRT", 1)
+
+	if _, err := store.Reserve(ctx, hold("r1", time.Minute, Item{"MUG", 2}, Item{"TSHIRT", 2})); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Reserve beyond stock: %v, want ErrInsufficientStock", err)
+	}
+	if _, err := store.Reserve(ctx, hold("r2", time.Minute, Item{"MUG", 1}, Item{"POSTER", 1})); !errors.Is(err, ErrUnknownSKU) {
+		t.Errorf("Reserve of untracked SKU: %v, want ErrUnknownSKU", err)
+	}
+	wantStock(t, store, "MUG", 5, 0)
+	wantStock(t, store, "TSHIRT", 1, 0)
+
+	r, err := store.Reserve(ctx, hold("r3", time.Minute, Item{"TSHIRT", 1}, Item{"MUG", 2}, Item{"MUG", 3}))
+	if err != nil || r.Status != statusHeld {
+		t.Fatalf("Reserve = %+v, %v; want HELD", r, err)
+	}
+	if want := []Item{{"MUG", 5}, {"TSHIRT", 1}}; fmt.Sprint(r.Items) != fmt.Sprint(want) {
+		t.Errorf("items = %v, want merged and sorted %v", r.Items, want)
+	}
+	wantStock(t, store, "MUG", 5, 5)
+	wantStock(t, store, "TSHIRT", 1, 1)
+}
+
+func testReserveIsIdempotent(t *testing.T, store Store) {
+	ctx := context.Background()
+	mustSetStock(t, store, "MUG", 5)
+	first, err := store.Reserve(ctx, hold("r1", time.Minute, Item{"MUG", 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	retry, err := store.Reserve(ctx, hold("r1", time.Hour, Item{"MUG", 4}))
+	if err != nil || retry.Status != statusHeld || !retry.ExpiresAt.Equal(first.ExpiresAt) || retry.Items[0] != (Item{"MUG", 2}) {
+		t.Errorf("replayed Reserve = %+v, %v; want %+v", retry, err, first)
+	}
+	wantStock(t, store, "MUG", 5, 2)
+}
+
+func testCommitAndRelease(t *testing.T, store Store) {
+	ctx := context.Background()
+	mustSetStock(t, store, "MUG", 5)
+	for _, id := range []string{"paid", "declined"} {
+		if _, err := store.Reserve(ctx, hold(id, time.Minute, Item{"MUG", 2})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for range 2 {
+		if r, err := store.Commit(ctx, "paid"); err != nil || r.Status != statusCommitted {
+			t.Errorf("Commit = %+v, %v; want COMMITTED", r, err)
+		}
+		if r, err := store.Release(ctx, "declined"); err != nil || r.Status != statusReleased {
+			t.Errorf("Release = %+v, %v; want RELEASED", r, err)
+		}
+	}
+	wantStock(t, store, "MUG", 3, 0)
+
+	if _, err := store.Release(ctx, "paid"); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Release of committed: %v, want ErrNotHeld", err)
+	}
+	if _, err := store.Commit(ctx, "declined"); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Commit of released: %v, want ErrNotHeld", err)
+	}
+	for _, op := range []func(context.Context, string) (Reservation, error){store.Commit, store.Release} {
+		if _, err := op(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("unknown reservation: %v, want ErrNotFound", err)
+		}
+	}
+	wantStock(t, store, "MUG", 3, 0)
+}
+
+func testReturn(t *testing.T, store Store) {
+	ctx := context.Background()
+	mustSetStock(t, store, "MUG", 5)
+	for _, id := range []string{"paid", "created"} {
+		if _, err := store.Reserve(ctx, hold(id, time.Minute, Item{"MUG", 2})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.Commit(ctx, "paid"); err != nil {
+		t.Fatal(err)
+	}
+	wantStock(t, store, "MUG", 3, 2)
+
+	for range 2 {
+		if r, err := store.Return(ctx, "paid"); err != nil || r.Status != statusReturned {
+			t.Errorf("Return of committed = %+v, %v; want RETURNED", r, err)
+		}
+		if r, err := store.Return(ctx, "created"); err != nil || r.Status != statusReleased {
+			t.Errorf("Return of held = %+v, %v; want RELEASED", r, err)
+		}
+	}
+	wantStock(t, store, "MUG", 5, 0)
+	if _, err := store.Commit(ctx, "paid"); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Commit of returned: %v, want ErrNotHeld", err)
+	}
+
+	// A cancellation that beats the checkout's Reserve leaves nothing held.
+	if r, err := store.Return(ctx, "late"); err != nil || r.Status != statusReleased {
+		t.Errorf("Return of unknown = %+v, %v; want RELEASED", r, err)
+	}
+	if r, err := store.Reserve(ctx, hold("late", time.Minute, Item{"MUG", 1})); err != nil || r.Status != statusReleased {
+		t.Errorf("Reserve after Return = %+v, %v; want the RELEASED tombstone", r, err)
+	}
+	wantStock(t, store, "MUG", 5, 0)
+}
+
+func testExpireHolds(t *testing.T, store Store) {
+	ctx := context.Background()
+	mustSetStock(t, store, "MUG", 5)
+	for _, r := range []Reservation{
+		hold("old", -2*time.Minute, Item{"MUG", 1}),
+		hold("due", -time.Minute, Item{"MUG", 1}),
+		hold("live", time.Hour, Item{"MUG", 1}),
+	} {
+		if _, err := store.Reserve(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := store.ExpireHolds(ctx, time.Now(), 1); err != nil || n != 1 {
+		t.Errorf("ExpireHolds(limit 1) = %d, %v; want 1", n, err)
+	}
+	if n, err := store.ExpireHolds(ctx, time.Now(), 10); err != nil || n != 1 {
+		t.Errorf("ExpireHolds = %d, %v; want the remaining 1", n, err)
+	}
+	if n, err := store.ExpireHolds(ctx, time.Now(), 10); err != nil || n != 0 {
+		t.Errorf("ExpireHolds with nothing due = %d, %v", n, err)
+	}
+	wantStock(t, store, "MUG", 5, 1)
+
+	if r, err := store.Release(ctx, "old"); err != nil || r.Status != statusExpired {
+		t.Errorf("Release of expired = %+v, %v; want a no-op", r, err)
+	}
+	// A late payment still commits an expired hold while stock lasts.
+	if r, err := store.Commit(ctx, "due"); err != nil || r.Status != statusCommitted {
+		t.Errorf("Commit of expired = %+v, %v; want COMMITTED", r, err)
+	}
+	wantStock(t, store, "MUG", 4, 1)
+	mustSetStock(t, store, "MUG", 1)
+	if _, err := store.Commit(ctx, "old"); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Commit of expired without stock: %v, want ErrInsufficientStock", err)
+	}
+	wantStock(t, store, "MUG", 1, 1)
+}
+
+// testNoOversell hammers one SKU from many goroutines, each reserving,
+// then committing or releasing, while stock lasts.
+func testNoOversell(t *testing.T, store Store) {
+	const stock, buyers = 20, 100
+	ctx := context.Background()
+	mustSetStock(t, store, "MUG", stock)
+
+	var reserved, committed, rejected atomic.Int64
+	var wg sync.WaitGroup
+	for i := range buyers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("r%d", i)
+			if _, err := store.Reserve(ctx, hold(id, time.Minute, Item{"MUG", 1})); err != nil {
+				if !errors.Is(err, ErrInsufficientStock) {
+					t.Errorf("Reserve: %v", err)
+				}
+				rejected.Add(1)
+				return
+			}
+			reserved.Add(1)
+			// Every third buyer's payment fails and frees its unit.
+			if i%3 == 0 {
+				if _, err := store.Release(ctx, id); err != nil {
+					t.Errorf("Release: %v", err)
+				}
+				return
+			}
+			if _, err := store.Commit(ctx, id); err != nil {
+				t.Errorf("Commit: %v", err)
+				return
+			}
+			committed.Add(1)
+		}()
+	}
+	wg.Wait()
+
+	s, err := store.Stock(ctx, "MUG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Reserved != 0 || s.OnHand != stock-committed.Load() || s.OnHand < 0 {
+		t.Errorf("stock = %+v after %d commits", s, committed.Load())
+	}
+	if reserved.Load()+rejected.Load() != buyers || committed.Load() > stock {
+		t.Errorf("%d reserved, %d rejected, %d committed of %d", reserved.Load(), rejected.Load(), committed.Load(), stock)
+	}
+	if s.OnHand == 0 && rejected.Load() == 0 {
+		t.Errorf("stock ran out but no Reserve was rejected")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(*testing.T) Store { return NewMemoryStore() })
+}
+
+func TestSeed_KeepsTrackedSKUs(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	mustSetStock(t, store, "MUG-350", 3)
+	if err := Seed(ctx, store, DefaultStock()); err != nil {
+		t.Fatal(err)
+	}
+	wantStock(t, store, "MUG-350", 3, 0)
+	wantStock(t, store, "TSHIRT-M", 100, 0)
+
+	if _, err := parseStock([]byte("stock:\n  MUG-350: -1\n")); err == nil {
+		t.Error("negative stock parsed without error")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/reliability-lab/gen/inventory"
+	"github.com/reliability-lab/pkg/platform"
+	"github.com/reliability-lab/pkg/platform/config"
+	"github.com/reliability-lab/services/inventory/inventorysvc"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	var cfg serviceConfig
+	config.MustLoad(&cfg, "inventory")
+
+	ctx := context.Background()
+	svc := platform.New(ctx, "inventory", platform.WithConfig(cfg.Config),
+		platform.WithGRPC(fmt.Sprintf(":%d", cfg.GRPCPort)), platform.WithAdmin(fmt.Sprintf(":%d", cfg.HTTPPort)))
+
+	db, err := inventorysvc.InitDB(ctx, cfg.DBURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("initDB failed")
+	}
+	svc.OnClose("postgres", func(context.Context) error {
+		db.Close()
+		return nil
+	})
+	svc.AddReadiness("postgres", db.Ping)
+
+	store := inventorysvc.NewPostgresStore(db)
+	stock := inventorysvc.DefaultStock()
+	if cfg.SeedFile != "" {
+		if stock, err = inventorysvc.LoadStock(cfg.SeedFile); err != nil {
+			log.Fatal().Err(err).Msg("load stock failed")
+		}
+	}
+	if err := inventorysvc.Seed(ctx, store, stock); err != nil {
+		log.Fatal().Err(err).Msg("seed stock failed")
+	}
+
+	srv := inventorysvc.NewServer(store, inventorysvc.WithHoldTTL(cfg.HoldTTL), inventorysvc.WithSweepInterval(cfg.SweepInterval))
+	svc.OnClose("hold sweeper", func(context.Context) error {
+		srv.Close()
+		return nil
+	})
+	inventory.RegisterInventoryServer(svc.GRPC, srv)
+
+	if err := svc.Run(ctx); err != nil {
+		log.Error().Err(err).Msg("inventory service stopped with error")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/reliability-lab/pkg/platform"
+)
+
+// serviceConfig is the notifications configuration; see pkg/platform/config
+// for the tag syntax and precedence.
+type serviceConfig struct {
+	platform.Config `section:"Platform (all services)"`
+
+	GRPCPort int `conf:"grpc_port" env:"NOTIFICATIONS_GRPC_PORT" default:"50053" desc:"gRPC listen port."`
+	HTTPPort int `conf:"http_port" env:"NOTIFICATIONS_HTTP_PORT" default:"8083" desc:"Admin port for /healthz, /readyz and /metrics."`
+}
+
+func (c *serviceConfig) Validate() error {
+	return errors.Join(platform.ValidPort("NOTIFICATIONS_GRPC_PORT", c.GRPCPort), platform.ValidPort("NOTIFICATIONS_HTTP_PORT", c.HTTPPort))
+}
@@ -0,0 +1,36 @@
+// Package notificationsvc implements the Notifications API.
+package notificationsvc
+
+import (
+	"context"
+
+	moneypb "github.com/reliability-lab/gen/money"
+	"github.com/reliability-lab/gen/notifications"
+	"github.com/reliability-lab/pkg/money"
+	"github.com/rs/zerolog/log"
+)
+
+// Server implements notifications.NotificationsServer by logging receipts.
+type Server struct {
+	notifications.UnimplementedNotificationsServer
+}
+
+func (s *Server) SendReceipt(ctx context.Context, req *notifications.SendReceiptRequest) (*notifications.SendReceiptResponse, error) {
+	// Ctx adds trace_id and span_id (see platform logging) for Loki → Tempo links.
+	ev := log.Info().Ctx(ctx).
+		Str("event", "receipt_sent").
+		Str("order_id", req.OrderId).
+		Str("user_id", req.UserId)
+	for _, a := range []struct {
+		name   string
+		amount *moneypb.Money
+	}{{"subtotal", req.Subtotal}, {"discount", req.Discount}, {"tax", req.Tax}, {"total", req.Total}} {
+		if m, err := money.FromProto(a.amount); err == nil {
+			ev = ev.Stringer(a.name, m)
+		}
+	}
+	ev.Msg("receipt_sent")
+	return &notifications.SendReceiptResponse{Ok: true}, nil
+}
+
+var _ notifications.NotificationsServer = (*Server)(nil)
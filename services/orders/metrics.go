@@ -1,35 +0,0 @@
-package main
-
-import (
-	"github.com/prometheus/client_golang/prometheus"
-)
-
-var (
-	dbQueryDurationSeconds = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "db_query_duration_seconds",
-			Help:    "Database query duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"operation"},
-	)
-	rpcRequestsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "rpc_requests_total",
-			Help: "Total RPC requests",
-		},
-		[]string{"service", "method", "code"},
-	)
-	rpcRequestDurationSeconds = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "rpc_request_duration_seconds",
-			Help:    "RPC request duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"service", "method"},
-	)
-)
-
-func init() {
-	prometheus.MustRegister(dbQueryDurationSeconds, rpcRequestsTotal, rpcRequestDurationSeconds)
-}
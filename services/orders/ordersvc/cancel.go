@@ -0,0 +1,143 @@
+package ordersvc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/reliability-lab/gen/inventory"
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/gen/payments"
+	"github.com/reliability-lab/pkg/money"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Cancellation reason codes accepted by CancelOrder.
+const (
+	reasonCustomerRequest = "CUSTOMER_REQUEST"
+	reasonDuplicateOrder  = "DUPLICATE_ORDER"
+	reasonFraudSuspected  = "FRAUD_SUSPECTED"
+	reasonOther           = "OTHER"
+)
+
+var cancelReasons = []string{reasonCustomerRequest, reasonDuplicateOrder, reasonFraudSuspected, reasonOther}
+
+// cancellable reports whether an order in status may be cancelled.
+func cancellable(status string) bool {
+	return status == statusCreated || status == statusPaid
+}
+
+// CancelOrder cancels the order first, then reverses its payment and gives
+// back its stock. Writing CANCELLED first is what makes a race with checkout
+// safe: the order can no longer become PAID, and the reversal voids a charge
+// still in flight or refunds one that captured first, so no money is kept for
+// a cancelled order. Returning the stock releases a hold, restocks committed
+// items and keeps a late hold from being placed. Cancelling a cancelled order
+// repeats the reversal and the return, which are idempotent, so a cancel that
+// failed part way is completed by retrying it.
+func (s *Server) CancelOrder(ctx context.Context, req *orders.CancelOrderRequest) (*orders.CancelOrderResponse, error) {
+	ctx, span := otel.Tracer("orders").Start(ctx, "CancelOrder")
+	defer span.End()
+	span.SetAttributes(attribute.String("order_id", req.OrderId), attribute.String("reason", req.Reason))
+
+	if req.OrderId == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "order_id required")
+	}
+	if !slices.Contains(cancelReasons, req.Reason) {
+		return nil, status.Error(grpccodes.InvalidArgument, "reason must be CUSTOMER_REQUEST, DUPLICATE_ORDER, FRAUD_SUSPECTED or OTHER")
+	}
+	if s.payments == nil {
+		return nil, status.Error(grpccodes.FailedPrecondition, "cancellation needs payments, which is not configured")
+	}
+
+	o, err := s.repo.GetOrder(ctx, req.OrderId)
+	if errors.Is(err, ErrNotFound) {
+		return nil, status.Error(grpccodes.NotFound, "order not found")
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, dbStatus(err, "failed to get order")
+	}
+	if err := checkVersion(o, req.ExpectedVersion); err != nil {
+		return nil, err
+	}
+	if o.Status != statusCancelled && !cancellable(o.Status) {
+		return nil, status.Errorf(grpccodes.FailedPrecondition, "order is %s", o.Status)
+	}
+
+	start := time.Now()
+	applied := false
+	o, err = s.repo.UpdateStatus(ctx, o.ID, Transition{Status: statusCancelled, Reason: req.Reason, By: auditOf(ctx)}, func(current Order) (bool, error) {
+		applied = false
+		// Someone may have written the order since it was read above.
+		if err := checkVersion(current, req.ExpectedVersion); err != nil {
+			return false, err
+		}
+		if current.Status == statusCancelled {
+			return false, nil
+		}
+		// The checkout settled the order first.
+		if !cancellable(current.Status) {
+			return false, status.Errorf(grpccodes.FailedPrecondition, "order is %s", current.Status)
+		}
+		applied = true
+		return true, nil
+	})
+	dbQueryDurationSeconds.WithLabelValues("cancel_order").Observe(time.Since(start).Seconds())
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			span.SetStatus(codes.Error, st.Message())
+			return nil, err
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, dbStatus(err, "failed to cancel order")
+	}
+
+	rev, err := s.payments.Reverse(ctx, &payments.ReverseRequest{OrderId: o.ID, Reason: req.Reason})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		st := status.Convert(err)
+		return nil, status.Errorf(st.Code(), "payments: %s", st.Message())
+	}
+	if len(o.Items) > 0 && s.inventory != nil {
+		if _, err := s.inventory.Return(ctx, &inventory.ReturnRequest{ReservationId: o.ID}); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			st := status.Convert(err)
+			return nil, status.Errorf(st.Code(), "inventory: %s", st.Message())
+		}
+	}
+	if applied {
+		orderCancellationsTotal.WithLabelValues(req.Reason, rev.Status).Inc()
+	}
+	refunded := refundOf(rev)
+	if refunded.Currency == "" {
+		// A void of an order that was never charged.
+		refunded.Currency = o.Charge().Currency
+	}
+	return &orders.CancelOrderResponse{
+		OrderId:       o.ID,
+		Status:        o.Status,
+		Reason:        o.StatusReason,
+		PaymentStatus: rev.Status,
+		RefundedCents: refunded.Amount,
+		Version:       o.Version,
+		Refunded:      refunded.Proto(),
+	}, nil
+}
+
+// refundOf returns what payments refunded: refunded, or refunded_cents and
+// currency from a payments that predates Money.
+func refundOf(rev *payments.ReverseResponse) money.Money {
+	if r := rev.Refunded; r != nil {
+		return money.Money{Amount: r.MinorUnits, Currency: r.Currency}
+	}
+	return money.Money{Amount: rev.RefundedCents, Currency: rev.Currency}
+}
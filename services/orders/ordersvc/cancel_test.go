@@ -0,0 +1,251 @@
+package ordersvc
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/reliability-lab/gen/inventory"
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/gen/payments"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakePayments refunds the orders in captured and voids the rest, or fails
+// with err.
+type fakePayments struct {
+	payments.PaymentsClient
+	mu       sync.Mutex
+	captured map[string]int64
+	reversed []string
+	err      error
+}
+
+func (p *fakePayments) Reverse(_ context.Context, req *payments.ReverseRequest, _ ...grpc.CallOption) (*payments.ReverseResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.reversed = append(p.reversed, req.OrderId)
+	if amount, ok := p.captured[req.OrderId]; ok {
+		return &payments.ReverseResponse{OrderId: req.OrderId, Status: "REFUNDED", RefundedCents: amount, Currency: "USD"}, nil
+	}
+	return &payments.ReverseResponse{OrderId: req.OrderId, Status: "VOIDED"}, nil
+}
+
+// fakeInventory records the reservations given back, or fails with err.
+type fakeInventory struct {
+	inventory.InventoryClient
+	mu       sync.Mutex
+	returned []string
+	err      error
+}
+
+func (i *fakeInventory) Return(_ context.Context, req *inventory.ReturnRequest, _ ...grpc.CallOption) (*inventory.ReturnResponse, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.err != nil {
+		return nil, i.err
+	}
+	i.returned = append(i.returned, req.ReservationId)
+	return &inventory.ReturnResponse{ReservationId: req.ReservationId, Status: "RETURNED"}, nil
+}
+
+func TestCancelOrder(t *testing.T) {
+	pay := &fakePayments{captured: map[string]int64{}}
+	srv := NewServer(NewMemoryRepository(), WithPayments(pay))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+	create := func(key, settle string) string {
+		t.Helper()
+		resp, err := srv.CreateOrder(ctx, &orders.CreateOrderRequest{UserId: "u1", AmountCents: 1299, Currency: "USD", IdempotencyKey: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if settle != "" {
+			if _, err := srv.UpdateOrderStatus(ctx, &orders.UpdateOrderStatusRequest{OrderId: resp.OrderId, Status: settle}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.OrderId
+	}
+
+	created := create("cancel-created", "")
+	for range 2 {
+		resp, err := srv.CancelOrder(ctx, &orders.CancelOrderRequest{OrderId: created, Reason: reasonCustomerRequest})
+		if err != nil || resp.Status != statusCancelled || resp.Reason != reasonCustomerRequest || resp.PaymentStatus != "VOIDED" ||
+			resp.Refunded == nil || resp.Refunded.MinorUnits != 0 || resp.Refunded.Currency != "USD" {
+			t.Fatalf("CancelOrder of a CREATED order = %v, %v; want CANCELLED, VOIDED with 0 USD refunded", resp, err)
+		}
+	}
+	got, err := srv.GetOrder(ctx, &orders.GetOrderRequest{OrderId: created})
+	if err != nil || got.Status != statusCancelled || got.StatusReason != reasonCustomerRequest {
+		t.Errorf("GetOrder after cancel = %v, %v", got, err)
+	}
+	// The checkout losing the race must not resurrect the order.
+	if _, err := srv.UpdateOrderStatus(ctx, &orders.UpdateOrderStatusRequest{OrderId: created, Status: statusPaid}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("PAID after CANCELLED: %v, want FailedPrecondition", err)
+	}
+
+	paid := create("cancel-paid", statusPaid)
+	pay.captured[paid] = 1299
+	resp, err := srv.CancelOrder(ctx, &orders.CancelOrderRequest{OrderId: paid, Reason: reasonDuplicateOrder})
+	if err != nil || resp.Status != statusCancelled || resp.PaymentStatus != "REFUNDED" || resp.RefundedCents != 1299 ||
+		resp.Refunded.GetMinorUnits() != 1299 || resp.Refunded.GetCurrency() != "USD" {
+		t.Errorf("CancelOrder of a PAID order = %v, %v; want CANCELLED, REFUNDED 1299 USD", resp, err)
+	}
+
+	failed := create("cancel-failed", statusPaymentFailed)
+	reversals := len(pay.reversed)
+	for _, tc := range []struct {
+		name string
+		req  *orders.CancelOrderRequest
+		want codes.Code
+	}{
+		{"payment failed", &orders.CancelOrderRequest{OrderId: failed, Reason: reasonOther}, codes.FailedPrecondition},
+		{"unknown order", &orders.CancelOrderRequest{OrderId: "6f9a3c1e-0000-4000-8000-000000000000", Reason: reasonOther}, codes.NotFound},
+		{"no reason", &orders.CancelOrderRequest{OrderId: paid}, codes.InvalidArgument},
+		{"unknown reason", &orders.CancelOrderRequest{OrderId: paid, Reason: "changed my mind"}, codes.InvalidArgument},
+	} {
+		if _, err := srv.CancelOrder(ctx, tc.req); status.Code(err) != tc.want {
+			t.Errorf("%s: %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if len(pay.reversed) != reversals {
+		t.Errorf("rejected cancels reversed payments: %v", pay.reversed[reversals:])
+	}
+
+	noPayments := NewServer(NewMemoryRepository())
+	t.Cleanup(noPayments.Close)
+	if _, err := noPayments.CancelOrder(ctx, &orders.CancelOrderRequest{OrderId: created, Reason: reasonOther}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("CancelOrder without payments: %v, want FailedPrecondition", err)
+	}
+}
+
+// TestCancelOrder_RetryCompletes fails the reversal and then the return of
+// a cancel: the order is CANCELLED either way, and retrying the cancel
+// reverses the payment and gives back the stock.
+func TestCancelOrder_RetryCompletes(t *testing.T) {
+	pay := &fakePayments{captured: map[string]int64{}}
+	inv := &fakeInventory{}
+	srv := NewServer(NewMemoryRepository(), WithCatalog(newFakeCatalog()), WithPayments(pay), WithInventory(inv))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+	created, err := srv.CreateOrder(ctx, itemsRequest("cancel-retry", 0, &orders.LineItem{Sku: "MUG-350", Quantity: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := created.OrderId
+	cancel := func() (*orders.CancelOrderResponse, error) {
+		return srv.CancelOrder(ctx, &orders.CancelOrderRequest{OrderId: id, Reason: reasonCustomerRequest})
+	}
+
+	pay.err = status.Error(codes.Unavailable, "payments down")
+	if _, err := cancel(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("cancel with payments down: %v, want Unavailable", err)
+	}
+	if got, err := srv.GetOrder(ctx, &orders.GetOrderRequest{OrderId: id}); err != nil || got.Status != statusCancelled {
+		t.Errorf("GetOrder after a failed reversal = %v, %v; want CANCELLED", got, err)
+	}
+	if len(inv.returned) != 0 {
+		t.Errorf("stock given back before the payment was reversed: %v", inv.returned)
+	}
+
+	pay.err = nil
+	inv.err = status.Error(codes.Unavailable, "inventory down")
+	if _, err := cancel(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("cancel with inventory down: %v, want Unavailable", err)
+	}
+
+	inv.err = nil
+	resp, err := cancel()
+	if err != nil || resp.Status != statusCancelled || resp.PaymentStatus != "VOIDED" {
+		t.Fatalf("retried cancel = %v, %v; want CANCELLED, VOIDED", resp, err)
+	}
+	if len(inv.returned) != 1 || inv.returned[0] != id {
+		t.Errorf("reservations given back %v, want [%s]", inv.returned, id)
+	}
+
+	// Orders without items have no reservation to give back.
+	plain, err := srv.CreateOrder(ctx, &orders.CreateOrderRequest{UserId: "u1", AmountCents: 1299, Currency: "USD", IdempotencyKey: "cancel-plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.CancelOrder(ctx, &orders.CancelOrderRequest{OrderId: plain.OrderId, Reason: reasonOther}); err != nil || len(inv.returned) != 1 {
+		t.Errorf("cancel of an order without items: %v, returned %v", err, inv.returned)
+	}
+}
+
+func TestExpectedVersion_Aborts(t *testing.T) {
+	pay := &fakePayments{captured: map[string]int64{}}
+	srv := NewServer(NewMemoryRepository(), WithPayments(pay))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+	created, err := srv.CreateOrder(ctx, &orders.CreateOrderRequest{UserId: "u1", AmountCents: 1299, Currency: "USD", IdempotencyKey: "versioned"})
+	if err != nil || created.Version != 1 {
+		t.Fatalf("CreateOrder = %v, %v; want version 1", created, err)
+	}
+	id := created.OrderId
+
+	if _, err := srv.UpdateOrderStatus(ctx, &orders.UpdateOrderStatusRequest{OrderId: id, Status: statusPaid, ExpectedVersion: 2}); status.Code(err) != codes.Aborted {
+		t.Errorf("update at a stale version: %v, want Aborted", err)
+	}
+	upd, err := srv.UpdateOrderStatus(ctx, &orders.UpdateOrderStatusRequest{OrderId: id, Status: statusPaid, ExpectedVersion: 1})
+	if err != nil || upd.Version != 2 {
+		t.Fatalf("update at the current version = %v, %v; want version 2", upd, err)
+	}
+
+	if _, err := srv.CancelOrder(ctx, &orders.CancelOrderRequest{OrderId: id, Reason: reasonOther, ExpectedVersion: 1}); status.Code(err) != codes.Aborted {
+		t.Errorf("cancel at a stale version: %v, want Aborted", err)
+	}
+	if len(pay.reversed) != 0 {
+		t.Errorf("payments reversed %v after an aborted cancel", pay.reversed)
+	}
+	resp, err := srv.CancelOrder(ctx, &orders.CancelOrderRequest{OrderId: id, Reason: reasonOther, ExpectedVersion: 2})
+	if err != nil || resp.Version != 3 {
+		t.Errorf("cancel at the current version = %v, %v; want version 3", resp, err)
+	}
+	if got, err := srv.GetOrder(ctx, &orders.GetOrderRequest{OrderId: id}); err != nil || got.Version != 3 {
+		t.Errorf("GetOrder = %v, %v; want version 3", got, err)
+	}
+}
+
+// writeAfterRead is an OrderRepository that marks an order PAID right after
+// CancelOrder has read it, as a checkout settling concurrently would.
+type writeAfterRead struct {
+	OrderRepository
+	once sync.Once
+}
+
+func (r *writeAfterRead) GetOrder(ctx context.Context, id string) (Order, error) {
+	o, err := r.OrderRepository.GetOrder(ctx, id)
+	r.once.Do(func() {
+		_, _ = r.OrderRepository.UpdateStatus(ctx, id, Transition{Status: statusPaid}, func(Order) (bool, error) { return true, nil })
+	})
+	return o, err
+}
+
+func TestCancelOrder_VersionRace(t *testing.T) {
+	pay := &fakePayments{captured: map[string]int64{}}
+	repo := &writeAfterRead{OrderRepository: NewMemoryRepository()}
+	srv := NewServer(repo, WithPayments(pay))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+	created, err := srv.CreateOrder(ctx, &orders.CreateOrderRequest{UserId: "u1", AmountCents: 1299, Currency: "USD", IdempotencyKey: "version-race"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := srv.CancelOrder(ctx, &orders.CancelOrderRequest{OrderId: created.OrderId, Reason: reasonOther, ExpectedVersion: created.Version}); status.Code(err) != codes.Aborted {
+		t.Errorf("cancel racing a write: %v, want Aborted", err)
+	}
+	if got, err := srv.GetOrder(ctx, &orders.GetOrderRequest{OrderId: created.OrderId}); err != nil || got.Status != statusPaid {
+		t.Errorf("GetOrder = %v, %v; want PAID", got, err)
+	}
+	if len(pay.reversed) != 0 {
+		t.Errorf("payments reversed %v after an aborted cancel", pay.reversed)
+	}
+}
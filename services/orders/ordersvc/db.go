@@ -0,0 +1,245 @@
+package ordersvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// dbConn is what the handlers need from Postgres. *pgxpool.Pool implements
+// it; faultyDB wraps one to inject the chaos API's postgres faults.
+type dbConn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// chaosPostgres is the injection point in front of every statement, Begin
+// and Commit the handlers issue. Faults match on the gRPC method being served.
+const chaosPostgres = "postgres"
+
+// Postgres fault errors.
+const (
+	errSerialization      = "SERIALIZATION_FAILURE"
+	errDeadlock           = "DEADLOCK"
+	errTooManyConnections = "TOO_MANY_CONNECTIONS"
+	// errDrop closes the connection the statement would use.
+	errDrop = "DROP"
+)
+
+// faultyDB injects postgres faults before each operation: latency that
+// honours ctx, Postgres errors with their real SQLSTATE, and dropped
+// connections.
+type faultyDB struct {
+	db    dbConn
+	chaos *chaos.Registry
+}
+
+func newFaultyDB(db dbConn, reg *chaos.Registry) *faultyDB {
+	reg.RegisterTarget(chaos.Target{
+		Name:        chaosPostgres,
+		Description: "every orders query, Begin and Commit: latency, Postgres errors and dropped connections; match on the gRPC method",
+		Errors:      []string{errSerialization, errDeadlock, errTooManyConnections, errDrop},
+	})
+	return &faultyDB{db: db, chaos: reg}
+}
+
+// inject applies the faults that fire on this operation. drop closes the
+// affected connection; it is nil outside a transaction, where a pooled
+// connection is dropped instead.
+func (d *faultyDB) inject(ctx context.Context, op string, drop func()) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	method, _ := grpc.Method(ctx)
+	for _, f := range d.chaos.Hit(ctx, chaosPostgres, chaos.Call{Method: method, Metadata: md}) {
+		if err := chaos.Sleep(ctx, d.chaos.Delay(ctx, f)); err != nil {
+			return err
+		}
+		switch f.Error {
+		case "":
+		case errSerialization:
+			return &pgconn.PgError{Severity: "ERROR", Code: "40001", Message: "could not serialize access due to concurrent update (chaos fault " + f.ID + ")"}
+		case errDeadlock:
+			return &pgconn.PgError{Severity: "ERROR", Code: "40P01", Message: "deadlock detected (chaos fault " + f.ID + ")"}
+		case errTooManyConnections:
+			return &pgconn.PgError{Severity: "FATAL", Code: "53300", Message: "sorry, too many clients already (chaos fault " + f.ID + ")"}
+		case errDrop:
+			if drop == nil {
+				drop = d.dropPooled
+			}
+			drop()
+			trace.SpanFromContext(ctx).AddEvent("chaos.postgres.drop")
+			return fmt.Errorf("%s: server closed the connection unexpectedly (chaos fault %s): %w", op, f.ID, io.ErrUnexpectedEOF)
+		}
+	}
+	return nil
+}
+
+// dropPooled closes an idle pooled connection, as a failover or a network
+// blip would; the pool replaces it on demand.
+func (d *faultyDB) dropPooled() {
+	pool, ok := d.db.(*pgxpool.Pool)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if c, err := pool.Acquire(ctx); err == nil {
+		_ = c.Hijack().Close(ctx)
+	}
+}
+
+func (d *faultyDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	if err := d.inject(ctx, "begin", nil); err != nil {
+		return nil, err
+	}
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &faultyTx{Tx: tx, db: d}, nil
+}
+
+func (d *faultyDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if err := d.inject(ctx, "exec", nil); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return d.db.Exec(ctx, sql, args...)
+}
+
+func (d *faultyDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if err := d.inject(ctx, "query", nil); err != nil {
+		return nil, err
+	}
+	return d.db.Query(ctx, sql, args...)
+}
+
+func (d *faultyDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if err := d.inject(ctx, "query", nil); err != nil {
+		return errRow{err}
+	}
+	return d.db.QueryRow(ctx, sql, args...)
+}
+
+// faultyTx injects faults into the statements and Commit of a transaction;
+// DROP closes the transaction's own connection.
+type faultyTx struct {
+	pgx.Tx
+	db *faultyDB
+}
+
+func (t *faultyTx) drop() { _ = t.Tx.Conn().Close(context.Background()) }
+
+func (t *faultyTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if err := t.db.inject(ctx, "exec", t.drop); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return t.Tx.Exec(ctx, sql, args...)
+}
+
+func (t *faultyTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if err := t.db.inject(ctx, "query", t.drop); err != nil {
+		return nil, err
+	}
+	return t.Tx.Query(ctx, sql, args...)
+}
+
+func (t *faultyTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if err := t.db.inject(ctx, "query", t.drop); err != nil {
+		return errRow{err}
+	}
+	return t.Tx.QueryRow(ctx, sql, args...)
+}
+
+func (t *faultyTx) Commit(ctx context.Context) error {
+	if err := t.db.inject(ctx, "commit", t.drop); err != nil {
+		return err
+	}
+	return t.Tx.Commit(ctx)
+}
+
+type errRow struct{ err error }
+
+func (r errRow) Scan(...any) error { return r.err }
+
+// maxTxAttempts bounds how often a transaction that hit a serialization
+// failure or deadlock is run.
+const maxTxAttempts = 3
+
+// retryTx runs fn, a whole transaction, again when Postgres aborted it with
+// a serialization failure or deadlock, with a short jittered backoff.
+func retryTx(ctx context.Context, op string, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if !retryableTx(err) || attempt == maxTxAttempts {
+			return err
+		}
+		dbTxRetriesTotal.WithLabelValues(op).Inc()
+		trace.SpanFromContext(ctx).AddEvent("db.tx.retry")
+		backoff := time.Duration(attempt)*10*time.Millisecond + time.Duration(rand.Intn(10))*time.Millisecond
+		if chaos.Sleep(ctx, backoff) != nil {
+			return err
+		}
+	}
+}
+
+func retryableTx(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01")
+}
+
+// dbStatus maps a database error to the gRPC status callers should act on:
+// Aborted for conflicts worth retrying, Unavailable when Postgres is
+// unreachable or out of connections, DeadlineExceeded/Canceled for the
+// caller's context, and Internal for the rest. msg describes the operation.
+func dbStatus(err error, msg string) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(grpccodes.DeadlineExceeded, msg+": deadline exceeded")
+	case errors.Is(err, context.Canceled):
+		return status.Error(grpccodes.Canceled, msg+": canceled")
+	case errors.As(err, &pgErr):
+		switch {
+		case pgErr.Code == "40001" || pgErr.Code == "40P01":
+			return status.Error(grpccodes.Aborted, msg+": concurrent update, retry")
+		case pgErr.Code == "53300", strings.HasPrefix(pgErr.Code, "08"), strings.HasPrefix(pgErr.Code, "57P"):
+			// too_many_connections, connection exceptions, and shutdowns
+			// during failover.
+			return status.Error(grpccodes.Unavailable, msg+": database unavailable")
+		case pgErr.Code == "57014":
+			return status.Error(grpccodes.DeadlineExceeded, msg+": statement timeout")
+		case strings.HasPrefix(pgErr.Code, "53"):
+			return status.Error(grpccodes.ResourceExhausted, msg+": database out of resources")
+		}
+	case connectionError(err):
+		return status.Error(grpccodes.Unavailable, msg+": database unavailable")
+	}
+	return status.Error(grpccodes.Internal, msg)
+}
+
+// connectionError reports errors from a connection that failed or was lost
+// rather than from the statement.
+func connectionError(err error) bool {
+	var connErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connErr) || errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) ||
+		pgconn.SafeToRetry(err)
+}
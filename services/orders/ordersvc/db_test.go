@@ -0,0 +1,126 @@
+package ordersvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeDB counts the statements that reach it.
+type fakeDB struct{ execs int }
+
+func (f *fakeDB) Begin(context.Context) (pgx.Tx, error) { return nil, errors.New("not implemented") }
+func (f *fakeDB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	f.execs++
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+func (f *fakeDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+func (f *fakeDB) QueryRow(context.Context, string, ...any) pgx.Row { return errRow{pgx.ErrNoRows} }
+
+func TestFaultyDB_InjectsPostgresErrors(t *testing.T) {
+	fake := &fakeDB{}
+	reg := chaos.NewRegistry("orders")
+	db := newFaultyDB(fake, reg)
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		fault string
+		code  codes.Code
+	}{
+		{errSerialization, codes.Aborted},
+		{errDeadlock, codes.Aborted},
+		{errTooManyConnections, codes.Unavailable},
+		{errDrop, codes.Unavailable},
+	} {
+		f, err := reg.Set(ctx, chaos.Fault{Target: chaosPostgres, Error: tt.fault}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = db.Exec(ctx, "UPDATE orders SET status = 'PAID'")
+		if got := status.Code(dbStatus(err, "update")); got != tt.code {
+			t.Errorf("%s: %v maps to %s, want %s", tt.fault, err, got, tt.code)
+		}
+		reg.Clear(ctx, f.ID)
+	}
+	if fake.execs != 0 {
+		t.Errorf("%d statements reached the database, want 0", fake.execs)
+	}
+	if _, err := db.Exec(ctx, "UPDATE orders SET status = 'PAID'"); err != nil || fake.execs != 1 {
+		t.Errorf("without faults: err %v, %d execs", err, fake.execs)
+	}
+}
+
+func TestFaultyDB_LatencyHonoursDeadline(t *testing.T) {
+	reg := chaos.NewRegistry("orders")
+	db := newFaultyDB(&fakeDB{}, reg)
+	if _, err := reg.Set(context.Background(), chaos.Fault{Target: chaosPostgres, Latency: time.Minute}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := db.QueryRow(ctx, "SELECT 1").Scan()
+	if status.Code(dbStatus(err, "get")) != codes.DeadlineExceeded || time.Since(start) > time.Second {
+		t.Fatalf("got %v after %s, want DeadlineExceeded at the deadline", err, time.Since(start))
+	}
+}
+
+func TestRetryTx(t *testing.T) {
+	serialization := &pgconn.PgError{Code: "40001"}
+	calls := 0
+	err := retryTx(context.Background(), "test", func() error {
+		calls++
+		if calls < 3 {
+			return serialization
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("serialization failures: err %v after %d calls, want success on the 3rd", err, calls)
+	}
+
+	calls = 0
+	err = retryTx(context.Background(), "test", func() error { calls++; return serialization })
+	if !errors.Is(err, serialization) || calls != maxTxAttempts {
+		t.Errorf("persistent failure: err %v after %d calls, want %d attempts", err, calls, maxTxAttempts)
+	}
+
+	calls = 0
+	tooMany := &pgconn.PgError{Code: "53300"}
+	if err := retryTx(context.Background(), "test", func() error { calls++; return tooMany }); err != tooMany || calls != 1 {
+		t.Errorf("too many connections: err %v after %d calls, want no retry", err, calls)
+	}
+}
+
+func TestDBStatus(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		code codes.Code
+	}{
+		{&pgconn.PgError{Code: "40001"}, codes.Aborted},
+		{&pgconn.PgError{Code: "53300"}, codes.Unavailable},
+		{&pgconn.PgError{Code: "57P01"}, codes.Unavailable},
+		{&pgconn.PgError{Code: "08006"}, codes.Unavailable},
+		{&pgconn.PgError{Code: "57014"}, codes.DeadlineExceeded},
+		{&pgconn.PgError{Code: "53200"}, codes.ResourceExhausted},
+		{&pgconn.PgError{Code: "23505"}, codes.Internal},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), codes.Unavailable},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{errors.New("boom"), codes.Internal},
+	} {
+		if got := status.Code(dbStatus(tt.err, "op")); got != tt.code {
+			t.Errorf("%v: got %s, want %s", tt.err, got, tt.code)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package ordersvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/reliability-lab/gen/orders"
+	"github.com/rs/zerolog/log"
+)
+
+// subscriberBuffer is how many events a slow WatchOrders stream may lag
+// before it is dropped and the client has to resume.
+const subscriberBuffer = 64
+
+// maxEventLag is how far the listener may trail the stored events before the
+// readiness check reports it.
+const maxEventLag = 100
+
+// Event is a status transition of an order. Seq orders events across all
+// orders.
+type Event struct {
+	Seq            int64     `json:"seq"`
+	OrderID        string    `json:"order_id"`
+	UserID         string    `json:"user_id"`
+	Status         string    `json:"status"`
+	PreviousStatus string    `json:"previous_status"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+func (e Event) proto() *orders.OrderEvent {
+	return &orders.OrderEvent{
+		Sequence:       e.Seq,
+		OrderId:        e.OrderID,
+		UserId:         e.UserID,
+		Status:         e.Status,
+		PreviousStatus: e.PreviousStatus,
+		OccurredAt:     e.OccurredAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+type subscription struct {
+	orderID string
+	userID  string
+	ch      chan Event
+}
+
+func (s *subscription) matches(ev Event) bool {
+	if s.orderID != "" {
+		return s.orderID == ev.OrderID
+	}
+	return s.userID == ev.UserID
+}
+
+var errHubClosed = errors.New("event hub closed")
+
+// eventHub fans order events out to WatchOrders streams.
+type eventHub struct {
+	mu     sync.Mutex
+	subs   map[*subscription]struct{}
+	closed bool
+
+	// connected and lastSeq feed lagCheck.
+	connected atomic.Bool
+	lastSeq   atomic.Int64
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: make(map[*subscription]struct{})}
+}
+
+func (h *eventHub) subscribe(orderID, userID string) (*subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, errHubClosed
+	}
+	sub := &subscription{orderID: orderID, userID: userID, ch: make(chan Event, subscriberBuffer)}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+func (h *eventHub) unsubscribe(sub *subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// publish never blocks: a subscriber whose buffer is full is closed and must
+// resume from its last sequence.
+func (h *eventHub) publish(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if !sub.matches(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// close ends every stream so that grpc GracefulStop does not wait on them.
+func (h *eventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		close(sub.ch)
+	}
+	h.subs = make(map[*subscription]struct{})
+}
+
+// listen publishes the events repo delivers until ctx is done, subscribing
+// again with backoff when the feed breaks.
+func (h *eventHub) listen(ctx context.Context, repo OrderRepository) {
+	backoff := 100 * time.Millisecond
+	for ctx.Err() == nil {
+		err := repo.Listen(ctx, func(head int64) {
+			// Events committed before subscribing are never delivered; count
+			// from the head.
+			h.advance(head)
+			h.connected.Store(true)
+		}, func(ev Event) {
+			h.advance(ev.Seq)
+			h.publish(ev)
+		})
+		h.connected.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn().Err(err).Dur("retry_in", backoff).Msg("order event listener disconnected")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 5*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func (h *eventHub) advance(seq int64) {
+	for {
+		cur := h.lastSeq.Load()
+		if seq <= cur || h.lastSeq.CompareAndSwap(cur, seq) {
+			return
+		}
+	}
+}
+
+// lagCheck is a readiness check: it fails while the listener is disconnected
+// or more than maxEventLag events behind the repository.
+func (h *eventHub) lagCheck(repo OrderRepository) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if !h.connected.Load() {
+			return errors.New("order event listener disconnected")
+		}
+		head, err := repo.LastEventSeq(ctx)
+		if err != nil {
+			return err
+		}
+		if lag := head - h.lastSeq.Load(); lag > maxEventLag {
+			return fmt.Errorf("order event listener %d events behind", lag)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,74 @@
+package ordersvc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventHub_FiltersByOrderAndUser(t *testing.T) {
+	hub := newEventHub()
+	byOrder, err := hub.subscribe("o1", "")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	byUser, err := hub.subscribe("", "u1")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	hub.publish(Event{Seq: 1, OrderID: "o1", UserID: "u1", Status: statusCreated})
+	hub.publish(Event{Seq: 2, OrderID: "o2", UserID: "u1", Status: statusCreated})
+	hub.publish(Event{Seq: 3, OrderID: "o3", UserID: "u2", Status: statusCreated})
+
+	if got := drain(byOrder); len(got) != 1 || got[0].Seq != 1 {
+		t.Errorf("order subscriber got %+v, want only seq 1", got)
+	}
+	if got := drain(byUser); len(got) != 2 || got[0].Seq != 1 || got[1].Seq != 2 {
+		t.Errorf("user subscriber got %+v, want seq 1 and 2", got)
+	}
+}
+
+func TestEventHub_DropsSlowSubscriber(t *testing.T) {
+	hub := newEventHub()
+	sub, _ := hub.subscribe("o1", "")
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.publish(Event{Seq: int64(i + 1), OrderID: "o1"})
+	}
+	n := 0
+	for range sub.ch {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("received %d events before close, want %d", n, subscriberBuffer)
+	}
+}
+
+func TestEventHub_CloseEndsStreams(t *testing.T) {
+	hub := newEventHub()
+	sub, _ := hub.subscribe("o1", "")
+	hub.close()
+	select {
+	case _, ok := <-sub.ch:
+		if ok {
+			t.Fatal("expected closed channel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not closed")
+	}
+	if _, err := hub.subscribe("o1", ""); err != errHubClosed {
+		t.Errorf("subscribe after close: got %v, want errHubClosed", err)
+	}
+	hub.unsubscribe(sub) // must not double-close
+}
+
+func drain(sub *subscription) []Event {
+	var out []Event
+	for {
+		select {
+		case ev := <-sub.ch:
+			out = append(out, ev)
+		default:
+			return out
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package ordersvc
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/pkg/money"
+	"github.com/reliability-lab/pkg/money/fx"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//go:embed fxrates.yaml
+var defaultRates []byte
+
+// DefaultRates returns the FX rates built into the binary.
+func DefaultRates() *fx.Table {
+	t, err := fx.Parse(defaultRates)
+	if err != nil {
+		panic("ordersvc: built-in fx rates: " + err.Error())
+	}
+	return t
+}
+
+// WithFX settles orders in another currency than they are priced in at
+// rates from src. Without it, such orders are rejected.
+func WithFX(src fx.Source) Option {
+	return func(s *Server) { s.fx = src }
+}
+
+// settle converts an order total into req's settlement currency. It returns
+// nil for orders charged in the currency they are priced in.
+func (s *Server) settle(ctx context.Context, req *orders.CreateOrderRequest, total money.Money) (*Settlement, error) {
+	if req.SettlementCurrency == "" || req.SettlementCurrency == total.Currency {
+		return nil, nil
+	}
+	if s.fx == nil {
+		return nil, status.Error(grpccodes.FailedPrecondition, "settlement_currency needs FX rates, which are not configured")
+	}
+	rate, err := s.fx.Rate(ctx, total.Currency, req.SettlementCurrency)
+	if errors.Is(err, fx.ErrNoRate) {
+		return nil, rejectField("fx_rate", "settlement_currency", "no FX rate from %s to %s", total.Currency, req.SettlementCurrency)
+	}
+	if err != nil {
+		return nil, status.Errorf(grpccodes.Unavailable, "fx rates: %v", err)
+	}
+	converted, err := rate.Convert(total)
+	if err != nil {
+		return nil, rejectField("fx_rate", "settlement_currency", "%v", err)
+	}
+	return &Settlement{Amount: converted, FXRate: rate.Value, FXRateAsOf: rate.AsOf}, nil
+}
@@ -0,0 +1,126 @@
+package ordersvc
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/pkg/money/fx"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateOrder_Settles(t *testing.T) {
+	srv := NewServer(NewMemoryRepository(), WithCatalog(newFakeCatalog()), WithFX(DefaultRates()))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		settlement string
+		amount     int64
+		rate       string
+	}{
+		{"EUR", 1195, "0.92"},
+		{"JPY", 1949, "150"}, // 1948.5 yen, rounded half away from zero
+		{"KWD", 3994, "0.3075"},
+	} {
+		req := itemsRequest("fx-"+tt.settlement, 0, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 1})
+		req.SettlementCurrency = tt.settlement
+		resp, err := srv.CreateOrder(ctx, req)
+		if err != nil || resp.AmountCents != 1299 || resp.Currency != "USD" || resp.FxRate != tt.rate ||
+			resp.Charge.GetMinorUnits() != tt.amount || resp.Charge.GetCurrency() != tt.settlement {
+			t.Fatalf("CreateOrder in %s = %v, %v; want 1299 USD charged as %d at %s", tt.settlement, resp, err, tt.amount, tt.rate)
+		}
+		got, err := srv.GetOrder(ctx, &orders.GetOrderRequest{OrderId: resp.OrderId})
+		if err != nil || got.AmountCents != 1299 || got.Currency != "USD" || got.SettlementCurrency != tt.settlement ||
+			got.SettlementAmountCents != tt.amount || got.FxRate != tt.rate || got.FxRateAsOf != "2026-10-01T00:00:00Z" {
+			t.Errorf("GetOrder = %v, %v; want 1299 USD settled as %d %s", got, err, tt.amount, tt.settlement)
+		}
+	}
+
+	// Settling in the priced currency is no conversion.
+	req := itemsRequest("fx-usd", 0, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 1})
+	req.SettlementCurrency = "USD"
+	if resp, err := srv.CreateOrder(ctx, req); err != nil || resp.AmountCents != 1299 || resp.Currency != "USD" || resp.FxRate != "" {
+		t.Errorf("CreateOrder settled in USD = %v, %v; want 1299 USD without a rate", resp, err)
+	}
+}
+
+func TestCreateOrder_RejectsCurrencies(t *testing.T) {
+	tests := []struct {
+		name       string
+		currency   string
+		settlement string
+		fx         bool
+		code       codes.Code
+		field      string
+	}{
+		{"lower case", "usd", "", true, codes.InvalidArgument, "currency"},
+		{"not ISO 4217", "DOG", "", true, codes.InvalidArgument, "currency"},
+		{"bad settlement", "USD", "EURO", true, codes.InvalidArgument, "settlement_currency"},
+		{"no rate", "USD", "SEK", true, codes.InvalidArgument, "settlement_currency"},
+		{"no fx", "USD", "EUR", false, codes.FailedPrecondition, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			opts := []Option{WithCatalog(newFakeCatalog())}
+			if tt.fx {
+				opts = append(opts, WithFX(DefaultRates()))
+			}
+			srv := NewServer(repo, opts...)
+			t.Cleanup(srv.Close)
+			req := itemsRequest("k", 1299)
+			req.Currency, req.SettlementCurrency = tt.currency, tt.settlement
+			_, err := srv.CreateOrder(context.Background(), req)
+			st := status.Convert(err)
+			if st.Code() != tt.code {
+				t.Fatalf("CreateOrder error %v, want %s", err, tt.code)
+			}
+			if tt.field != "" && !hasFieldViolation(st, tt.field) {
+				t.Errorf("error details %v, want a violation on %s", st.Details(), tt.field)
+			}
+			if head, _ := repo.LastEventSeq(context.Background()); head != 0 {
+				t.Errorf("a rejected order was stored")
+			}
+		})
+	}
+}
+
+// publishedAgo is an fx.Source whose rates are all 1 and published age ago.
+type publishedAgo time.Duration
+
+func (age publishedAgo) Rate(_ context.Context, from, to string) (fx.Rate, error) {
+	return fx.NewRate(from, to, big.NewRat(1, 1), time.Now().Add(-time.Duration(age))), nil
+}
+
+func TestCreateOrder_RejectsStaleRates(t *testing.T) {
+	srv := NewServer(NewMemoryRepository(), WithCatalog(newFakeCatalog()), WithFX(fx.MaxAge(publishedAgo(72*time.Hour), 48*time.Hour)))
+	t.Cleanup(srv.Close)
+	req := itemsRequest("stale", 0, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 1})
+	req.SettlementCurrency = "EUR"
+	if _, err := srv.CreateOrder(context.Background(), req); status.Code(err) != codes.Unavailable {
+		t.Errorf("CreateOrder at a 72h old rate: %v, want Unavailable", err)
+	}
+	req.SettlementCurrency = ""
+	if _, err := srv.CreateOrder(context.Background(), req); err != nil {
+		t.Errorf("CreateOrder without settlement: %v", err)
+	}
+}
+
+func hasFieldViolation(st *status.Status, field string) bool {
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				if v.Field == field && strings.TrimSpace(v.Description) != "" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
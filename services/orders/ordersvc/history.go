@@ -0,0 +1,96 @@
+package ordersvc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/pkg/platform"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Audit says who made a change to an order.
+type Audit struct {
+	// Actor is who the change was made for, e.g. user:u123.
+	Actor string
+	// Source is the service that called orders.
+	Source  string
+	TraceID string
+}
+
+// Change is an entry of an order's append-only history.
+type Change struct {
+	OrderID string
+	// Version is the order's version after the change.
+	Version   int64
+	OldStatus string
+	NewStatus string
+	Reason    string
+	By        Audit
+	At        time.Time
+}
+
+const (
+	unknownCaller = "unknown"
+	// maxReasonLen bounds the free-form reason of UpdateOrderStatus.
+	maxReasonLen = 200
+)
+
+// auditOf is the Audit of a change requested in ctx: the caller's actor and
+// service as sent through the platform headers, and the current trace.
+func auditOf(ctx context.Context) Audit {
+	actor, source := platform.Caller(ctx)
+	if actor == "" {
+		actor = unknownCaller
+	}
+	if source == "" {
+		source = unknownCaller
+	}
+	a := Audit{Actor: actor, Source: source}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		a.TraceID = sc.TraceID().String()
+	}
+	return a
+}
+
+func (s *Server) GetOrderHistory(ctx context.Context, req *orders.GetOrderHistoryRequest) (*orders.GetOrderHistoryResponse, error) {
+	ctx, span := otel.Tracer("orders").Start(ctx, "GetOrderHistory")
+	defer span.End()
+	span.SetAttributes(attribute.String("order_id", req.OrderId))
+
+	if req.OrderId == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "order_id required")
+	}
+	start := time.Now()
+	changes, err := s.repo.History(ctx, req.OrderId)
+	dbQueryDurationSeconds.WithLabelValues("get_order_history").Observe(time.Since(start).Seconds())
+	if errors.Is(err, ErrNotFound) {
+		span.SetStatus(codes.Error, "not found")
+		return nil, status.Error(grpccodes.NotFound, "order not found")
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, dbStatus(err, "failed to get order history")
+	}
+	resp := &orders.GetOrderHistoryResponse{OrderId: req.OrderId}
+	for _, c := range changes {
+		resp.Changes = append(resp.Changes, &orders.OrderChange{
+			Version:    c.Version,
+			OldStatus:  c.OldStatus,
+			NewStatus:  c.NewStatus,
+			Reason:     c.Reason,
+			Actor:      c.By.Actor,
+			Source:     c.By.Source,
+			TraceId:    c.By.TraceID,
+			OccurredAt: c.At.UTC().Format(time.RFC3339Nano),
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,205 @@
+package ordersvc
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryRepository is an OrderRepository held in process memory, for tests
+// and local runs. Its Listen only sees changes made through it.
+type MemoryRepository struct {
+	mu        sync.Mutex
+	orders    map[string]Order
+	byKey     map[string]string
+	events    []Event
+	history   map[string][]Change
+	listeners map[*func(Event)]struct{}
+	// redeemed counts coupon redemptions by code and, under "code/user",
+	// by user.
+	redeemed map[string]int64
+}
+
+// NewMemoryRepository returns an empty repository.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		orders:    make(map[string]Order),
+		byKey:     make(map[string]string),
+		history:   make(map[string][]Change),
+		listeners: make(map[*func(Event)]struct{}),
+		redeemed:  make(map[string]int64),
+	}
+}
+
+func (r *MemoryRepository) CreateOrder(ctx context.Context, o Order, by Audit) (Order, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Order{}, false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id, ok := r.byKey[o.IdempotencyKey]; ok {
+		return r.orders[id], false, nil
+	}
+	for _, d := range o.Discounts {
+		if l := d.Limits.Global; l > 0 && r.redeemed[d.Code] >= l {
+			return Order{}, false, &CouponLimitError{Code: d.Code}
+		}
+		if l := d.Limits.PerUser; l > 0 && r.redeemed[d.Code+"/"+o.UserID] >= l {
+			return Order{}, false, &CouponLimitError{Code: d.Code, PerUser: true}
+		}
+	}
+	// Callers own o's slices; keep copies.
+	o.Items = slices.Clip(slices.Clone(o.Items))
+	o.Discounts = slices.Clip(slices.Clone(o.Discounts))
+	o.Taxes = slices.Clip(slices.Clone(o.Taxes))
+	for i, d := range o.Discounts {
+		r.redeemed[d.Code]++
+		r.redeemed[d.Code+"/"+o.UserID]++
+		o.Discounts[i].Limits = CouponLimits{}
+	}
+	if o.Settlement != nil {
+		st := *o.Settlement
+		o.Settlement = &st
+	}
+	o.Version = 1
+	r.orders[o.ID] = o
+	r.byKey[o.IdempotencyKey] = o.ID
+	r.appendEventLocked(o, "")
+	r.appendChangeLocked(o, "", by)
+	return o, true, nil
+}
+
+func (r *MemoryRepository) GetOrder(ctx context.Context, id string) (Order, error) {
+	if err := ctx.Err(); err != nil {
+		return Order{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[id]
+	if !ok {
+		return Order{}, ErrNotFound
+	}
+	return o, nil
+}
+
+func (r *MemoryRepository) OrderByKey(ctx context.Context, key string) (Order, error) {
+	if err := ctx.Err(); err != nil {
+		return Order{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id, ok := r.byKey[key]
+	if !ok {
+		return Order{}, ErrNotFound
+	}
+	return r.orders[id], nil
+}
+
+func (r *MemoryRepository) UpdateStatus(ctx context.Context, id string, next Transition, decide func(Order) (bool, error)) (Order, error) {
+	if err := ctx.Err(); err != nil {
+		return Order{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[id]
+	if !ok {
+		return Order{}, ErrNotFound
+	}
+	if apply, err := decide(o); err != nil || !apply {
+		return o, err
+	}
+	prev := o.Status
+	if releasesCoupons(prev, next.Status) {
+		for _, d := range o.Discounts {
+			r.redeemed[d.Code]--
+			r.redeemed[d.Code+"/"+o.UserID]--
+		}
+	}
+	o.Status, o.StatusReason = next.Status, next.Reason
+	o.Version++
+	r.orders[id] = o
+	r.appendEventLocked(o, prev)
+	r.appendChangeLocked(o, prev, next.By)
+	return o, nil
+}
+
+func (r *MemoryRepository) appendChangeLocked(o Order, prev string, by Audit) {
+	r.history[o.ID] = append(r.history[o.ID], Change{
+		OrderID:   o.ID,
+		Version:   o.Version,
+		OldStatus: prev,
+		NewStatus: o.Status,
+		Reason:    o.StatusReason,
+		By:        by,
+		At:        time.Now().UTC(),
+	})
+}
+
+func (r *MemoryRepository) History(ctx context.Context, orderID string) ([]Change, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.orders[orderID]; !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(r.history[orderID]), nil
+}
+
+// appendEventLocked records o's current status and hands the event to the
+// listeners before the change becomes visible to other callers.
+func (r *MemoryRepository) appendEventLocked(o Order, prev string) {
+	ev := Event{
+		Seq:            int64(len(r.events)) + 1,
+		OrderID:        o.ID,
+		UserID:         o.UserID,
+		Status:         o.Status,
+		PreviousStatus: prev,
+		OccurredAt:     time.Now().UTC(),
+	}
+	r.events = append(r.events, ev)
+	for publish := range r.listeners {
+		(*publish)(ev)
+	}
+}
+
+func (r *MemoryRepository) Events(ctx context.Context, orderID, userID string, after int64, limit int) ([]Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Event
+	for _, ev := range r.events[min(int(max(after, 0)), len(r.events)):] {
+		if len(out) == limit {
+			break
+		}
+		if (orderID != "" && ev.OrderID == orderID) || (orderID == "" && ev.UserID == userID) {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func (r *MemoryRepository) LastEventSeq(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.events)), nil
+}
+
+func (r *MemoryRepository) Listen(ctx context.Context, started func(int64), publish func(Event)) error {
+	r.mu.Lock()
+	r.listeners[&publish] = struct{}{}
+	started(int64(len(r.events)))
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	r.mu.Lock()
+	delete(r.listeners, &publish)
+	r.mu.Unlock()
+	return ctx.Err()
+}
+
+var _ OrderRepository = (*MemoryRepository)(nil)
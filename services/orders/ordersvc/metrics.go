@@ -0,0 +1,48 @@
+package ordersvc
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	dbQueryDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Database query duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"operation"},
+	)
+	dbTxRetriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_tx_retries_total",
+			Help: "Transactions re-run after a serialization failure or deadlock",
+		},
+		[]string{"operation"},
+	)
+	orderPricingRejectionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_pricing_rejections_total",
+			Help: "Orders rejected while pricing their line items, by reason",
+		},
+		[]string{"reason"},
+	)
+	orderCancellationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_cancellations_total",
+			Help: "Orders cancelled, by reason code and payment outcome: VOIDED or REFUNDED",
+		},
+		[]string{"reason", "payment_status"},
+	)
+	orderCouponRedemptionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_coupon_redemptions_total",
+			Help: "Coupons redeemed by created orders, by code",
+		},
+		[]string{"code"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(dbQueryDurationSeconds, dbTxRetriesTotal, orderPricingRejectionsTotal, orderCancellationsTotal, orderCouponRedemptionsTotal)
+}
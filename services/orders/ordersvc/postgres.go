@@ -0,0 +1,641 @@
+package ordersvc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// orderEventsChannel is the Postgres NOTIFY channel that carries status
+// transitions, so every orders replica sees changes made by the others.
+const orderEventsChannel = "order_events"
+
+const orderColumns = `id, user_id, amount_cents, currency, status, idempotency_key, created_at, status_reason, version,
+	settlement_currency, settlement_amount_cents, fx_rate, fx_rate_as_of, tax_jurisdiction, tax_rules_version`
+
+// PostgresRepository is the OrderRepository on Postgres. Transactions that
+// hit a serialization failure or deadlock are retried.
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+	db   dbConn
+}
+
+// NewPostgresRepository stores orders in pool, which InitDB has migrated.
+// With reg set, statements go through its postgres injection point.
+func NewPostgresRepository(pool *pgxpool.Pool, reg *chaos.Registry) *PostgresRepository {
+	r := &PostgresRepository{pool: pool, db: pool}
+	if reg != nil {
+		r.db = newFaultyDB(pool, reg)
+	}
+	return r
+}
+
+// InitDB connects to connStr and creates the orders schema if needed.
+func InitDB(ctx context.Context, connStr string) (*pgxpool.Pool, error) {
+	ctx, span := otel.Tracer("orders").Start(ctx, "initDB")
+	defer span.End()
+
+	pool, err := pgxpool.New(ctx, connStr)
+	if err != nil {
+		return nil, err
+	}
+	q := `CREATE TABLE IF NOT EXISTS orders (
+		id UUID PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		amount_cents BIGINT NOT NULL,
+		currency TEXT NOT NULL,
+		status TEXT NOT NULL,
+		idempotency_key TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS orders_idempotency_key_key ON orders (idempotency_key);
+	CREATE TABLE IF NOT EXISTS order_events (
+		seq BIGSERIAL PRIMARY KEY,
+		order_id UUID NOT NULL,
+		user_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		previous_status TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS order_events_order_id_seq ON order_events (order_id, seq);
+	CREATE INDEX IF NOT EXISTS order_events_user_id_seq ON order_events (user_id, seq);
+	CREATE TABLE IF NOT EXISTS order_items (
+		order_id UUID NOT NULL REFERENCES orders (id),
+		line INT NOT NULL,
+		sku TEXT NOT NULL,
+		name TEXT NOT NULL,
+		quantity INT NOT NULL,
+		unit_price_cents BIGINT NOT NULL,
+		PRIMARY KEY (order_id, line)
+	);
+	DO $$ BEGIN
+		-- amount_cents was a 32-bit INT, which tops out at 21,474,836.47 USD.
+		-- Changing the type rewrites the table, so it is done only once.
+		IF (SELECT data_type FROM information_schema.columns
+		    WHERE table_schema = current_schema() AND table_name = 'orders' AND column_name = 'amount_cents') = 'integer' THEN
+			ALTER TABLE orders ALTER COLUMN amount_cents TYPE BIGINT;
+		END IF;
+	END $$;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS status_reason TEXT NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS settlement_currency TEXT NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS settlement_amount_cents BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS fx_rate TEXT NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS fx_rate_as_of TIMESTAMPTZ;
+	CREATE TABLE IF NOT EXISTS order_discounts (
+		order_id UUID NOT NULL REFERENCES orders (id),
+		line INT NOT NULL,
+		coupon_code TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		description TEXT NOT NULL,
+		amount_cents BIGINT NOT NULL,
+		PRIMARY KEY (order_id, line)
+	);
+	ALTER TABLE order_discounts ADD COLUMN IF NOT EXISTS sku TEXT NOT NULL DEFAULT '';
+	ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_category TEXT NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_jurisdiction TEXT NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_rules_version TEXT NOT NULL DEFAULT '';
+	CREATE TABLE IF NOT EXISTS order_taxes (
+		order_id UUID NOT NULL REFERENCES orders (id),
+		line INT NOT NULL,
+		name TEXT NOT NULL,
+		rate TEXT NOT NULL,
+		taxable_cents BIGINT NOT NULL,
+		amount_cents BIGINT NOT NULL,
+		PRIMARY KEY (order_id, line)
+	);
+	-- Redemptions of coupons with limits, per user and, with user_id empty,
+	-- in total.
+	CREATE TABLE IF NOT EXISTS coupon_redemptions (
+		coupon_code TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		redeemed BIGINT NOT NULL,
+		PRIMARY KEY (coupon_code, user_id)
+	);
+	CREATE TABLE IF NOT EXISTS order_history (
+		id BIGSERIAL PRIMARY KEY,
+		order_id UUID NOT NULL REFERENCES orders (id),
+		version BIGINT NOT NULL,
+		old_status TEXT NOT NULL,
+		new_status TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		source TEXT NOT NULL,
+		trace_id TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS order_history_order_id_id ON order_history (order_id, id);
+	CREATE OR REPLACE FUNCTION order_history_append_only() RETURNS trigger LANGUAGE plpgsql AS $$
+	BEGIN
+		RAISE EXCEPTION 'order_history is append-only';
+	END $$;
+	CREATE OR REPLACE TRIGGER order_history_append_only BEFORE UPDATE OR DELETE ON order_history
+		FOR EACH ROW EXECUTE FUNCTION order_history_append_only();`
+	_, err = pool.Exec(ctx, q)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("db", "ready"))
+	return pool, nil
+}
+
+// scanOrder scans orderColumns, then into extra.
+func scanOrder(row pgx.Row, extra ...any) (Order, error) {
+	var o Order
+	var st Settlement
+	var asOf *time.Time
+	err := row.Scan(append([]any{&o.ID, &o.UserID, &o.AmountCents, &o.Currency, &o.Status, &o.IdempotencyKey, &o.CreatedAt, &o.StatusReason, &o.Version,
+		&st.Amount.Currency, &st.Amount.Amount, &st.FXRate, &asOf, &o.TaxJurisdiction, &o.TaxRulesVersion}, extra...)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Order{}, ErrNotFound
+	}
+	if err != nil {
+		return Order{}, err
+	}
+	if st.Amount.Currency != "" {
+		if asOf != nil {
+			st.FXRateAsOf = *asOf
+		}
+		o.Settlement = &st
+	}
+	return o, nil
+}
+
+// settlementArgs returns the settlement column values of o.
+func settlementArgs(o Order) []any {
+	if o.Settlement == nil {
+		return []any{"", int64(0), "", nil}
+	}
+	st := o.Settlement
+	return []any{st.Amount.Currency, st.Amount.Amount, st.FXRate, st.FXRateAsOf}
+}
+
+func (r *PostgresRepository) CreateOrder(ctx context.Context, o Order, by Audit) (Order, bool, error) {
+	var out Order
+	var created bool
+	err := retryTx(ctx, "create_order", func() (err error) {
+		out, created, err = r.createOrderTx(ctx, o, by)
+		return err
+	})
+	return out, created, err
+}
+
+func (r *PostgresRepository) createOrderTx(ctx context.Context, o Order, by Audit) (Order, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return Order{}, false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// INSERT with ON CONFLICT DO UPDATE SET status = orders.status to force RETURNING the existing row;
+	// xmax = 0 only for a freshly inserted row.
+	q := `INSERT INTO orders (` + orderColumns + `)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 1, $9, $10, $11, $12, $13, $14)
+	      ON CONFLICT (idempotency_key) DO UPDATE SET status = orders.status
+	      RETURNING ` + orderColumns + `, (xmax = 0) AS inserted`
+	var inserted bool
+	args := append([]any{o.ID, o.UserID, o.AmountCents, o.Currency, o.Status, o.IdempotencyKey, o.CreatedAt, o.StatusReason}, settlementArgs(o)...)
+	args = append(args, o.TaxJurisdiction, o.TaxRulesVersion)
+	out, err := scanOrder(tx.QueryRow(ctx, q, args...), &inserted)
+	if err != nil {
+		return Order{}, false, err
+	}
+	if inserted {
+		if err := redeemCoupons(ctx, tx, o.UserID, o.Discounts); err != nil {
+			return Order{}, false, err
+		}
+		if err := insertItems(ctx, tx, out.ID, o.Items); err != nil {
+			return Order{}, false, err
+		}
+		if err := insertDiscounts(ctx, tx, out.ID, o.Discounts); err != nil {
+			return Order{}, false, err
+		}
+		if err := insertTaxes(ctx, tx, out.ID, o.Taxes); err != nil {
+			return Order{}, false, err
+		}
+		if err := recordChange(ctx, tx, out, "", by); err != nil {
+			return Order{}, false, err
+		}
+		// Last, so the events lock is held only until the commit.
+		if err := recordEvent(ctx, tx, out.ID, out.UserID, "", out.Status); err != nil {
+			return Order{}, false, err
+		}
+		out.Items, out.Discounts, out.Taxes = o.Items, slices.Clone(o.Discounts), o.Taxes
+		for i := range out.Discounts {
+			out.Discounts[i].Limits = CouponLimits{}
+		}
+	} else if err := loadLines(ctx, tx, &out); err != nil {
+		return Order{}, false, err
+	}
+	return out, inserted, tx.Commit(ctx)
+}
+
+// insertItems stores items as lines 1..n of the order in one statement.
+func insertItems(ctx context.Context, tx pgx.Tx, orderID string, items []Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	skus := make([]string, len(items))
+	names := make([]string, len(items))
+	quantities := make([]int32, len(items))
+	prices := make([]int64, len(items))
+	categories := make([]string, len(items))
+	for i, it := range items {
+		skus[i], names[i], quantities[i], prices[i], categories[i] = it.SKU, it.Name, it.Quantity, it.UnitPriceCents, it.TaxCategory
+	}
+	q := `INSERT INTO order_items (order_id, line, sku, name, quantity, unit_price_cents, tax_category)
+	      SELECT $1, t.line, t.sku, t.name, t.quantity, t.unit_price_cents, t.tax_category
+	      FROM unnest($2::text[], $3::text[], $4::int[], $5::bigint[], $6::text[])
+	           WITH ORDINALITY AS t(sku, name, quantity, unit_price_cents, tax_category, line)`
+	_, err := tx.Exec(ctx, q, orderID, skus, names, quantities, prices, categories)
+	return err
+}
+
+// redeemCoupons counts a redemption of each discount's coupon that has
+// limits, failing with a *CouponLimitError if that goes over one. Each
+// counter is bumped only while under its limit by a single upsert, which
+// holds the row lock until tx ends, so concurrent orders cannot both take
+// the last redemption. Codes are locked in order to avoid deadlocks.
+func redeemCoupons(ctx context.Context, tx pgx.Tx, userID string, discounts []Discount) error {
+	discounts = byCode(discounts)
+	q := `INSERT INTO coupon_redemptions AS r (coupon_code, user_id, redeemed) VALUES ($1, $2, 1)
+	      ON CONFLICT (coupon_code, user_id) DO UPDATE SET redeemed = r.redeemed + 1
+	      WHERE r.redeemed < $3
+	      RETURNING redeemed`
+	for _, d := range discounts {
+		for _, c := range []struct {
+			user  string
+			limit int64
+		}{{"", d.Limits.Global}, {userID, d.Limits.PerUser}} {
+			if c.limit == 0 {
+				continue
+			}
+			var n int64
+			err := tx.QueryRow(ctx, q, d.Code, c.user, c.limit).Scan(&n)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return &CouponLimitError{Code: d.Code, PerUser: c.user != ""}
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// releaseCoupons gives back the redemptions redeemCoupons counted for
+// discounts, locking the counters in the same order. Only coupons with limits
+// have counters, so the others match no rows.
+func releaseCoupons(ctx context.Context, tx pgx.Tx, userID string, discounts []Discount) error {
+	discounts = byCode(discounts)
+	q := `UPDATE coupon_redemptions SET redeemed = redeemed - 1
+	      WHERE coupon_code = $1 AND user_id = $2 AND redeemed > 0`
+	for _, d := range discounts {
+		for _, user := range []string{"", userID} {
+			if _, err := tx.Exec(ctx, q, d.Code, user); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// byCode returns a copy of discounts sorted by coupon code, the order their
+// redemption counters are locked in.
+func byCode(discounts []Discount) []Discount {
+	sorted := slices.Clone(discounts)
+	slices.SortFunc(sorted, func(a, b Discount) int { return strings.Compare(a.Code, b.Code) })
+	return sorted
+}
+
+// insertDiscounts stores discounts as lines 1..n of the order.
+func insertDiscounts(ctx context.Context, tx pgx.Tx, orderID string, discounts []Discount) error {
+	if len(discounts) == 0 {
+		return nil
+	}
+	codes := make([]string, len(discounts))
+	kinds := make([]string, len(discounts))
+	descriptions := make([]string, len(discounts))
+	amounts := make([]int64, len(discounts))
+	skus := make([]string, len(discounts))
+	for i, d := range discounts {
+		codes[i], kinds[i], descriptions[i], amounts[i], skus[i] = d.Code, d.Kind, d.Description, d.AmountCents, d.SKU
+	}
+	q := `INSERT INTO order_discounts (order_id, line, coupon_code, kind, description, amount_cents, sku)
+	      SELECT $1, t.line, t.coupon_code, t.kind, t.description, t.amount_cents, t.sku
+	      FROM unnest($2::text[], $3::text[], $4::text[], $5::bigint[], $6::text[])
+	           WITH ORDINALITY AS t(coupon_code, kind, description, amount_cents, sku, line)`
+	_, err := tx.Exec(ctx, q, orderID, codes, kinds, descriptions, amounts, skus)
+	return err
+}
+
+// insertTaxes stores taxes as lines 1..n of the order.
+func insertTaxes(ctx context.Context, tx pgx.Tx, orderID string, taxes []TaxLine) error {
+	if len(taxes) == 0 {
+		return nil
+	}
+	names := make([]string, len(taxes))
+	rates := make([]string, len(taxes))
+	taxable := make([]int64, len(taxes))
+	amounts := make([]int64, len(taxes))
+	for i, t := range taxes {
+		names[i], rates[i], taxable[i], amounts[i] = t.Name, t.Rate, t.TaxableCents, t.AmountCents
+	}
+	q := `INSERT INTO order_taxes (order_id, line, name, rate, taxable_cents, amount_cents)
+	      SELECT $1, t.line, t.name, t.rate, t.taxable_cents, t.amount_cents
+	      FROM unnest($2::text[], $3::text[], $4::bigint[], $5::bigint[])
+	           WITH ORDINALITY AS t(name, rate, taxable_cents, amount_cents, line)`
+	_, err := tx.Exec(ctx, q, orderID, names, rates, taxable, amounts)
+	return err
+}
+
+// queryer is satisfied by dbConn and pgx.Tx.
+type queryer interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// loadItems returns an order's line items in line order, nil if it has none.
+func loadItems(ctx context.Context, q queryer, orderID string) ([]Item, error) {
+	rows, err := q.Query(ctx, `SELECT sku, name, quantity, unit_price_cents, tax_category FROM order_items WHERE order_id = $1 ORDER BY line`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.SKU, &it.Name, &it.Quantity, &it.UnitPriceCents, &it.TaxCategory); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// loadDiscounts returns an order's discounts in line order, nil if it has
+// none.
+func loadDiscounts(ctx context.Context, q queryer, orderID string) ([]Discount, error) {
+	rows, err := q.Query(ctx, `SELECT coupon_code, kind, description, amount_cents, sku FROM order_discounts WHERE order_id = $1 ORDER BY line`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var discounts []Discount
+	for rows.Next() {
+		var d Discount
+		if err := rows.Scan(&d.Code, &d.Kind, &d.Description, &d.AmountCents, &d.SKU); err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, d)
+	}
+	return discounts, rows.Err()
+}
+
+// loadTaxes returns an order's taxes in line order, nil if it has none.
+func loadTaxes(ctx context.Context, q queryer, orderID string) ([]TaxLine, error) {
+	rows, err := q.Query(ctx, `SELECT name, rate, taxable_cents, amount_cents FROM order_taxes WHERE order_id = $1 ORDER BY line`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var taxes []TaxLine
+	for rows.Next() {
+		var t TaxLine
+		if err := rows.Scan(&t.Name, &t.Rate, &t.TaxableCents, &t.AmountCents); err != nil {
+			return nil, err
+		}
+		taxes = append(taxes, t)
+	}
+	return taxes, rows.Err()
+}
+
+// loadLines fills in o's items, discounts and taxes.
+func loadLines(ctx context.Context, q queryer, o *Order) (err error) {
+	if o.Items, err = loadItems(ctx, q, o.ID); err != nil {
+		return err
+	}
+	if o.Discounts, err = loadDiscounts(ctx, q, o.ID); err != nil {
+		return err
+	}
+	o.Taxes, err = loadTaxes(ctx, q, o.ID)
+	return err
+}
+
+// GetOrder and UpdateStatus report malformed IDs as ErrNotFound, as Postgres
+// would otherwise reject them as invalid UUIDs.
+func (r *PostgresRepository) GetOrder(ctx context.Context, id string) (Order, error) {
+	if uuid.Validate(id) != nil {
+		return Order{}, ErrNotFound
+	}
+	o, err := scanOrder(r.db.QueryRow(ctx, `SELECT `+orderColumns+` FROM orders WHERE id = $1`, id))
+	if err != nil {
+		return Order{}, err
+	}
+	if err := loadLines(ctx, r.db, &o); err != nil {
+		return Order{}, err
+	}
+	return o, nil
+}
+
+func (r *PostgresRepository) OrderByKey(ctx context.Context, key string) (Order, error) {
+	o, err := scanOrder(r.db.QueryRow(ctx, `SELECT `+orderColumns+` FROM orders WHERE idempotency_key = $1`, key))
+	if err != nil {
+		return Order{}, err
+	}
+	if err := loadLines(ctx, r.db, &o); err != nil {
+		return Order{}, err
+	}
+	return o, nil
+}
+
+func (r *PostgresRepository) UpdateStatus(ctx context.Context, id string, next Transition, decide func(Order) (bool, error)) (Order, error) {
+	if uuid.Validate(id) != nil {
+		return Order{}, ErrNotFound
+	}
+	var out Order
+	err := retryTx(ctx, "update_order_status", func() (err error) {
+		out, err = r.updateStatusTx(ctx, id, next, decide)
+		return err
+	})
+	return out, err
+}
+
+func (r *PostgresRepository) updateStatusTx(ctx context.Context, id string, next Transition, decide func(Order) (bool, error)) (Order, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return Order{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	o, err := scanOrder(tx.QueryRow(ctx, `SELECT `+orderColumns+` FROM orders WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return Order{}, err
+	}
+	if err := loadLines(ctx, tx, &o); err != nil {
+		return Order{}, err
+	}
+	if apply, err := decide(o); err != nil || !apply {
+		return o, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE orders SET status = $2, status_reason = $3, version = version + 1 WHERE id = $1`, id, next.Status, next.Reason); err != nil {
+		return Order{}, err
+	}
+	prev := o.Status
+	o.Status, o.StatusReason = next.Status, next.Reason
+	o.Version++
+	if err := recordChange(ctx, tx, o, prev, next.By); err != nil {
+		return Order{}, err
+	}
+	if releasesCoupons(prev, next.Status) {
+		if err := releaseCoupons(ctx, tx, o.UserID, o.Discounts); err != nil {
+			return Order{}, err
+		}
+	}
+	// Last, so the events lock is held only until the commit.
+	if err := recordEvent(ctx, tx, id, o.UserID, prev, next.Status); err != nil {
+		return Order{}, err
+	}
+	return o, tx.Commit(ctx)
+}
+
+// recordChange appends o's current status to its history inside tx.
+func recordChange(ctx context.Context, tx pgx.Tx, o Order, prev string, by Audit) error {
+	q := `INSERT INTO order_history (order_id, version, old_status, new_status, reason, actor, source, trace_id, created_at)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())`
+	_, err := tx.Exec(ctx, q, o.ID, o.Version, prev, o.Status, o.StatusReason, by.Actor, by.Source, by.TraceID)
+	return err
+}
+
+func (r *PostgresRepository) History(ctx context.Context, orderID string) ([]Change, error) {
+	if uuid.Validate(orderID) != nil {
+		return nil, ErrNotFound
+	}
+	var exists bool
+	if err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1)`, orderID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	q := `SELECT version, old_status, new_status, reason, actor, source, trace_id, created_at
+	      FROM order_history WHERE order_id = $1 ORDER BY id`
+	rows, err := r.db.Query(ctx, q, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Change
+	for rows.Next() {
+		c := Change{OrderID: orderID}
+		if err := rows.Scan(&c.Version, &c.OldStatus, &c.NewStatus, &c.Reason, &c.By.Actor, &c.By.Source, &c.By.TraceID, &c.At); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// orderEventsLock is the transaction-level advisory lock recordEvent takes
+// before drawing a seq. Holding it until commit makes events commit in seq
+// order; otherwise a transaction that drew a lower seq but committed later
+// would land behind a reader's "seq > cursor" and be skipped.
+const orderEventsLock = 0x6f726465 // "orde"
+
+// recordEvent appends a status transition inside tx and should be its last
+// statement. Postgres delivers the NOTIFY only if tx commits.
+func recordEvent(ctx context.Context, tx pgx.Tx, orderID, userID, prev, next string) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(orderEventsLock)); err != nil {
+		return err
+	}
+	q := `WITH ev AS (
+		INSERT INTO order_events (order_id, user_id, status, previous_status, created_at)
+		VALUES ($1, $2, $3, $4, now())
+		RETURNING seq, order_id, user_id, status, previous_status, created_at
+	)
+	SELECT pg_notify($5, json_build_object(
+		'seq', seq, 'order_id', order_id, 'user_id', user_id,
+		'status', status, 'previous_status', previous_status, 'occurred_at', created_at)::text)
+	FROM ev`
+	_, err := tx.Exec(ctx, q, orderID, userID, next, prev, orderEventsChannel)
+	return err
+}
+
+func (r *PostgresRepository) Events(ctx context.Context, orderID, userID string, after int64, limit int) ([]Event, error) {
+	q := `SELECT seq, order_id, user_id, status, previous_status, created_at
+	      FROM order_events WHERE order_id = $1 AND seq > $2 ORDER BY seq LIMIT $3`
+	if orderID != "" && uuid.Validate(orderID) != nil {
+		return nil, nil
+	}
+	arg := orderID
+	if orderID == "" {
+		q = `SELECT seq, order_id, user_id, status, previous_status, created_at
+		     FROM order_events WHERE user_id = $1 AND seq > $2 ORDER BY seq LIMIT $3`
+		arg = userID
+	}
+	rows, err := r.db.Query(ctx, q, arg, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Event
+	for rows.Next() {
+		var ev Event
+		if err := rows.Scan(&ev.Seq, &ev.OrderID, &ev.UserID, &ev.Status, &ev.PreviousStatus, &ev.OccurredAt); err != nil {
+			return nil, err
+		}
+		out = append(out, ev)
+	}
+	return out, rows.Err()
+}
+
+func (r *PostgresRepository) LastEventSeq(ctx context.Context) (int64, error) {
+	var head int64
+	err := r.db.QueryRow(ctx, `SELECT COALESCE(max(seq), 0) FROM order_events`).Scan(&head)
+	return head, err
+}
+
+// Listen LISTENs on orderEventsChannel on a dedicated pool connection, which
+// bypasses fault injection.
+func (r *PostgresRepository) Listen(ctx context.Context, started func(int64), publish func(Event)) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "LISTEN "+orderEventsChannel); err != nil {
+		return err
+	}
+	var head int64
+	if err := conn.QueryRow(ctx, `SELECT COALESCE(max(seq), 0) FROM order_events`).Scan(&head); err != nil {
+		return err
+	}
+	started(head)
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			// The connection may still be LISTENing; don't hand it back to the pool.
+			_ = conn.Conn().Close(context.Background())
+			return err
+		}
+		var ev Event
+		if err := json.Unmarshal([]byte(n.Payload), &ev); err != nil {
+			log.Warn().Err(err).Str("payload", n.Payload).Msg("bad order event payload")
+			continue
+		}
+		publish(ev)
+	}
+}
+
+var _ OrderRepository = (*PostgresRepository)(nil)
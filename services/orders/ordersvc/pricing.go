@@ -0,0 +1,155 @@
+package ordersvc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/reliability-lab/gen/catalog"
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/pkg/money"
+	"github.com/reliability-lab/pkg/money/tax"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxItemsPerOrder = 100
+	maxItemQuantity  = 1000
+)
+
+// requestAmount returns the total req asks for: amount, or amount_cents and
+// currency from clients that predate Money. If both are sent they must
+// agree.
+func requestAmount(req *orders.CreateOrderRequest) (money.Money, error) {
+	if req.Amount == nil {
+		m, err := money.New(req.AmountCents, req.Currency)
+		if err != nil {
+			return money.Money{}, rejectField("currency", "currency", "%v", err)
+		}
+		return m, nil
+	}
+	m, err := money.FromProto(req.Amount)
+	if err != nil {
+		// Name the field the client filled in: a client sending both forms
+		// knows currency.
+		field := "amount.currency"
+		if req.Currency != "" && req.Currency == req.Amount.Currency {
+			field = "currency"
+		}
+		return money.Money{}, rejectField("currency", field, "%v", err)
+	}
+	if (req.AmountCents != 0 && req.AmountCents != m.Amount) || (req.Currency != "" && req.Currency != m.Currency) {
+		return money.Money{}, rejectField("amount_mismatch", "amount", "amount_cents %d and currency %q do not match amount %s", req.AmountCents, req.Currency, m)
+	}
+	return m, nil
+}
+
+// priceItems prices req's line items through the catalog and returns them
+// with their subtotal in want's currency. Orders without items keep the
+// client's amount.
+func (s *Server) priceItems(ctx context.Context, req *orders.CreateOrderRequest, want money.Money) ([]Item, money.Money, error) {
+	none := money.Money{}
+	if len(req.Items) == 0 {
+		return nil, want, nil
+	}
+	if s.catalog == nil {
+		return nil, none, status.Error(grpccodes.FailedPrecondition, "line items need the catalog, which is not configured")
+	}
+	if len(req.Items) > maxItemsPerOrder {
+		return nil, none, rejectPricing("invalid_item", "at most %d line items per order", maxItemsPerOrder)
+	}
+	skus := make([]string, len(req.Items))
+	for i, it := range req.Items {
+		if it.Sku == "" || it.Quantity <= 0 || it.Quantity > maxItemQuantity {
+			return nil, none, rejectPricing("invalid_item", "item %d: sku and a quantity between 1 and %d required", i, maxItemQuantity)
+		}
+		skus[i] = it.Sku
+	}
+
+	resp, err := s.catalog.GetSkus(ctx, &catalog.GetSkusRequest{Skus: skus})
+	if err != nil {
+		st := status.Convert(err)
+		if st.Code() == grpccodes.NotFound {
+			return nil, none, rejectPricing("unknown_sku", "%s", st.Message())
+		}
+		return nil, none, status.Errorf(st.Code(), "catalog: %s", st.Message())
+	}
+	if len(resp.Skus) != len(req.Items) {
+		return nil, none, status.Error(grpccodes.Internal, "catalog returned the wrong number of skus")
+	}
+
+	items := make([]Item, len(req.Items))
+	total := money.Money{Currency: want.Currency}
+	for i, it := range req.Items {
+		sku := resp.Skus[i]
+		if sku.Currency != want.Currency {
+			return nil, none, rejectPricing("currency", "sku %s is priced in %s, not %s", sku.Sku, sku.Currency, want.Currency)
+		}
+		line, err := money.Money{Amount: sku.PriceCents, Currency: sku.Currency}.Mul(int64(it.Quantity))
+		if err == nil {
+			total, err = total.Add(line)
+		}
+		if err != nil {
+			return nil, none, rejectPricing("invalid_item", "order total overflows")
+		}
+		category := sku.TaxCategory
+		if category == "" {
+			category = tax.DefaultCategory
+		}
+		items[i] = Item{SKU: sku.Sku, Name: sku.Name, Quantity: it.Quantity, UnitPriceCents: sku.PriceCents, TaxCategory: category}
+	}
+	return items, total, nil
+}
+
+// checkTotal rejects a client amount other than the total an order was
+// priced at; adjusted is set when discounts or taxes are part of it.
+func checkTotal(want, total money.Money, adjusted bool) error {
+	if want.Amount == 0 || want == total {
+		return nil
+	}
+	if adjusted {
+		return rejectPricing("total_mismatch", "amount %s does not match the total %s with discounts and tax", want, total)
+	}
+	return rejectPricing("total_mismatch", "amount %s does not match the catalog total %s", want, total)
+}
+
+func rejectPricing(reason, format string, args ...any) error {
+	orderPricingRejectionsTotal.WithLabelValues(reason).Inc()
+	return status.Errorf(grpccodes.InvalidArgument, format, args...)
+}
+
+// rejectField is rejectPricing for a bad request field, which it names in a
+// BadRequest detail.
+func rejectField(reason, field, format string, args ...any) error {
+	orderPricingRejectionsTotal.WithLabelValues(reason).Inc()
+	desc := fmt.Sprintf(format, args...)
+	st := status.New(grpccodes.InvalidArgument, field+": "+desc)
+	if withDetails, err := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: desc}},
+	}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// itemsProto returns the line items of an order priced in currency.
+func itemsProto(items []Item, currency string) []*orders.OrderItem {
+	out := make([]*orders.OrderItem, len(items))
+	for i, it := range items {
+		unit := money.Money{Amount: it.UnitPriceCents, Currency: currency}
+		// Stored totals were checked for overflow when the order was priced.
+		amount, _ := unit.Mul(int64(it.Quantity))
+		out[i] = &orders.OrderItem{
+			Sku:            it.SKU,
+			Name:           it.Name,
+			Quantity:       it.Quantity,
+			UnitPriceCents: unit.Amount,
+			AmountCents:    amount.Amount,
+			UnitPrice:      unit.Proto(),
+			Amount:         amount.Proto(),
+			TaxCategory:    it.TaxCategory,
+		}
+	}
+	return out
+}
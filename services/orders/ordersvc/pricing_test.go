@@ -0,0 +1,196 @@
+package ordersvc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reliability-lab/gen/catalog"
+	moneypb "github.com/reliability-lab/gen/money"
+	"github.com/reliability-lab/gen/orders"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeCatalog serves GetSkus from a fixed price list.
+type fakeCatalog struct {
+	catalog.CatalogClient
+	skus map[string]*catalog.Sku
+	err  error
+}
+
+func (c *fakeCatalog) GetSkus(_ context.Context, req *catalog.GetSkusRequest, _ ...grpc.CallOption) (*catalog.GetSkusResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	resp := &catalog.GetSkusResponse{}
+	for _, code := range req.Skus {
+		sku, ok := c.skus[code]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "sku %q not found", code)
+		}
+		resp.Skus = append(resp.Skus, sku)
+	}
+	return resp, nil
+}
+
+func newFakeCatalog() *fakeCatalog {
+	return &fakeCatalog{skus: map[string]*catalog.Sku{
+		"TSHIRT-M": {Sku: "TSHIRT-M", Name: "T-shirt, medium", PriceCents: 1299, Currency: "USD", TaxCategory: "clothing"},
+		"MUG-350":  {Sku: "MUG-350", Name: "Mug, 350 ml", PriceCents: 899, Currency: "USD"},
+		"MUG-EUR":  {Sku: "MUG-EUR", Name: "Mug, 350 ml", PriceCents: 799, Currency: "EUR"},
+		"YACHT":    {Sku: "YACHT", Name: "Yacht", PriceCents: 1 << 62, Currency: "USD"},
+		"TOY":      {Sku: "TOY", Name: "Toy", PriceCents: 500, Currency: "USD", TaxCategory: "toys"},
+	}}
+}
+
+func itemsRequest(key string, amount int64, items ...*orders.LineItem) *orders.CreateOrderRequest {
+	return &orders.CreateOrderRequest{UserId: "u1", AmountCents: amount, Currency: "USD", IdempotencyKey: key, Items: items}
+}
+
+func TestCreateOrder_PricesItems(t *testing.T) {
+	repo := NewMemoryRepository()
+	srv := NewServer(repo, WithCatalog(newFakeCatalog()))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	items := []*orders.LineItem{{Sku: "TSHIRT-M", Quantity: 2}, {Sku: "MUG-350", Quantity: 1}}
+	for _, amount := range []int64{0, 3497} {
+		resp, err := srv.CreateOrder(ctx, itemsRequest("priced", amount, items...))
+		if err != nil || resp.AmountCents != 3497 || resp.Currency != "USD" {
+			t.Fatalf("CreateOrder(amount %d) = %v, %v; want a 3497 USD order", amount, resp, err)
+		}
+	}
+
+	resp, _ := srv.CreateOrder(ctx, itemsRequest("priced", 0, items...))
+	got, err := srv.GetOrder(ctx, &orders.GetOrderRequest{OrderId: resp.OrderId})
+	if err != nil || got.AmountCents != 3497 || len(got.Items) != 2 {
+		t.Fatalf("GetOrder = %v, %v; want 3497 with 2 items", got, err)
+	}
+	if it := got.Items[0]; it.Sku != "TSHIRT-M" || it.Name != "T-shirt, medium" || it.Quantity != 2 || it.UnitPriceCents != 1299 || it.AmountCents != 2598 {
+		t.Errorf("first item %v, want 2 x TSHIRT-M at 1299", it)
+	}
+}
+
+func TestCreateOrder_RetryIsNotRepriced(t *testing.T) {
+	cat := newFakeCatalog()
+	srv := NewServer(NewMemoryRepository(), WithCatalog(cat))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	req := itemsRequest("retry", 0, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 1})
+	first, err := srv.CreateOrder(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The retry is answered from the stored order: neither a new price nor
+	// a catalog outage reaches it.
+	cat.skus["TSHIRT-M"].PriceCents = 1499
+	cat.err = status.Error(codes.Unavailable, "connection refused")
+	retry, err := srv.CreateOrder(ctx, req)
+	if err != nil || !proto.Equal(retry, first) {
+		t.Errorf("retry = %v, %v; want %v", retry, err, first)
+	}
+}
+
+// TestCreateOrder_RetryMustMatch reuses a key for a different order: only a
+// request that could have placed the stored order gets it back.
+func TestCreateOrder_RetryMustMatch(t *testing.T) {
+	srv := NewServer(NewMemoryRepository(), WithCatalog(newFakeCatalog()))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	first, err := srv.CreateOrder(ctx, itemsRequest("reused", 2598, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retry, err := srv.CreateOrder(ctx, itemsRequest("reused", 0, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 2})); err != nil || retry.OrderId != first.OrderId {
+		t.Errorf("retry without an amount = %v, %v; want order %s", retry, err, first.OrderId)
+	}
+	for name, req := range map[string]*orders.CreateOrderRequest{
+		"quantity": itemsRequest("reused", 0, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 3}),
+		"sku":      itemsRequest("reused", 0, &orders.LineItem{Sku: "TSHIRT-S", Quantity: 2}),
+		"no items": itemsRequest("reused", 2598),
+		"amount":   itemsRequest("reused", 1299, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 2}),
+		"coupon":   couponRequest("reused", []string{"WELCOME10"}, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 2}),
+		"tax":      taxRequest("reused", "US-CA", 0, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 2}),
+		"currency": {UserId: "u1", AmountCents: 2598, Currency: "EUR", IdempotencyKey: "reused", Items: []*orders.LineItem{{Sku: "TSHIRT-M", Quantity: 2}}},
+		"user":     {UserId: "u2", AmountCents: 2598, Currency: "USD", IdempotencyKey: "reused", Items: []*orders.LineItem{{Sku: "TSHIRT-M", Quantity: 2}}},
+	} {
+		if resp, err := srv.CreateOrder(ctx, req); status.Code(err) != codes.AlreadyExists {
+			t.Errorf("%s: CreateOrder = %v, %v; want AlreadyExists", name, resp, err)
+		}
+	}
+}
+
+func TestCreateOrder_RejectsBadItems(t *testing.T) {
+	broken := newFakeCatalog()
+	broken.err = status.Error(codes.Unavailable, "connection refused")
+
+	tests := []struct {
+		name    string
+		catalog catalog.CatalogClient
+		req     *orders.CreateOrderRequest
+		code    codes.Code
+	}{
+		{"total mismatch", newFakeCatalog(), itemsRequest("k", 1299, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 2}), codes.InvalidArgument},
+		{"unknown sku", newFakeCatalog(), itemsRequest("k", 0, &orders.LineItem{Sku: "HAT", Quantity: 1}), codes.InvalidArgument},
+		{"zero quantity", newFakeCatalog(), itemsRequest("k", 0, &orders.LineItem{Sku: "TSHIRT-M"}), codes.InvalidArgument},
+		{"other currency", newFakeCatalog(), itemsRequest("k", 0, &orders.LineItem{Sku: "MUG-EUR", Quantity: 1}), codes.InvalidArgument},
+		{"overflow", newFakeCatalog(), itemsRequest("k", 0, &orders.LineItem{Sku: "YACHT", Quantity: 2}), codes.InvalidArgument},
+		{"catalog down", broken, itemsRequest("k", 0, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 1}), codes.Unavailable},
+		{"no catalog", nil, itemsRequest("k", 0, &orders.LineItem{Sku: "TSHIRT-M", Quantity: 1}), codes.FailedPrecondition},
+		{"no items or amount", newFakeCatalog(), itemsRequest("k", 0), codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			var opts []Option
+			if tt.catalog != nil {
+				opts = append(opts, WithCatalog(tt.catalog))
+			}
+			srv := NewServer(repo, opts...)
+			t.Cleanup(srv.Close)
+			if _, err := srv.CreateOrder(context.Background(), tt.req); status.Code(err) != tt.code {
+				t.Fatalf("CreateOrder error %v, want %s", err, tt.code)
+			}
+			if head, _ := repo.LastEventSeq(context.Background()); head != 0 {
+				t.Errorf("a rejected order was stored")
+			}
+		})
+	}
+}
+
+func TestCreateOrder_Money(t *testing.T) {
+	srv := NewServer(NewMemoryRepository(), WithCatalog(newFakeCatalog()))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	// A client that only sends amount.
+	req := &orders.CreateOrderRequest{UserId: "u1", IdempotencyKey: "money", Amount: &moneypb.Money{Currency: "USD"},
+		Items: []*orders.LineItem{{Sku: "TSHIRT-M", Quantity: 2}}}
+	resp, err := srv.CreateOrder(ctx, req)
+	want := &moneypb.Money{Currency: "USD", MinorUnits: 2598}
+	if err != nil || !proto.Equal(resp.Charge, want) || resp.AmountCents != 2598 || resp.Currency != "USD" {
+		t.Fatalf("CreateOrder = %v, %v; want 2598 USD in both forms", resp, err)
+	}
+	got, err := srv.GetOrder(ctx, &orders.GetOrderRequest{OrderId: resp.OrderId})
+	if err != nil || !proto.Equal(got.Total, want) || got.AmountCents != 2598 || got.Settlement != nil {
+		t.Fatalf("GetOrder = %v, %v; want a 2598 USD total", got, err)
+	}
+	if it := got.Items[0]; !proto.Equal(it.UnitPrice, &moneypb.Money{Currency: "USD", MinorUnits: 1299}) || !proto.Equal(it.Amount, want) {
+		t.Errorf("item %v, want 2 x 1299 USD", it)
+	}
+
+	for name, req := range map[string]*orders.CreateOrderRequest{
+		"amounts differ":    {UserId: "u1", IdempotencyKey: "k1", AmountCents: 100, Currency: "USD", Amount: &moneypb.Money{Currency: "USD", MinorUnits: 200}},
+		"currencies differ": {UserId: "u1", IdempotencyKey: "k2", AmountCents: 100, Currency: "USD", Amount: &moneypb.Money{Currency: "EUR", MinorUnits: 100}},
+		"bad currency":      {UserId: "u1", IdempotencyKey: "k3", Amount: &moneypb.Money{Currency: "usd", MinorUnits: 100}},
+		"negative":          {UserId: "u1", IdempotencyKey: "k4", Amount: &moneypb.Money{Currency: "USD", MinorUnits: -100}},
+	} {
+		if _, err := srv.CreateOrder(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: %v, want InvalidArgument", name, err)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package ordersvc
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/pkg/money"
+	"gopkg.in/yaml.v3"
+)
+
+// Coupon kinds.
+const (
+	couponPercentage  = "PERCENTAGE"
+	couponFixedAmount = "FIXED_AMOUNT"
+	couponBuyXGetY    = "BUY_X_GET_Y"
+)
+
+const maxCouponsPerOrder = 5
+
+// Coupon is a promotion redeemed with its code.
+type Coupon struct {
+	Code        string
+	Description string
+	Kind        string
+	// PercentOff, for PERCENTAGE, is taken off the subtotal, rounded down.
+	PercentOff int64
+	// AmountOff, for FIXED_AMOUNT, is taken off orders in its currency.
+	AmountOff money.Money
+	// For BUY_X_GET_Y, Get of every Buy+Get units of SKU are free.
+	SKU      string
+	Buy, Get int32
+	// ValidFrom and ValidUntil bound when the coupon can be redeemed;
+	// ValidUntil is exclusive and zero values are unbounded.
+	ValidFrom, ValidUntil time.Time
+	Limits                CouponLimits
+}
+
+// CouponLimits caps how often a coupon is redeemed; 0 is no limit.
+type CouponLimits struct {
+	PerUser int64
+	Global  int64
+}
+
+// Promotions is an immutable set of coupons.
+type Promotions struct {
+	byCode map[string]Coupon
+}
+
+//go:embed promotions.yaml
+var defaultPromotions []byte
+
+// DefaultPromotions returns the coupons built into the binary.
+func DefaultPromotions() *Promotions {
+	p, err := parsePromotions(defaultPromotions)
+	if err != nil {
+		panic("ordersvc: built-in promotions: " + err.Error())
+	}
+	return p
+}
+
+// LoadPromotions reads a promotions file with the layout of promotions.yaml.
+func LoadPromotions(path string) (*Promotions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parsePromotions(data)
+	if err != nil {
+		return nil, fmt.Errorf("promotions %s: %w", path, err)
+	}
+	return p, nil
+}
+
+func parsePromotions(data []byte) (*Promotions, error) {
+	var f struct {
+		Coupons []struct {
+			Code        string `yaml:"code"`
+			Description string `yaml:"description"`
+			PercentOff  int64  `yaml:"percent_off"`
+			AmountOff   *struct {
+				Currency   string `yaml:"currency"`
+				MinorUnits int64  `yaml:"minor_units"`
+			} `yaml:"amount_off"`
+			BuyXGetY *struct {
+				SKU string `yaml:"sku"`
+				Buy int32  `yaml:"buy"`
+				Get int32  `yaml:"get"`
+			} `yaml:"buy_x_get_y"`
+			ValidFrom    time.Time `yaml:"valid_from"`
+			ValidUntil   time.Time `yaml:"valid_until"`
+			PerUserLimit int64     `yaml:"per_user_limit"`
+			GlobalLimit  int64     `yaml:"global_limit"`
+		} `yaml:"coupons"`
+	}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	p := &Promotions{byCode: make(map[string]Coupon)}
+	var errs []error
+	for i, fc := range f.Coupons {
+		c := Coupon{
+			Code:        normalizeCoupon(fc.Code),
+			Description: fc.Description,
+			ValidFrom:   fc.ValidFrom,
+			ValidUntil:  fc.ValidUntil,
+			Limits:      CouponLimits{PerUser: fc.PerUserLimit, Global: fc.GlobalLimit},
+		}
+		if c.Code == "" {
+			errs = append(errs, fmt.Errorf("coupon %d: code required", i))
+			continue
+		}
+		if _, dup := p.byCode[c.Code]; dup {
+			errs = append(errs, fmt.Errorf("coupon %s: duplicate", c.Code))
+			continue
+		}
+		var kinds int
+		if fc.PercentOff != 0 {
+			kinds++
+			c.Kind, c.PercentOff = couponPercentage, fc.PercentOff
+			if c.PercentOff < 0 || c.PercentOff > 100 {
+				errs = append(errs, fmt.Errorf("coupon %s: percent_off must be between 1 and 100", c.Code))
+			}
+		}
+		if a := fc.AmountOff; a != nil {
+			kinds++
+			c.Kind = couponFixedAmount
+			m, err := money.New(a.MinorUnits, a.Currency)
+			if err == nil && m.Amount <= 0 {
+				err = errors.New("minor_units must be positive")
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("coupon %s: amount_off: %w", c.Code, err))
+			}
+			c.AmountOff = m
+		}
+		if b := fc.BuyXGetY; b != nil {
+			kinds++
+			c.Kind, c.SKU, c.Buy, c.Get = couponBuyXGetY, b.SKU, b.Buy, b.Get
+			if b.SKU == "" || b.Buy <= 0 || b.Get <= 0 {
+				errs = append(errs, fmt.Errorf("coupon %s: buy_x_get_y needs a sku and positive buy and get", c.Code))
+			}
+		}
+		if kinds != 1 {
+			errs = append(errs, fmt.Errorf("coupon %s: exactly one of percent_off, amount_off and buy_x_get_y required", c.Code))
+		}
+		if !c.ValidUntil.IsZero() && !c.ValidUntil.After(c.ValidFrom) {
+			errs = append(errs, fmt.Errorf("coupon %s: valid_until must be after valid_from", c.Code))
+		}
+		if c.Limits.PerUser < 0 || c.Limits.Global < 0 {
+			errs = append(errs, fmt.Errorf("coupon %s: negative limit", c.Code))
+		}
+		p.byCode[c.Code] = c
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// normalizeCoupon makes codes case-insensitive.
+func normalizeCoupon(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// WithPromotions redeems coupon codes from p. Without it, orders with coupon
+// codes are rejected.
+func WithPromotions(p *Promotions) Option {
+	return func(s *Server) { s.promotions = p }
+}
+
+// applyCoupons returns the discounts codes give on an order with items and
+// subtotal, and the total after them. Each coupon is worked out on the
+// subtotal; together they never take the total below zero. Usage limits are
+// left to the repository, which checks them as it stores the order and gives
+// the redemptions back if the order fails or is cancelled. CreateOrder looks
+// a retry up by its idempotency key before calling this, so a retry after a
+// coupon's window has closed still gets its order.
+func (s *Server) applyCoupons(codes []string, items []Item, subtotal money.Money, now time.Time) ([]Discount, money.Money, error) {
+	if len(codes) == 0 {
+		return nil, subtotal, nil
+	}
+	none := money.Money{}
+	if s.promotions == nil {
+		return nil, none, rejectField("coupon_invalid", "coupon_codes", "coupons are not enabled")
+	}
+	if len(items) == 0 {
+		return nil, none, rejectField("coupon_invalid", "coupon_codes", "coupons need line items")
+	}
+	if len(codes) > maxCouponsPerOrder {
+		return nil, none, rejectField("coupon_invalid", "coupon_codes", "at most %d coupons per order", maxCouponsPerOrder)
+	}
+	discounts := make([]Discount, 0, len(codes))
+	total := subtotal
+	for _, code := range codes {
+		c, ok := s.promotions.byCode[normalizeCoupon(code)]
+		switch {
+		case !ok:
+			return nil, none, rejectField("coupon_invalid", "coupon_codes", "unknown coupon %q", code)
+		case slices.ContainsFunc(discounts, func(d Discount) bool { return d.Code == c.Code }):
+			return nil, none, rejectField("coupon_invalid", "coupon_codes", "coupon %s given twice", c.Code)
+		case now.Before(c.ValidFrom):
+			return nil, none, rejectField("coupon_invalid", "coupon_codes", "coupon %s is not valid until %s", c.Code, c.ValidFrom.UTC().Format(time.RFC3339))
+		case !c.ValidUntil.IsZero() && !now.Before(c.ValidUntil):
+			return nil, none, rejectField("coupon_invalid", "coupon_codes", "coupon %s expired at %s", c.Code, c.ValidUntil.UTC().Format(time.RFC3339))
+		}
+		off, err := c.discount(items, subtotal)
+		if err != nil {
+			return nil, none, rejectField("coupon_invalid", "coupon_codes", "coupon %s: %v", c.Code, err)
+		}
+		if off.Amount > total.Amount {
+			off.Amount = total.Amount
+		}
+		// off is at most total, so this cannot overflow.
+		total, _ = total.Sub(off)
+		discounts = append(discounts, Discount{Code: c.Code, Kind: c.Kind, Description: c.Description, AmountCents: off.Amount, SKU: c.SKU, Limits: c.Limits})
+	}
+	return discounts, total, nil
+}
+
+// discount returns what c takes off an order with items and subtotal.
+func (c Coupon) discount(items []Item, subtotal money.Money) (money.Money, error) {
+	switch c.Kind {
+	case couponPercentage:
+		return subtotal.Scale(big.NewRat(c.PercentOff, 100), money.Down)
+	case couponFixedAmount:
+		if c.AmountOff.Currency != subtotal.Currency {
+			return money.Money{}, fmt.Errorf("only for orders in %s", c.AmountOff.Currency)
+		}
+		return c.AmountOff, nil
+	case couponBuyXGetY:
+		var qty int64
+		var unit int64
+		for _, it := range items {
+			if it.SKU == c.SKU {
+				qty += int64(it.Quantity)
+				unit = it.UnitPriceCents
+			}
+		}
+		free := qty / int64(c.Buy+c.Get) * int64(c.Get)
+		if free == 0 {
+			return money.Money{}, fmt.Errorf("needs %d x %s", c.Buy+c.Get, c.SKU)
+		}
+		return money.Money{Amount: unit, Currency: subtotal.Currency}.Mul(free)
+	}
+	return money.Money{}, fmt.Errorf("unknown kind %q", c.Kind)
+}
+
+// discountsProto returns the discounts of an order priced in currency.
+func discountsProto(discounts []Discount, currency string) []*orders.OrderDiscount {
+	out := make([]*orders.OrderDiscount, len(discounts))
+	for i, d := range discounts {
+		out[i] = &orders.OrderDiscount{
+			CouponCode:  d.Code,
+			Kind:        d.Kind,
+			Description: d.Description,
+			Amount:      money.Money{Amount: d.AmountCents, Currency: currency}.Proto(),
+			Sku:         d.SKU,
+		}
+	}
+	return out
+}
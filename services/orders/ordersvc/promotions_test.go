@@ -0,0 +1,210 @@
+package ordersvc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/reliability-lab/gen/orders"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testPromotions(t *testing.T) *Promotions {
+	t.Helper()
+	p, err := parsePromotions([]byte(`
+coupons:
+  - {code: TEN, description: 10% off, percent_off: 10}
+  - {code: FIVE, amount_off: {currency: USD, minor_units: 500}}
+  - {code: EURO, amount_off: {currency: EUR, minor_units: 500}}
+  - {code: HUGE, amount_off: {currency: USD, minor_units: 1000000}}
+  - {code: MUGS, buy_x_get_y: {sku: MUG-350, buy: 2, get: 1}}
+  - {code: OLD, percent_off: 50, valid_until: 2000-01-01T00:00:00Z}
+  - {code: SOON, percent_off: 50, valid_from: 2999-01-01T00:00:00Z}
+  - {code: ONCE, percent_off: 10, per_user_limit: 1}
+  - {code: LAST, percent_off: 10, global_limit: 3}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func couponRequest(key string, codes []string, items ...*orders.LineItem) *orders.CreateOrderRequest {
+	req := itemsRequest(key, 0, items...)
+	req.CouponCodes = codes
+	return req
+}
+
+func TestDefaultPromotions(t *testing.T) {
+	if p := DefaultPromotions(); len(p.byCode) == 0 {
+		t.Fatal("no built-in coupons")
+	}
+}
+
+func TestParsePromotions_Invalid(t *testing.T) {
+	_, err := parsePromotions([]byte(`
+coupons:
+  - {code: A, percent_off: 10}
+  - {code: a, percent_off: 10}
+  - {code: B, percent_off: 120}
+  - {code: C, percent_off: 10, amount_off: {currency: USD, minor_units: 100}}
+  - {code: D, amount_off: {currency: usd, minor_units: 100}}
+  - {code: E, buy_x_get_y: {sku: MUG-350, buy: 0, get: 1}}
+  - {code: F, percent_off: 10, valid_from: 2026-02-01T00:00:00Z, valid_until: 2026-01-01T00:00:00Z}
+  - {code: G, percent_off: 10, global_limit: -1}
+  - {code: H}
+`))
+	if err == nil {
+		t.Fatal("parsePromotions accepted invalid coupons")
+	}
+	for _, want := range []string{"coupon A: duplicate", "coupon B: percent_off", "coupon C: exactly one", "coupon D: amount_off",
+		"coupon E: buy_x_get_y", "coupon F: valid_until", "coupon G: negative limit", "coupon H: exactly one"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestCreateOrder_Coupons(t *testing.T) {
+	shirtsAndMug := []*orders.LineItem{{Sku: "TSHIRT-M", Quantity: 2}, {Sku: "MUG-350", Quantity: 1}}
+	tests := []struct {
+		name      string
+		codes     []string
+		items     []*orders.LineItem
+		total     int64
+		discounts []int64
+	}{
+		{"percentage rounds down", []string{"TEN"}, shirtsAndMug, 3148, []int64{349}},
+		{"codes are case-insensitive", []string{" ten "}, shirtsAndMug, 3148, []int64{349}},
+		{"fixed amount", []string{"FIVE"}, shirtsAndMug, 2997, []int64{500}},
+		{"both on the subtotal", []string{"TEN", "FIVE"}, shirtsAndMug, 2648, []int64{349, 500}},
+		{"fixed amount capped", []string{"HUGE"}, shirtsAndMug, 0, []int64{3497}},
+		{"buy 2 get 1", []string{"MUGS"}, []*orders.LineItem{{Sku: "MUG-350", Quantity: 3}}, 1798, []int64{899}},
+		{"buy 2 get 1 twice", []string{"MUGS"}, []*orders.LineItem{{Sku: "MUG-350", Quantity: 4}, {Sku: "MUG-350", Quantity: 3}}, 4495, []int64{1798}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer(NewMemoryRepository(), WithCatalog(newFakeCatalog()), WithPromotions(testPromotions(t)))
+			t.Cleanup(srv.Close)
+			ctx := context.Background()
+
+			resp, err := srv.CreateOrder(ctx, couponRequest("coupons", tt.codes, tt.items...))
+			if err != nil || resp.Charge.GetMinorUnits() != tt.total || len(resp.Discounts) != len(tt.discounts) {
+				t.Fatalf("CreateOrder = %v, %v; want total %d with %d discounts", resp, err, tt.total, len(tt.discounts))
+			}
+			got, err := srv.GetOrder(ctx, &orders.GetOrderRequest{OrderId: resp.OrderId})
+			if err != nil {
+				t.Fatal(err)
+			}
+			subtotal := tt.total
+			for i, d := range got.Discounts {
+				if d.CouponCode != strings.TrimSpace(strings.ToUpper(tt.codes[i])) || d.Amount.GetMinorUnits() != tt.discounts[i] || d.Amount.GetCurrency() != "USD" {
+					t.Errorf("discount %d = %v, want %s for %d USD", i, d, tt.codes[i], tt.discounts[i])
+				}
+				subtotal += tt.discounts[i]
+			}
+			if got.Total.GetMinorUnits() != tt.total || got.Subtotal.GetMinorUnits() != subtotal {
+				t.Errorf("GetOrder total %v and subtotal %v, want %d and %d", got.Total, got.Subtotal, tt.total, subtotal)
+			}
+		})
+	}
+}
+
+func TestCreateOrder_RejectsCoupons(t *testing.T) {
+	shirt := &orders.LineItem{Sku: "TSHIRT-M", Quantity: 1}
+	mismatch := couponRequest("k", []string{"TEN"}, shirt)
+	mismatch.AmountCents = 1299
+	noItems := couponRequest("k", []string{"TEN"})
+	noItems.AmountCents = 1299
+	tests := []struct {
+		name       string
+		promotions bool
+		req        *orders.CreateOrderRequest
+		field      string
+	}{
+		{"unknown", true, couponRequest("k", []string{"NOPE"}, shirt), "coupon_codes"},
+		{"expired", true, couponRequest("k", []string{"OLD"}, shirt), "coupon_codes"},
+		{"not yet valid", true, couponRequest("k", []string{"SOON"}, shirt), "coupon_codes"},
+		{"twice", true, couponRequest("k", []string{"TEN", "ten"}, shirt), "coupon_codes"},
+		{"other currency", true, couponRequest("k", []string{"EURO"}, shirt), "coupon_codes"},
+		{"nothing free", true, couponRequest("k", []string{"MUGS"}, &orders.LineItem{Sku: "MUG-350", Quantity: 2}), "coupon_codes"},
+		{"too many", true, couponRequest("k", []string{"TEN", "FIVE", "HUGE", "MUGS", "ONCE", "LAST"}, shirt), "coupon_codes"},
+		{"no items", true, noItems, "coupon_codes"},
+		{"not enabled", false, couponRequest("k", []string{"TEN"}, shirt), "coupon_codes"},
+		{"undiscounted amount", true, mismatch, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			opts := []Option{WithCatalog(newFakeCatalog())}
+			if tt.promotions {
+				opts = append(opts, WithPromotions(testPromotions(t)))
+			}
+			srv := NewServer(repo, opts...)
+			t.Cleanup(srv.Close)
+			_, err := srv.CreateOrder(context.Background(), tt.req)
+			st := status.Convert(err)
+			if st.Code() != codes.InvalidArgument {
+				t.Fatalf("CreateOrder error %v, want InvalidArgument", err)
+			}
+			if tt.field != "" && !hasFieldViolation(st, tt.field) {
+				t.Errorf("error details %v, want a violation on %s", st.Details(), tt.field)
+			}
+			if head, _ := repo.LastEventSeq(context.Background()); head != 0 {
+				t.Errorf("a rejected order was stored")
+			}
+		})
+	}
+}
+
+func TestCreateOrder_CouponLimits(t *testing.T) {
+	srv := NewServer(NewMemoryRepository(), WithCatalog(newFakeCatalog()), WithPromotions(testPromotions(t)))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+	shirt := &orders.LineItem{Sku: "TSHIRT-M", Quantity: 1}
+
+	first, err := srv.CreateOrder(ctx, couponRequest("once-1", []string{"ONCE"}, shirt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retry, err := srv.CreateOrder(ctx, couponRequest("once-1", []string{"ONCE"}, shirt)); err != nil || retry.OrderId != first.OrderId {
+		t.Errorf("retried CreateOrder = %v, %v; want the first order", retry, err)
+	}
+	_, err = srv.CreateOrder(ctx, couponRequest("once-2", []string{"ONCE"}, shirt))
+	if st := status.Convert(err); st.Code() != codes.InvalidArgument || !hasFieldViolation(st, "coupon_codes") {
+		t.Errorf("second redemption by u1: %v, want a violation on coupon_codes", err)
+	}
+	other := couponRequest("once-3", []string{"ONCE"}, shirt)
+	other.UserId = "u2"
+	if _, err := srv.CreateOrder(ctx, other); err != nil {
+		t.Errorf("redemption by u2: %v", err)
+	}
+
+	// LAST can be redeemed 3 times in all, however many orders race for it.
+	const n = 10
+	var created atomic.Int32
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := couponRequest(fmt.Sprintf("last-%d", i), []string{"LAST"}, shirt)
+			req.UserId = fmt.Sprintf("racer-%d", i)
+			_, err := srv.CreateOrder(ctx, req)
+			switch {
+			case err == nil:
+				created.Add(1)
+			case status.Code(err) != codes.InvalidArgument:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if created.Load() != 3 {
+		t.Errorf("%d orders redeemed LAST, want 3", created.Load())
+	}
+}
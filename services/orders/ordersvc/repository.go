@@ -0,0 +1,186 @@
+package ordersvc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/reliability-lab/pkg/money"
+)
+
+// ErrNotFound is returned by an OrderRepository for unknown order IDs.
+var ErrNotFound = errors.New("order not found")
+
+// CouponLimitError is returned by CreateOrder when a coupon has been
+// redeemed as often as its limits allow.
+type CouponLimitError struct {
+	Code string
+	// PerUser is set when the user's limit was reached, not the global one.
+	PerUser bool
+}
+
+func (e *CouponLimitError) Error() string {
+	if e.PerUser {
+		return "coupon " + e.Code + " has been used as often as allowed per user"
+	}
+	return "coupon " + e.Code + " is no longer available"
+}
+
+// Order is a stored order. AmountCents is in minor units of Currency.
+// Orders created from line items carry them in Items; AmountCents is then
+// their total less Discounts plus Taxes.
+type Order struct {
+	ID             string
+	UserID         string
+	AmountCents    int64
+	Currency       string
+	Status         string
+	IdempotencyKey string
+	CreatedAt      time.Time
+	Items          []Item
+	Discounts      []Discount
+	// TaxJurisdiction is where the order is taxed, under TaxRulesVersion of
+	// the rules; both are empty for untaxed orders.
+	TaxJurisdiction string
+	TaxRulesVersion string
+	Taxes           []TaxLine
+	// StatusReason is the reason code given with the current status.
+	StatusReason string
+	// Version is 1 for a new order and incremented by every write; the
+	// repository sets it.
+	Version int64
+	// Settlement is set for orders charged in another currency than they
+	// were priced in.
+	Settlement *Settlement
+}
+
+// Settlement is what an order priced in one currency is charged in another.
+type Settlement struct {
+	Amount money.Money
+	// FXRate is the applied rate, units of Amount's currency per unit of the
+	// order's, as a decimal string.
+	FXRate string
+	// FXRateAsOf is when the rate was published.
+	FXRateAsOf time.Time
+}
+
+// Total returns the order total in the currency it is priced in.
+func (o Order) Total() money.Money {
+	return money.Money{Amount: o.AmountCents, Currency: o.Currency}
+}
+
+// Subtotal returns the order total before discounts and tax: the sum of its
+// items.
+func (o Order) Subtotal() money.Money {
+	// The items were priced without overflow, so every step fits.
+	sub, _ := o.Total().Sub(o.Tax())
+	for _, d := range o.Discounts {
+		sub, _ = sub.Add(money.Money{Amount: d.AmountCents, Currency: o.Currency})
+	}
+	return sub
+}
+
+// Tax returns the sum of the order's taxes.
+func (o Order) Tax() money.Money {
+	tax := money.Money{Currency: o.Currency}
+	for _, t := range o.Taxes {
+		// Taxes were added to the total without overflow.
+		tax, _ = tax.Add(money.Money{Amount: t.AmountCents, Currency: o.Currency})
+	}
+	return tax
+}
+
+// Charge returns what the order is charged.
+func (o Order) Charge() money.Money {
+	if o.Settlement != nil {
+		return o.Settlement.Amount
+	}
+	return o.Total()
+}
+
+// Transition is a status change requested through UpdateStatus.
+type Transition struct {
+	Status string
+	// Reason is stored as the order's StatusReason.
+	Reason string
+	// By is recorded in the order's history.
+	By Audit
+}
+
+// releasesCoupons reports whether an order moving from prev to next gives
+// back its coupon redemptions: orders that failed or were cancelled do not
+// count against a coupon's limits.
+func releasesCoupons(prev, next string) bool {
+	released := func(s string) bool {
+		return s == statusPaymentFailed || s == statusOutOfStock || s == statusCancelled
+	}
+	return released(next) && !released(prev)
+}
+
+// Item is a line item priced from the catalog when the order was created.
+type Item struct {
+	SKU            string
+	Name           string
+	Quantity       int32
+	UnitPriceCents int64
+	TaxCategory    string
+}
+
+// Discount is what a coupon took off an order, in minor units of the
+// order's currency.
+type Discount struct {
+	Code        string
+	Kind        string
+	Description string
+	AmountCents int64
+	// SKU is the item a BUY_X_GET_Y coupon made free.
+	SKU string
+	// Limits are checked when the order is created and not stored.
+	Limits CouponLimits
+}
+
+// TaxLine is a tax levied on an order, in minor units of its currency.
+type TaxLine struct {
+	Name string
+	// Rate is a decimal string: "0.0725" is 7.25%.
+	Rate string
+	// TaxableCents is the part of the order, after discounts, Rate applied
+	// to.
+	TaxableCents int64
+	AmountCents  int64
+}
+
+// OrderRepository stores orders, their status events and their history.
+// Every change to an order appends an Event and a Change in the same
+// transaction.
+type OrderRepository interface {
+	// CreateOrder stores o, its items, an Event for its initial status and a
+	// Change made by by, unless an order with the same idempotency key
+	// exists: then it returns that order and created is false. It redeems
+	// o's coupons in the same transaction, failing with a *CouponLimitError
+	// instead of going over their limits.
+	CreateOrder(ctx context.Context, o Order, by Audit) (out Order, created bool, err error)
+	// GetOrder returns ErrNotFound for an unknown id.
+	GetOrder(ctx context.Context, id string) (Order, error)
+	// OrderByKey returns the order created with idempotency key, and
+	// ErrNotFound if there is none.
+	OrderByKey(ctx context.Context, key string) (Order, error)
+	// UpdateStatus locks the order, asks decide whether to apply next and, if
+	// so, stores the change and its Event. decide's error is returned as is.
+	// An order that fails or is cancelled gives back its coupon redemptions
+	// in the same transaction.
+	UpdateStatus(ctx context.Context, id string, next Transition, decide func(current Order) (apply bool, err error)) (Order, error)
+	// History returns an order's changes, oldest first, and ErrNotFound for
+	// an unknown id.
+	History(ctx context.Context, orderID string) ([]Change, error)
+	// Events returns up to limit events after seq for an order or, with
+	// orderID empty, a user, oldest first.
+	Events(ctx context.Context, orderID, userID string, after int64, limit int) ([]Event, error)
+	// LastEventSeq returns the newest event's sequence, 0 if there is none.
+	LastEventSeq(ctx context.Context) (int64, error)
+	// Listen subscribes to the events committed from now on by every replica
+	// sharing the store, calls started with the newest sequence at that point
+	// and then publish for each event until ctx is done. It returns an error
+	// when the feed breaks; events are missed until it is called again.
+	Listen(ctx context.Context, started func(head int64), publish func(Event)) error
+}
@@ -0,0 +1,612 @@
+package ordersvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/reliability-lab/pkg/money"
+)
+
+// testRepository is the conformance suite every OrderRepository must pass.
+// newRepo returns an empty repository.
+func testRepository(t *testing.T, newRepo func(t *testing.T) OrderRepository) {
+	t.Run("CreateAndGet", func(t *testing.T) { testCreateAndGet(t, newRepo(t)) })
+	t.Run("CreateIsIdempotent", func(t *testing.T) { testCreateIsIdempotent(t, newRepo(t)) })
+	t.Run("Items", func(t *testing.T) { testItems(t, newRepo(t)) })
+	t.Run("Settlement", func(t *testing.T) { testSettlement(t, newRepo(t)) })
+	t.Run("Discounts", func(t *testing.T) { testDiscounts(t, newRepo(t)) })
+	t.Run("CouponLimits", func(t *testing.T) { testCouponLimits(t, newRepo(t)) })
+	t.Run("ReleasesCouponRedemptions", func(t *testing.T) { testReleasesCouponRedemptions(t, newRepo(t)) })
+	t.Run("Taxes", func(t *testing.T) { testTaxes(t, newRepo(t)) })
+	t.Run("LargeAmount", func(t *testing.T) { testLargeAmount(t, newRepo(t)) })
+	t.Run("ConcurrentCreatesOneOrder", func(t *testing.T) { testConcurrentCreates(t, newRepo(t)) })
+	t.Run("UpdateStatus", func(t *testing.T) { testUpdateStatus(t, newRepo(t)) })
+	t.Run("ConcurrentUpdatesSerialize", func(t *testing.T) { testConcurrentUpdates(t, newRepo(t)) })
+	t.Run("History", func(t *testing.T) { testHistory(t, newRepo(t)) })
+	t.Run("Events", func(t *testing.T) { testEvents(t, newRepo(t)) })
+	t.Run("EventCursorSkipsNothing", func(t *testing.T) { testEventCursor(t, newRepo(t)) })
+	t.Run("Listen", func(t *testing.T) { testListen(t, newRepo(t)) })
+}
+
+var testAudit = Audit{Actor: "user:u1", Source: "gateway", TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"}
+
+func newOrder(userID, key string) Order {
+	return Order{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		AmountCents:    1299,
+		Currency:       "USD",
+		Status:         statusCreated,
+		IdempotencyKey: key,
+		CreatedAt:      time.Now().UTC().Truncate(time.Microsecond),
+		// The repository stores new orders at version 1 whatever is passed.
+		Version: 1,
+	}
+}
+
+func mustCreate(t *testing.T, repo OrderRepository, o Order) Order {
+	t.Helper()
+	out, _, err := repo.CreateOrder(context.Background(), o, testAudit)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	return out
+}
+
+// sameOrder compares orders, times by instant: Postgres returns them in the
+// local zone.
+func sameOrder(a, b Order) bool {
+	ok := a.CreatedAt.Equal(b.CreatedAt) && slices.Equal(a.Items, b.Items)
+	a.CreatedAt, b.CreatedAt = time.Time{}, time.Time{}
+	a.Items, b.Items = nil, nil
+	if a.Settlement != nil && b.Settlement != nil {
+		as, bs := *a.Settlement, *b.Settlement
+		ok = ok && as.FXRateAsOf.Equal(bs.FXRateAsOf)
+		as.FXRateAsOf, bs.FXRateAsOf = time.Time{}, time.Time{}
+		a.Settlement, b.Settlement = &as, &bs
+	}
+	return ok && reflect.DeepEqual(a, b)
+}
+
+func testCreateAndGet(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	o := newOrder("u1", "key-1")
+	out, created, err := repo.CreateOrder(ctx, o, testAudit)
+	if err != nil || !created || !sameOrder(out, o) {
+		t.Fatalf("CreateOrder = %+v, %v, %v; want %+v, created", out, created, err, o)
+	}
+	got, err := repo.GetOrder(ctx, o.ID)
+	if err != nil || !sameOrder(got, o) {
+		t.Errorf("GetOrder = %+v, %v; want %+v", got, err, o)
+	}
+	for _, id := range []string{uuid.New().String(), "not-a-uuid"} {
+		if _, err := repo.GetOrder(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetOrder(%q) error %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func testCreateIsIdempotent(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	first := mustCreate(t, repo, newOrder("u1", "key-1"))
+	retry := newOrder("u1", "key-1")
+	retry.AmountCents = 1
+	out, created, err := repo.CreateOrder(ctx, retry, testAudit)
+	if err != nil || created || !sameOrder(out, first) {
+		t.Errorf("CreateOrder with a used key = %+v, %v, %v; want the first order, not created", out, created, err)
+	}
+	if _, err := repo.GetOrder(ctx, retry.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("retry's ID was stored: %v", err)
+	}
+	evs, err := repo.Events(ctx, first.ID, "", 0, 10)
+	if err != nil || len(evs) != 1 {
+		t.Errorf("events after a repeated create: %+v, %v; want just one", evs, err)
+	}
+	if got, err := repo.OrderByKey(ctx, "key-1"); err != nil || !sameOrder(got, first) {
+		t.Errorf("OrderByKey = %+v, %v; want the first order", got, err)
+	}
+	if _, err := repo.OrderByKey(ctx, "key-unused"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("OrderByKey(unused) error %v, want ErrNotFound", err)
+	}
+}
+
+func testItems(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	o := newOrder("u1", "key-items")
+	o.Items = []Item{
+		{SKU: "TSHIRT-M", Name: "T-shirt, medium", Quantity: 2, UnitPriceCents: 1299},
+		{SKU: "MUG-350", Name: "Mug, 350 ml", Quantity: 1, UnitPriceCents: 899},
+	}
+	o.AmountCents = 3497
+	if out, _, err := repo.CreateOrder(ctx, o, testAudit); err != nil || !sameOrder(out, o) {
+		t.Fatalf("CreateOrder = %+v, %v; want %+v", out, err, o)
+	}
+	if got, err := repo.GetOrder(ctx, o.ID); err != nil || !sameOrder(got, o) {
+		t.Errorf("GetOrder = %+v, %v; want the items in order", got, err)
+	}
+	retry := newOrder("u1", "key-items")
+	if out, _, err := repo.CreateOrder(ctx, retry, testAudit); err != nil || !sameOrder(out, o) {
+		t.Errorf("CreateOrder with a used key = %+v, %v; want the first order with its items", out, err)
+	}
+	out, err := repo.UpdateStatus(ctx, o.ID, Transition{Status: statusPaid}, func(Order) (bool, error) { return true, nil })
+	if o.Status, o.Version = statusPaid, 2; err != nil || !sameOrder(out, o) {
+		t.Errorf("UpdateStatus = %+v, %v; want the order with its items", out, err)
+	}
+
+	plain := mustCreate(t, repo, newOrder("u1", "key-plain"))
+	if got, _ := repo.GetOrder(ctx, plain.ID); len(got.Items) != 0 {
+		t.Errorf("order without items has %+v", got.Items)
+	}
+}
+
+func testSettlement(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	o := newOrder("u1", "key-fx")
+	o.Settlement = &Settlement{Amount: money.Money{Amount: 1195, Currency: "EUR"}, FXRate: "0.92", FXRateAsOf: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)}
+	if out, _, err := repo.CreateOrder(ctx, o, testAudit); err != nil || !sameOrder(out, o) {
+		t.Fatalf("CreateOrder = %+v, %v; want %+v", out, err, o)
+	}
+	if got, err := repo.GetOrder(ctx, o.ID); err != nil || !sameOrder(got, o) {
+		t.Errorf("GetOrder = %+v, %v; want the settlement", got, err)
+	}
+	if got := o.Charge(); got != (money.Money{Amount: 1195, Currency: "EUR"}) {
+		t.Errorf("Charge() = %v, want 11.95 EUR", got)
+	}
+
+	plain := mustCreate(t, repo, newOrder("u1", "key-plain"))
+	if got, _ := repo.GetOrder(ctx, plain.ID); got.Settlement != nil {
+		t.Errorf("order charged in its own currency has settlement %+v", got.Settlement)
+	}
+}
+
+func testDiscounts(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	o := newOrder("u1", "key-discounts")
+	o.Items = []Item{{SKU: "MUG-350", Name: "Mug, 350 ml", Quantity: 3, UnitPriceCents: 899}}
+	o.Discounts = []Discount{
+		{Code: "MUGS3FOR2", Kind: couponBuyXGetY, Description: "Buy 2 mugs, get a third free", AmountCents: 899, SKU: "MUG-350"},
+		{Code: "WELCOME10", Kind: couponPercentage, AmountCents: 269, Limits: CouponLimits{PerUser: 1}},
+	}
+	o.AmountCents = 1529
+	out, _, err := repo.CreateOrder(ctx, o, testAudit)
+	// Limits are checked, not stored.
+	o.Discounts[1].Limits = CouponLimits{}
+	if err != nil || !sameOrder(out, o) {
+		t.Fatalf("CreateOrder = %+v, %v; want %+v", out, err, o)
+	}
+	if got, err := repo.GetOrder(ctx, o.ID); err != nil || !sameOrder(got, o) || got.Subtotal().Amount != 2697 {
+		t.Errorf("GetOrder = %+v, %v; want the discounts from a 2697 subtotal", got, err)
+	}
+	if got, err := repo.OrderByKey(ctx, "key-discounts"); err != nil || !sameOrder(got, o) {
+		t.Errorf("OrderByKey = %+v, %v; want the order with its discounts", got, err)
+	}
+	retry := newOrder("u1", "key-discounts")
+	if out, _, err := repo.CreateOrder(ctx, retry, testAudit); err != nil || !sameOrder(out, o) {
+		t.Errorf("CreateOrder with a used key = %+v, %v; want the first order with its discounts", out, err)
+	}
+}
+
+func testTaxes(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	o := newOrder("u1", "key-taxes")
+	o.Items = []Item{
+		{SKU: "TSHIRT-M", Name: "T-shirt, medium", Quantity: 1, UnitPriceCents: 1299, TaxCategory: "clothing"},
+		{SKU: "COFFEE", Name: "Coffee, 250 g", Quantity: 1, UnitPriceCents: 1000, TaxCategory: "food"},
+	}
+	o.TaxJurisdiction, o.TaxRulesVersion = "DE", "2026-10-01"
+	o.Taxes = []TaxLine{
+		{Name: "Umsatzsteuer 19%", Rate: "0.19", TaxableCents: 1299, AmountCents: 247},
+		{Name: "Umsatzsteuer 7%", Rate: "0.07", TaxableCents: 1000, AmountCents: 70},
+	}
+	o.AmountCents = 2616
+	if out, _, err := repo.CreateOrder(ctx, o, testAudit); err != nil || !sameOrder(out, o) {
+		t.Fatalf("CreateOrder = %+v, %v; want %+v", out, err, o)
+	}
+	got, err := repo.GetOrder(ctx, o.ID)
+	if err != nil || !sameOrder(got, o) {
+		t.Fatalf("GetOrder = %+v, %v; want the taxes", got, err)
+	}
+	if got.Tax().Amount != 317 || got.Subtotal().Amount != 2299 {
+		t.Errorf("Tax() = %v and Subtotal() = %v, want 3.17 and 22.99 USD", got.Tax(), got.Subtotal())
+	}
+}
+
+func testCouponLimits(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	withCoupon := func(userID, key string, limits CouponLimits) Order {
+		o := newOrder(userID, key)
+		o.Discounts = []Discount{{Code: "LIMITED", Kind: couponPercentage, AmountCents: 129, Limits: limits}}
+		o.AmountCents = 1170
+		return o
+	}
+	perUser := CouponLimits{PerUser: 1, Global: 3}
+	first := mustCreate(t, repo, withCoupon("u1", "key-1", perUser))
+	if out, created, err := repo.CreateOrder(ctx, withCoupon("u1", "key-1", perUser), testAudit); err != nil || created || out.ID != first.ID {
+		t.Errorf("retried CreateOrder = %+v, %v, %v; want the first order", out, created, err)
+	}
+	var limit *CouponLimitError
+	if _, _, err := repo.CreateOrder(ctx, withCoupon("u1", "key-2", perUser), testAudit); !errors.As(err, &limit) || !limit.PerUser {
+		t.Errorf("second redemption by u1: %v, want a per-user CouponLimitError", err)
+	}
+	mustCreate(t, repo, withCoupon("u2", "key-3", perUser))
+
+	// One redemption is left; racing orders must not take more.
+	const n = 8
+	var created atomic.Int32
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := repo.CreateOrder(ctx, withCoupon(fmt.Sprintf("racer-%d", i), fmt.Sprintf("key-race-%d", i), perUser), testAudit)
+			switch {
+			case err == nil && ok:
+				created.Add(1)
+			case err != nil && !errors.As(err, new(*CouponLimitError)):
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if created.Load() != 1 {
+		t.Errorf("%d racing orders redeemed the last coupon, want 1", created.Load())
+	}
+	if _, _, err := repo.CreateOrder(ctx, withCoupon("u9", "key-9", perUser), testAudit); !errors.As(err, &limit) || limit.PerUser {
+		t.Errorf("redemption past the global limit: %v, want a CouponLimitError", err)
+	}
+	// A rejected order is not stored.
+	if out, _, err := repo.CreateOrder(ctx, newOrder("u9", "key-9"), testAudit); err != nil || len(out.Discounts) != 0 {
+		t.Errorf("CreateOrder after a rejected one with its key = %+v, %v; want a new order", out, err)
+	}
+}
+
+// testReleasesCouponRedemptions checks failed and cancelled orders give their
+// redemptions back, once, and paid ones keep them.
+func testReleasesCouponRedemptions(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	limits := CouponLimits{PerUser: 1, Global: 1}
+	withCoupon := func(key string) Order {
+		o := newOrder("u1", key)
+		o.Discounts = []Discount{{Code: "ONCE", Kind: couponPercentage, AmountCents: 129, Limits: limits}}
+		o.AmountCents = 1170
+		return o
+	}
+	apply := func(Order) (bool, error) { return true, nil }
+	for i, next := range []string{statusPaymentFailed, statusOutOfStock, statusCancelled} {
+		o := mustCreate(t, repo, withCoupon(fmt.Sprintf("key-%d", i)))
+		if _, _, err := repo.CreateOrder(ctx, withCoupon(fmt.Sprintf("key-%d-again", i)), testAudit); !errors.As(err, new(*CouponLimitError)) {
+			t.Fatalf("redemption while order %d holds it: %v, want a CouponLimitError", i, err)
+		}
+		if _, err := repo.UpdateStatus(ctx, o.ID, Transition{Status: next}, apply); err != nil {
+			t.Fatalf("UpdateStatus to %s: %v", next, err)
+		}
+	}
+	// Giving back twice would let two more orders through.
+	o := mustCreate(t, repo, withCoupon("key-paid"))
+	if _, err := repo.UpdateStatus(ctx, o.ID, Transition{Status: statusCancelled}, apply); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.UpdateStatus(ctx, o.ID, Transition{Status: statusCancelled}, apply); err != nil {
+		t.Fatal(err)
+	}
+	o = mustCreate(t, repo, withCoupon("key-paid-2"))
+	if _, err := repo.UpdateStatus(ctx, o.ID, Transition{Status: statusPaid}, apply); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := repo.CreateOrder(ctx, withCoupon("key-after-paid"), testAudit); !errors.As(err, new(*CouponLimitError)) {
+		t.Errorf("redemption after a paid one: %v, want a CouponLimitError", err)
+	}
+}
+
+// testLargeAmount stores a total beyond 32 bits: 30M USD, or 3B yen.
+func testLargeAmount(t *testing.T, repo OrderRepository) {
+	o := newOrder("u1", "key-large")
+	o.AmountCents = 3_000_000_000
+	mustCreate(t, repo, o)
+	if got, err := repo.GetOrder(context.Background(), o.ID); err != nil || got.AmountCents != o.AmountCents {
+		t.Errorf("GetOrder = %+v, %v; want amount %d", got, err, o.AmountCents)
+	}
+}
+
+func testConcurrentCreates(t *testing.T, repo OrderRepository) {
+	const n = 16
+	ids := make([]string, n)
+	var created atomic.Int32
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, ok, err := repo.CreateOrder(context.Background(), newOrder("u1", "key-race"), testAudit)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				created.Add(1)
+			}
+			ids[i] = out.ID
+		}()
+	}
+	wg.Wait()
+	if created.Load() != 1 {
+		t.Errorf("%d creates reported created, want 1", created.Load())
+	}
+	for i, id := range ids {
+		if id != ids[0] {
+			t.Fatalf("create %d returned order %s, want %s", i, id, ids[0])
+		}
+	}
+}
+
+func testUpdateStatus(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	o := mustCreate(t, repo, newOrder("u1", "key-1"))
+	if o.Version != 1 {
+		t.Errorf("new order at version %d, want 1", o.Version)
+	}
+
+	var seen Order
+	out, err := repo.UpdateStatus(ctx, o.ID, Transition{Status: statusPaid}, func(cur Order) (bool, error) {
+		seen = cur
+		return true, nil
+	})
+	if err != nil || out.Status != statusPaid || seen.Status != statusCreated {
+		t.Fatalf("UpdateStatus = %+v, %v (decide saw %s); want PAID, decide seeing CREATED", out, err, seen.Status)
+	}
+	if got, _ := repo.GetOrder(ctx, o.ID); got.Status != statusPaid || got.Version != 2 || out.Version != 2 {
+		t.Errorf("stored %s at version %d (returned %d), want PAID at 2", got.Status, got.Version, out.Version)
+	}
+
+	out, err = repo.UpdateStatus(ctx, o.ID, Transition{Status: statusPaymentFailed}, func(Order) (bool, error) { return false, nil })
+	if err != nil || out.Status != statusPaid {
+		t.Errorf("declined update = %+v, %v; want the order unchanged", out, err)
+	}
+	errRefused := errors.New("refused")
+	if _, err := repo.UpdateStatus(ctx, o.ID, Transition{Status: statusPaymentFailed}, func(Order) (bool, error) { return true, errRefused }); err != errRefused {
+		t.Errorf("decide error: got %v, want it returned as is", err)
+	}
+	if got, _ := repo.GetOrder(ctx, o.ID); got.Status != statusPaid || got.Version != 2 {
+		t.Errorf("stored %s at version %d after refused updates, want PAID at 2", got.Status, got.Version)
+	}
+	evs, _ := repo.Events(ctx, o.ID, "", 0, 10)
+	if len(evs) != 2 {
+		t.Errorf("%d events, want 2 (created, paid)", len(evs))
+	}
+
+	for _, id := range []string{uuid.New().String(), "not-a-uuid"} {
+		called := false
+		_, err = repo.UpdateStatus(ctx, id, Transition{Status: statusPaid}, func(Order) (bool, error) { called = true; return true, nil })
+		if !errors.Is(err, ErrNotFound) || called {
+			t.Errorf("UpdateStatus(%q): err %v, decide called %v; want ErrNotFound without calling decide", id, err, called)
+		}
+	}
+	out, err = repo.UpdateStatus(ctx, o.ID, Transition{Status: statusCancelled, Reason: reasonFraudSuspected}, func(Order) (bool, error) { return true, nil })
+	if err != nil || out.StatusReason != reasonFraudSuspected {
+		t.Errorf("UpdateStatus with a reason = %+v, %v", out, err)
+	}
+	if got, _ := repo.GetOrder(ctx, o.ID); got.Status != statusCancelled || got.StatusReason != reasonFraudSuspected {
+		t.Errorf("stored %s with reason %q, want CANCELLED with %s", got.Status, got.StatusReason, reasonFraudSuspected)
+	}
+}
+
+func testHistory(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	o := mustCreate(t, repo, newOrder("u1", "key-1"))
+	support := Audit{Actor: "support:ana", Source: "gateway"}
+	if _, err := repo.UpdateStatus(ctx, o.ID, Transition{Status: statusPaid, Reason: "APPROVED", By: testAudit}, func(Order) (bool, error) { return true, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.UpdateStatus(ctx, o.ID, Transition{Status: statusPaid, By: testAudit}, func(Order) (bool, error) { return false, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.UpdateStatus(ctx, o.ID, Transition{Status: statusCancelled, Reason: reasonCustomerRequest, By: support}, func(Order) (bool, error) { return true, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.History(ctx, o.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{OrderID: o.ID, Version: 1, NewStatus: statusCreated, By: testAudit},
+		{OrderID: o.ID, Version: 2, OldStatus: statusCreated, NewStatus: statusPaid, Reason: "APPROVED", By: testAudit},
+		{OrderID: o.ID, Version: 3, OldStatus: statusPaid, NewStatus: statusCancelled, Reason: reasonCustomerRequest, By: support},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("History = %+v, want %d changes", got, len(want))
+	}
+	for i := range got {
+		if got[i].At.IsZero() {
+			t.Errorf("change %d has no time", i)
+		}
+		got[i].At = time.Time{}
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	for _, id := range []string{uuid.New().String(), "not-a-uuid"} {
+		if _, err := repo.History(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("History(%q): %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func testConcurrentUpdates(t *testing.T, repo OrderRepository) {
+	o := mustCreate(t, repo, newOrder("u1", "key-1"))
+	const n = 16
+	var applied atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		next := statusPaid
+		if i%2 == 1 {
+			next = statusPaymentFailed
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.UpdateStatus(context.Background(), o.ID, Transition{Status: next}, func(cur Order) (bool, error) {
+				if cur.Status != statusCreated {
+					return false, nil
+				}
+				applied.Add(1)
+				return true, nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if applied.Load() != 1 {
+		t.Errorf("%d updates applied to a CREATED order, want exactly 1", applied.Load())
+	}
+	evs, _ := repo.Events(context.Background(), o.ID, "", 0, 100)
+	if len(evs) != 2 {
+		t.Errorf("%d events, want 2", len(evs))
+	}
+}
+
+func testEvents(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	a := mustCreate(t, repo, newOrder("u1", "key-a"))
+	b := mustCreate(t, repo, newOrder("u1", "key-b"))
+	c := mustCreate(t, repo, newOrder("u2", "key-c"))
+	paid := func(Order) (bool, error) { return true, nil }
+	if _, err := repo.UpdateStatus(ctx, a.ID, Transition{Status: statusPaid}, paid); err != nil {
+		t.Fatal(err)
+	}
+
+	byOrder, err := repo.Events(ctx, a.ID, "", 0, 10)
+	if err != nil || len(byOrder) != 2 {
+		t.Fatalf("events of order a: %+v, %v; want 2", byOrder, err)
+	}
+	if e := byOrder[1]; e.Status != statusPaid || e.PreviousStatus != statusCreated || e.UserID != "u1" || e.Seq <= byOrder[0].Seq {
+		t.Errorf("transition event %+v, want CREATED -> PAID after seq %d", e, byOrder[0].Seq)
+	}
+	byUser, err := repo.Events(ctx, "", "u1", 0, 10)
+	if err != nil || len(byUser) != 3 {
+		t.Fatalf("events of u1: %+v, %v; want 3", byUser, err)
+	}
+	for i, want := range []string{a.ID, b.ID, a.ID} {
+		if byUser[i].OrderID != want {
+			t.Errorf("u1 event %d is for %s, want %s", i, byUser[i].OrderID, want)
+		}
+	}
+	if after, _ := repo.Events(ctx, "", "u1", byUser[0].Seq, 10); len(after) != 2 || after[0].Seq != byUser[1].Seq {
+		t.Errorf("events after seq %d: %+v", byUser[0].Seq, after)
+	}
+	if limited, _ := repo.Events(ctx, "", "u1", 0, 1); len(limited) != 1 {
+		t.Errorf("limit 1 returned %d events", len(limited))
+	}
+	if none, _ := repo.Events(ctx, c.ID, "", byOrder[1].Seq, 10); len(none) != 0 {
+		t.Errorf("events of c after the last seq: %+v", none)
+	}
+	if none, err := repo.Events(ctx, "not-a-uuid", "", 0, 10); err != nil || len(none) != 0 {
+		t.Errorf("events of a malformed order ID: %+v, %v; want none", none, err)
+	}
+	head, err := repo.LastEventSeq(ctx)
+	if err != nil || head != byOrder[1].Seq {
+		t.Errorf("LastEventSeq = %d, %v; want %d", head, err, byOrder[1].Seq)
+	}
+}
+
+// testEventCursor reads a user's events with a seq cursor while orders are
+// created concurrently: every event must be read, including those whose
+// transactions committed after one with a higher seq would have.
+func testEventCursor(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	const writers, perWriter = 8, 10
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWriter {
+				if _, _, err := repo.CreateOrder(ctx, newOrder("u1", fmt.Sprintf("key-%d-%d", w, i)), testAudit); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+
+	seen := make(map[int64]bool)
+	var cursor int64
+	read := func() {
+		evs, err := repo.Events(ctx, "", "u1", cursor, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ev := range evs {
+			seen[ev.Seq] = true
+			cursor = ev.Seq
+		}
+	}
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		read()
+	}
+	read()
+	if len(seen) != writers*perWriter {
+		t.Errorf("cursor read %d events, want %d", len(seen), writers*perWriter)
+	}
+}
+
+func testListen(t *testing.T, repo OrderRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mustCreate(t, repo, newOrder("u1", "key-before"))
+
+	started := make(chan int64, 1)
+	events := make(chan Event, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- repo.Listen(ctx, func(head int64) { started <- head }, func(ev Event) { events <- ev })
+	}()
+	var head int64
+	select {
+	case head = <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not start")
+	}
+	if last, _ := repo.LastEventSeq(ctx); head != last {
+		t.Errorf("started at %d, want the head %d", head, last)
+	}
+
+	o := mustCreate(t, repo, newOrder("u1", "key-after"))
+	select {
+	case ev := <-events:
+		if ev.OrderID != o.ID || ev.Status != statusCreated || ev.Seq <= head {
+			t.Errorf("published %+v, want order %s CREATED after seq %d", ev, o.ID, head)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event published")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not return after cancel")
+	}
+}
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(*testing.T) OrderRepository { return NewMemoryRepository() })
+}
@@ -0,0 +1,422 @@
+// Package ordersvc implements the Orders API.
+package ordersvc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/reliability-lab/gen/catalog"
+	"github.com/reliability-lab/gen/inventory"
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/gen/payments"
+	"github.com/reliability-lab/pkg/money"
+	"github.com/reliability-lab/pkg/money/fx"
+	"github.com/reliability-lab/pkg/money/tax"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Order statuses. Orders start CREATED and settle to PAID or PAYMENT_FAILED
+// once the gateway has charged them, or to OUT_OF_STOCK when their items
+// could not be reserved. CREATED and PAID orders can be CANCELLED.
+const (
+	statusCreated       = "CREATED"
+	statusPaid          = "PAID"
+	statusPaymentFailed = "PAYMENT_FAILED"
+	statusOutOfStock    = "OUT_OF_STOCK"
+	statusCancelled     = "CANCELLED"
+)
+
+// Server implements orders.OrdersServer on an OrderRepository.
+type Server struct {
+	orders.UnimplementedOrdersServer
+	repo       OrderRepository
+	catalog    catalog.CatalogClient
+	payments   payments.PaymentsClient
+	inventory  inventory.InventoryClient
+	fx         fx.Source
+	promotions *Promotions
+	tax        *tax.Engine
+	events     *eventHub
+
+	stopListening context.CancelFunc
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithCatalog prices line items through c. Without it, orders with items
+// are rejected.
+func WithCatalog(c catalog.CatalogClient) Option {
+	return func(s *Server) { s.catalog = c }
+}
+
+// WithPayments reverses the payments of cancelled orders through c. Without
+// it, CancelOrder fails.
+func WithPayments(c payments.PaymentsClient) Option {
+	return func(s *Server) { s.payments = c }
+}
+
+// WithInventory gives back the stock of cancelled orders through c. Without
+// it, cancelling an order leaves its reservation alone.
+func WithInventory(c inventory.InventoryClient) Option {
+	return func(s *Server) { s.inventory = c }
+}
+
+// NewServer serves the orders in repo and feeds WatchOrders from
+// repo.Listen until Close.
+func NewServer(repo OrderRepository, opts ...Option) *Server {
+	ctx, stop := context.WithCancel(context.Background())
+	s := &Server{repo: repo, events: newEventHub(), stopListening: stop}
+	for _, opt := range opts {
+		opt(s)
+	}
+	go s.events.listen(ctx, repo)
+	return s
+}
+
+// Close ends the WatchOrders streams, which a graceful stop would otherwise
+// wait on, and stops listening for events.
+func (s *Server) Close() {
+	s.events.close()
+	s.stopListening()
+}
+
+// EventLagCheck is a readiness check: it fails while the event listener is
+// disconnected or falling behind.
+func (s *Server) EventLagCheck(ctx context.Context) error {
+	return s.events.lagCheck(s.repo)(ctx)
+}
+
+func (s *Server) CreateOrder(ctx context.Context, req *orders.CreateOrderRequest) (*orders.CreateOrderResponse, error) {
+	ctx, span := otel.Tracer("orders").Start(ctx, "CreateOrder")
+	defer span.End()
+
+	if req.UserId == "" || req.IdempotencyKey == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "missing or invalid required fields")
+	}
+	want, err := requestAmount(req)
+	if err != nil {
+		return nil, err
+	}
+	if want.Amount < 0 || (want.Amount == 0 && len(req.Items) == 0) {
+		return nil, status.Error(grpccodes.InvalidArgument, "missing or invalid required fields")
+	}
+	if req.SettlementCurrency != "" {
+		if err := money.ValidateCurrency(req.SettlementCurrency); err != nil {
+			return nil, rejectField("currency", "settlement_currency", "%v", err)
+		}
+	}
+	// Seeded chaos decisions on this call's queries follow the idempotency key.
+	ctx = chaos.WithKey(ctx, req.IdempotencyKey)
+	// A retry returns the order as created, before prices, rates or coupon
+	// windows have had a chance to change under it.
+	start := time.Now()
+	existing, err := s.repo.OrderByKey(ctx, req.IdempotencyKey)
+	dbQueryDurationSeconds.WithLabelValues("get_order_by_key").Observe(time.Since(start).Seconds())
+	if err == nil {
+		if err := checkRetry(existing, req, want); err != nil {
+			return nil, err
+		}
+		return createOrderResponse(existing), nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, dbStatus(err, "failed to look up order")
+	}
+	items, subtotal, err := s.priceItems(ctx, req, want)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+	discounts, total, err := s.applyCoupons(req.CouponCodes, items, subtotal, createdAt)
+	jurisdiction := normalizeJurisdiction(req.TaxJurisdiction)
+	var taxes []TaxLine
+	if err == nil {
+		taxes, total, err = s.applyTax(jurisdiction, items, discounts, total)
+	}
+	if err == nil {
+		err = checkTotal(want, total, len(discounts) > 0 || len(taxes) > 0)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	var rulesVersion string
+	if jurisdiction != "" {
+		rulesVersion = s.tax.Version()
+	}
+	settlement, err := s.settle(ctx, req, total)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	start = time.Now()
+	o, created, err := s.repo.CreateOrder(ctx, Order{
+		ID:              uuid.New().String(),
+		UserID:          req.UserId,
+		AmountCents:     total.Amount,
+		Currency:        total.Currency,
+		Status:          statusCreated,
+		IdempotencyKey:  req.IdempotencyKey,
+		CreatedAt:       createdAt,
+		Items:           items,
+		Discounts:       discounts,
+		TaxJurisdiction: jurisdiction,
+		TaxRulesVersion: rulesVersion,
+		Taxes:           taxes,
+		Settlement:      settlement,
+	}, auditOf(ctx))
+	dbQueryDurationSeconds.WithLabelValues("create_order").Observe(time.Since(start).Seconds())
+	var limit *CouponLimitError
+	if errors.As(err, &limit) {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, rejectField("coupon_limit", "coupon_codes", "%v", limit)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, dbStatus(err, "failed to create order")
+	}
+	if created {
+		for _, d := range o.Discounts {
+			orderCouponRedemptionsTotal.WithLabelValues(d.Code).Inc()
+		}
+	}
+	// A concurrent create with the same key may have won the insert.
+	if err := checkRetry(o, req, want); err != nil {
+		return nil, err
+	}
+	return createOrderResponse(o), nil
+}
+
+// checkRetry fails with AlreadyExists unless req could have placed o, the
+// order stored under its idempotency key: a key reused by another user, or
+// for other items, coupons, jurisdiction or another amount, must not hand
+// back a different order.
+func checkRetry(o Order, req *orders.CreateOrderRequest, want money.Money) error {
+	if o.UserID != req.UserId || o.Currency != want.Currency || (want.Amount != 0 && o.AmountCents != want.Amount) ||
+		!sameItems(o.Items, req.Items) || !sameCoupons(o.Discounts, req.CouponCodes) ||
+		o.TaxJurisdiction != normalizeJurisdiction(req.TaxJurisdiction) {
+		return status.Error(grpccodes.AlreadyExists, "idempotency_key was already used for a different order")
+	}
+	return nil
+}
+
+// sameItems reports whether an order's items are the line items requested.
+func sameItems(items []Item, requested []*orders.LineItem) bool {
+	return slices.EqualFunc(items, requested, func(it Item, li *orders.LineItem) bool {
+		return it.SKU == li.Sku && it.Quantity == li.Quantity
+	})
+}
+
+// sameCoupons reports whether an order's discounts are those of the coupon
+// codes requested; applyCoupons gives every code one, in order.
+func sameCoupons(discounts []Discount, codes []string) bool {
+	return slices.EqualFunc(discounts, codes, func(d Discount, code string) bool {
+		return d.Code == normalizeCoupon(code)
+	})
+}
+
+// createOrderResponse describes o as CreateOrder returns it.
+func createOrderResponse(o Order) *orders.CreateOrderResponse {
+	charge := o.Charge()
+	resp := &orders.CreateOrderResponse{
+		OrderId:     o.ID,
+		Status:      o.Status,
+		Version:     o.Version,
+		AmountCents: o.AmountCents,
+		Currency:    o.Currency,
+		Amount:      charge.Proto(),
+		Charge:      charge.Proto(),
+		Discounts:   discountsProto(o.Discounts, o.Currency),
+		Subtotal:    o.Subtotal().Proto(),
+		Tax:         o.Tax().Proto(),
+		Total:       o.Total().Proto(),
+		Taxes:       taxesProto(o.Taxes, o.Currency),
+	}
+	if o.Settlement != nil {
+		resp.FxRate = o.Settlement.FXRate
+	}
+	return resp
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *orders.GetOrderRequest) (*orders.GetOrderResponse, error) {
+	ctx, span := otel.Tracer("orders").Start(ctx, "GetOrder")
+	defer span.End()
+
+	if req.OrderId == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "order_id required")
+	}
+
+	start := time.Now()
+	o, err := s.repo.GetOrder(ctx, req.OrderId)
+	dbQueryDurationSeconds.WithLabelValues("get_order").Observe(time.Since(start).Seconds())
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			span.SetStatus(codes.Error, "not found")
+			return nil, status.Error(grpccodes.NotFound, "order not found")
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, dbStatus(err, "failed to get order")
+	}
+	resp := &orders.GetOrderResponse{
+		OrderId:         o.ID,
+		UserId:          o.UserID,
+		AmountCents:     o.AmountCents,
+		Currency:        o.Currency,
+		Status:          o.Status,
+		IdempotencyKey:  o.IdempotencyKey,
+		CreatedAt:       o.CreatedAt.UTC().Format(time.RFC3339),
+		Items:           itemsProto(o.Items, o.Currency),
+		StatusReason:    o.StatusReason,
+		Version:         o.Version,
+		Total:           o.Total().Proto(),
+		Subtotal:        o.Subtotal().Proto(),
+		Discounts:       discountsProto(o.Discounts, o.Currency),
+		Tax:             o.Tax().Proto(),
+		Taxes:           taxesProto(o.Taxes, o.Currency),
+		TaxJurisdiction: o.TaxJurisdiction,
+		TaxRulesVersion: o.TaxRulesVersion,
+	}
+	if st := o.Settlement; st != nil {
+		resp.SettlementCurrency, resp.SettlementAmountCents = st.Amount.Currency, st.Amount.Amount
+		resp.Settlement, resp.FxRate = st.Amount.Proto(), st.FXRate
+		resp.FxRateAsOf = st.FXRateAsOf.UTC().Format(time.RFC3339)
+	}
+	return resp, nil
+}
+
+// UpdateOrderStatus moves a CREATED order to PAID, PAYMENT_FAILED or
+// OUT_OF_STOCK and records the transition. Repeating the same update is a
+// no-op so retries are safe; any other transition fails with
+// FailedPrecondition, and a stale expected_version with Aborted.
+func (s *Server) UpdateOrderStatus(ctx context.Context, req *orders.UpdateOrderStatusRequest) (*orders.UpdateOrderStatusResponse, error) {
+	ctx, span := otel.Tracer("orders").Start(ctx, "UpdateOrderStatus")
+	defer span.End()
+	span.SetAttributes(attribute.String("order_id", req.OrderId), attribute.String("status", req.Status))
+
+	if req.OrderId == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "order_id required")
+	}
+	if req.Status != statusPaid && req.Status != statusPaymentFailed && req.Status != statusOutOfStock {
+		return nil, status.Error(grpccodes.InvalidArgument, "status must be PAID, PAYMENT_FAILED or OUT_OF_STOCK")
+	}
+	if len(req.Reason) > maxReasonLen {
+		return nil, status.Errorf(grpccodes.InvalidArgument, "reason longer than %d bytes", maxReasonLen)
+	}
+
+	start := time.Now()
+	o, err := s.repo.UpdateStatus(ctx, req.OrderId, Transition{Status: req.Status, Reason: req.Reason, By: auditOf(ctx)}, func(current Order) (bool, error) {
+		if err := checkVersion(current, req.ExpectedVersion); err != nil {
+			return false, err
+		}
+		if current.Status == req.Status {
+			return false, nil
+		}
+		if current.Status != statusCreated {
+			return false, status.Errorf(grpccodes.FailedPrecondition, "order is %s", current.Status)
+		}
+		return true, nil
+	})
+	dbQueryDurationSeconds.WithLabelValues("update_order_status").Observe(time.Since(start).Seconds())
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			span.SetStatus(codes.Error, "not found")
+			return nil, status.Error(grpccodes.NotFound, "order not found")
+		}
+		if st, ok := status.FromError(err); ok {
+			span.SetStatus(codes.Error, st.Message())
+			return nil, err
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, dbStatus(err, "failed to update order")
+	}
+	return &orders.UpdateOrderStatusResponse{OrderId: req.OrderId, Status: o.Status, Version: o.Version}, nil
+}
+
+// checkVersion fails with Aborted when expected is set and o is at another
+// version, i.e. someone else wrote the order since the caller read it.
+func checkVersion(o Order, expected int64) error {
+	if expected != 0 && o.Version != expected {
+		return status.Errorf(grpccodes.Aborted, "order is at version %d, not %d", o.Version, expected)
+	}
+	return nil
+}
+
+// WatchOrders replays persisted events after req.AfterSequence and then
+// streams live ones until the client goes away or the server shuts down.
+func (s *Server) WatchOrders(req *orders.WatchOrdersRequest, stream orders.Orders_WatchOrdersServer) error {
+	ctx := stream.Context()
+	if (req.OrderId == "") == (req.UserId == "") {
+		return status.Error(grpccodes.InvalidArgument, "exactly one of order_id or user_id required")
+	}
+	if req.OrderId != "" {
+		if _, err := s.repo.GetOrder(ctx, req.OrderId); errors.Is(err, ErrNotFound) {
+			return status.Error(grpccodes.NotFound, "order not found")
+		} else if err != nil {
+			return dbStatus(err, "failed to look up order")
+		}
+	}
+
+	// Subscribe before replaying so nothing committed in between is missed;
+	// duplicates are filtered by sequence below.
+	sub, err := s.events.subscribe(req.OrderId, req.UserId)
+	if err != nil {
+		return status.Error(grpccodes.Unavailable, "server shutting down")
+	}
+	defer s.events.unsubscribe(sub)
+	// Send headers now so callers learn the watch is accepted even if no
+	// event arrives for a while.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	last := req.AfterSequence
+	// A whole order's history is short; for a user without a resume point only live events are sent.
+	if req.OrderId != "" || last > 0 {
+		backlog, err := s.repo.Events(ctx, req.OrderId, req.UserId, last, 1000)
+		if err != nil {
+			return dbStatus(err, "failed to load order events")
+		}
+		for _, ev := range backlog {
+			if err := stream.Send(ev.proto()); err != nil {
+				return err
+			}
+			last = ev.Seq
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case ev, ok := <-sub.ch:
+			if !ok {
+				return status.Error(grpccodes.Unavailable, "event stream closed; resume from the last sequence")
+			}
+			if ev.Seq <= last {
+				continue
+			}
+			if err := stream.Send(ev.proto()); err != nil {
+				return err
+			}
+			last = ev.Seq
+		}
+	}
+}
+
+var _ orders.OrdersServer = (*Server)(nil)
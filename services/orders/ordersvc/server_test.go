@@ -0,0 +1,164 @@
+package ordersvc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"github.com/reliability-lab/pkg/platform/pgtest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	db, err := InitDB(context.Background(), pgtest.Start(t))
+	if err != nil {
+		t.Fatalf("initDB: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// TestPostgresRepository runs the conformance suite on one database,
+// emptied before each case.
+func TestPostgresRepository(t *testing.T) {
+	db := newTestDB(t)
+	testRepository(t, func(t *testing.T) OrderRepository {
+		if _, err := db.Exec(context.Background(), `TRUNCATE orders, order_items, order_events, coupon_redemptions RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatal(err)
+		}
+		return NewPostgresRepository(db, nil)
+	})
+}
+
+func newTestServer(t *testing.T, repo OrderRepository) *Server {
+	t.Helper()
+	srv := NewServer(repo)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCreateOrder_Idempotency(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	srv := newTestServer(t, NewPostgresRepository(db, nil))
+	idemKey := "idem-test-123"
+	req := &orders.CreateOrderRequest{
+		UserId:         "u1",
+		AmountCents:    1299,
+		Currency:       "USD",
+		IdempotencyKey: idemKey,
+	}
+
+	resp1, err := srv.CreateOrder(ctx, req)
+	if err != nil {
+		t.Fatalf("first CreateOrder: %v", err)
+	}
+	resp2, err := srv.CreateOrder(ctx, req)
+	if err != nil {
+		t.Fatalf("second CreateOrder: %v", err)
+	}
+
+	if resp1.OrderId != resp2.OrderId {
+		t.Errorf("order_id mismatch: first=%q second=%q", resp1.OrderId, resp2.OrderId)
+	}
+	if resp1.Status != resp2.Status {
+		t.Errorf("status mismatch: first=%q second=%q", resp1.Status, resp2.Status)
+	}
+
+	var count int
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM orders WHERE idempotency_key = $1", idemKey).Scan(&count)
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 row for idempotency_key, got %d", count)
+	}
+
+	// Another user reusing the key is rejected rather than handed u1's order.
+	other := &orders.CreateOrderRequest{UserId: "u2", AmountCents: 1299, Currency: "USD", IdempotencyKey: idemKey}
+	if resp, err := srv.CreateOrder(ctx, other); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateOrder with the key of user u1 = %v, %v; want AlreadyExists", resp, err)
+	}
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t, NewPostgresRepository(newTestDB(t), nil))
+
+	created, err := srv.CreateOrder(ctx, &orders.CreateOrderRequest{
+		UserId:         "u1",
+		AmountCents:    500,
+		Currency:       "USD",
+		IdempotencyKey: "idem-status",
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := srv.UpdateOrderStatus(ctx, &orders.UpdateOrderStatusRequest{OrderId: created.OrderId, Status: statusPaid})
+		if err != nil {
+			t.Fatalf("UpdateOrderStatus attempt %d: %v", i, err)
+		}
+		if resp.Status != statusPaid {
+			t.Errorf("status = %q, want %q", resp.Status, statusPaid)
+		}
+	}
+
+	_, err = srv.UpdateOrderStatus(ctx, &orders.UpdateOrderStatusRequest{OrderId: created.OrderId, Status: statusPaymentFailed})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("PAID -> PAYMENT_FAILED: got %v, want FailedPrecondition", err)
+	}
+}
+
+func TestCreateOrder_PostgresFaults(t *testing.T) {
+	ctx := context.Background()
+	reg := chaos.NewRegistry("orders")
+	srv := newTestServer(t, NewPostgresRepository(newTestDB(t), reg))
+	create := func(key string) error {
+		_, err := srv.CreateOrder(ctx, &orders.CreateOrderRequest{UserId: "u1", AmountCents: 100, Currency: "USD", IdempotencyKey: key})
+		return err
+	}
+
+	// Statements fail with a serialization failure 30% of the time; the
+	// transaction is retried, so most orders commit.
+	reg.SetSeed(1)
+	f, err := reg.Set(ctx, chaos.Fault{Target: chaosPostgres, Error: errSerialization, Probability: 0.3}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := create(fmt.Sprintf("idem-serialization-%d", i)); err != nil && status.Code(err) != codes.Aborted {
+			t.Fatalf("order %d: %v, want success or Aborted after retries", i, err)
+		}
+	}
+	reg.Clear(ctx, f.ID)
+
+	for _, tt := range []struct {
+		fault string
+		code  codes.Code
+	}{
+		{errTooManyConnections, codes.Unavailable},
+		{errDrop, codes.Unavailable},
+	} {
+		f, err := reg.Set(ctx, chaos.Fault{Target: chaosPostgres, Error: tt.fault}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := create("idem-" + tt.fault); status.Code(err) != tt.code {
+			t.Errorf("%s: got %v, want %s", tt.fault, err, tt.code)
+		}
+		reg.Clear(ctx, f.ID)
+		// The pool replaces dropped connections.
+		if err := create("idem-" + tt.fault); err != nil {
+			t.Errorf("after clearing %s: %v", tt.fault, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package ordersvc
+
+import (
+	_ "embed"
+	"errors"
+	"strings"
+
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/pkg/money"
+	"github.com/reliability-lab/pkg/money/tax"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//go:embed taxrules.yaml
+var defaultTaxRules []byte
+
+// DefaultTaxRules returns the tax rules built into the binary.
+func DefaultTaxRules() *tax.Engine {
+	e, err := tax.Parse(defaultTaxRules)
+	if err != nil {
+		panic("ordersvc: built-in tax rules: " + err.Error())
+	}
+	return e
+}
+
+// WithTax taxes orders with a tax_jurisdiction under the rules in e, and
+// rejects orders with items that leave it out. Without it, orders with a
+// tax_jurisdiction are rejected.
+func WithTax(e *tax.Engine) Option {
+	return func(s *Server) { s.tax = e }
+}
+
+// applyTax returns the taxes on an order with items and discounts sold in
+// jurisdiction, and total, the order total after discounts, with them
+// added. Orders without items, and any order when no rules are configured,
+// need no jurisdiction and are then not taxed.
+func (s *Server) applyTax(jurisdiction string, items []Item, discounts []Discount, total money.Money) ([]TaxLine, money.Money, error) {
+	none := money.Money{}
+	if jurisdiction == "" {
+		if s.tax != nil && len(items) > 0 {
+			return nil, none, rejectField("tax_jurisdiction", "tax_jurisdiction", "orders with items need a tax_jurisdiction")
+		}
+		return nil, total, nil
+	}
+	if s.tax == nil {
+		return nil, none, status.Error(grpccodes.FailedPrecondition, "tax_jurisdiction needs tax rules, which are not configured")
+	}
+	if len(items) == 0 {
+		return nil, none, rejectField("tax_jurisdiction", "tax_jurisdiction", "tax needs line items")
+	}
+	lines, err := taxableLines(items, discounts, total.Currency)
+	if err != nil {
+		return nil, none, status.Errorf(grpccodes.Internal, "taxable lines: %v", err)
+	}
+	taxes, err := s.tax.Calculate(jurisdiction, lines)
+	switch {
+	case errors.Is(err, tax.ErrUnknownJurisdiction):
+		return nil, none, rejectField("tax_jurisdiction", "tax_jurisdiction", "no tax rules for %q", jurisdiction)
+	case errors.Is(err, tax.ErrUnknownCategory):
+		// The catalog and the tax rules disagree; the client can't fix that.
+		return nil, none, status.Errorf(grpccodes.FailedPrecondition, "%v", err)
+	case err != nil:
+		return nil, none, rejectPricing("invalid_item", "tax: %v", err)
+	}
+	out := make([]TaxLine, len(taxes))
+	for i, t := range taxes {
+		if total, err = total.Add(t.Amount); err != nil {
+			return nil, none, rejectPricing("invalid_item", "order total overflows")
+		}
+		out[i] = TaxLine{Name: t.Name, Rate: t.Rate, TaxableCents: t.Taxable.Amount, AmountCents: t.Amount.Amount}
+	}
+	return out, total, nil
+}
+
+// normalizeJurisdiction makes jurisdiction codes case-insensitive.
+func normalizeJurisdiction(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// taxableLines returns what is left of each item after discounts, with its
+// tax category. A BUY_X_GET_Y discount comes off the lines of its SKU, any
+// other over all lines, in proportion to what is left of them; what a line
+// cannot take goes to the others, so no line drops below zero.
+func taxableLines(items []Item, discounts []Discount, currency string) ([]tax.Line, error) {
+	lines := make([]tax.Line, len(items))
+	for i, it := range items {
+		// Line amounts were checked for overflow when the items were priced.
+		amount, _ := money.Money{Amount: it.UnitPriceCents, Currency: currency}.Mul(int64(it.Quantity))
+		lines[i] = tax.Line{Category: it.TaxCategory, Amount: amount}
+	}
+	for _, d := range discounts {
+		left := d.AmountCents
+		for _, sku := range []string{d.SKU, ""} {
+			weights := make([]int64, len(lines))
+			var sum int64
+			for i, l := range lines {
+				if sku == "" || items[i].SKU == sku {
+					weights[i] = l.Amount.Amount
+					sum += l.Amount.Amount
+				}
+			}
+			take := min(left, sum)
+			if take == 0 {
+				continue
+			}
+			parts, err := money.Money{Amount: take, Currency: currency}.Allocate(weights...)
+			if err != nil {
+				return nil, err
+			}
+			for i, p := range parts {
+				lines[i].Amount.Amount -= p.Amount
+			}
+			left -= take
+		}
+	}
+	return lines, nil
+}
+
+// taxesProto returns the taxes of an order priced in currency.
+func taxesProto(taxes []TaxLine, currency string) []*orders.OrderTax {
+	out := make([]*orders.OrderTax, len(taxes))
+	for i, t := range taxes {
+		out[i] = &orders.OrderTax{
+			Name:    t.Name,
+			Rate:    t.Rate,
+			Taxable: money.Money{Amount: t.TaxableCents, Currency: currency}.Proto(),
+			Amount:  money.Money{Amount: t.AmountCents, Currency: currency}.Proto(),
+		}
+	}
+	return out
+}
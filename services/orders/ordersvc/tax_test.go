@@ -0,0 +1,149 @@
+package ordersvc
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/pkg/money/tax"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func taxRequest(key, jurisdiction string, amount int64, items ...*orders.LineItem) *orders.CreateOrderRequest {
+	req := itemsRequest(key, amount, items...)
+	req.TaxJurisdiction = jurisdiction
+	return req
+}
+
+func TestCreateOrder_Taxes(t *testing.T) {
+	shirtsAndMug := []*orders.LineItem{{Sku: "TSHIRT-M", Quantity: 2}, {Sku: "MUG-350", Quantity: 1}}
+	tests := []struct {
+		name         string
+		jurisdiction string
+		coupons      []string
+		stored       string
+		taxes        []TaxLine
+		total        int64
+	}{
+		// 7.25% of 34.97 is 2.535325.
+		{"sales tax", "US-CA", nil, "US-CA", []TaxLine{{Name: "California sales tax", Rate: "0.0725", TaxableCents: 3497, AmountCents: 254}}, 3751},
+		{"codes are case-insensitive", " de ", nil, "DE", []TaxLine{{Name: "Umsatzsteuer 19%", Rate: "0.19", TaxableCents: 3497, AmountCents: 664}}, 4161},
+		{"no sales tax", "US-OR", nil, "US-OR", nil, 3497},
+		{"after discounts", "US-CA", []string{"TEN"}, "US-CA", []TaxLine{{Name: "California sales tax", Rate: "0.0725", TaxableCents: 3148, AmountCents: 228}}, 3376},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			rules := DefaultTaxRules()
+			srv := NewServer(repo, WithCatalog(newFakeCatalog()), WithPromotions(testPromotions(t)), WithTax(rules))
+			t.Cleanup(srv.Close)
+			ctx := context.Background()
+
+			req := taxRequest("tax", tt.jurisdiction, 0, shirtsAndMug...)
+			req.CouponCodes = tt.coupons
+			resp, err := srv.CreateOrder(ctx, req)
+			if err != nil || resp.Charge.GetMinorUnits() != tt.total || resp.Total.GetMinorUnits() != tt.total || resp.Subtotal.GetMinorUnits() != 3497 {
+				t.Fatalf("CreateOrder = %v, %v; want a total of %d from 3497", resp, err, tt.total)
+			}
+			o, err := repo.GetOrder(ctx, resp.OrderId)
+			if err != nil || o.TaxJurisdiction != tt.stored || !slices.Equal(o.Taxes, tt.taxes) {
+				t.Fatalf("stored order taxed in %q with %+v, %v; want %q with %+v", o.TaxJurisdiction, o.Taxes, err, tt.stored, tt.taxes)
+			}
+			if o.TaxRulesVersion != rules.Version() {
+				t.Errorf("stored rules version %q, want %q", o.TaxRulesVersion, rules.Version())
+			}
+
+			got, err := srv.GetOrder(ctx, &orders.GetOrderRequest{OrderId: resp.OrderId})
+			var taxed int64
+			for _, l := range tt.taxes {
+				taxed += l.AmountCents
+			}
+			if err != nil || got.Subtotal.GetMinorUnits() != 3497 || got.Tax.GetMinorUnits() != taxed || got.Tax.GetCurrency() != "USD" ||
+				got.Total.GetMinorUnits() != tt.total || len(got.Taxes) != len(tt.taxes) || got.Items[0].TaxCategory != "clothing" {
+				t.Errorf("GetOrder = %v, %v; want subtotal 3497, tax %d and total %d", got, err, taxed, tt.total)
+			}
+		})
+	}
+}
+
+func TestCreateOrder_RejectsTax(t *testing.T) {
+	shirt := &orders.LineItem{Sku: "TSHIRT-M", Quantity: 1}
+	noItems := taxRequest("k", "US-CA", 1299)
+	tests := []struct {
+		name  string
+		rules bool
+		req   *orders.CreateOrderRequest
+		code  codes.Code
+		field string
+	}{
+		{"unknown jurisdiction", true, taxRequest("k", "US-TX", 0, shirt), codes.InvalidArgument, "tax_jurisdiction"},
+		{"no jurisdiction", true, taxRequest("k", "", 0, shirt), codes.InvalidArgument, "tax_jurisdiction"},
+		{"no items", true, noItems, codes.InvalidArgument, "tax_jurisdiction"},
+		{"untaxed amount", true, taxRequest("k", "US-CA", 1299, shirt), codes.InvalidArgument, ""},
+		{"unknown category", true, taxRequest("k", "US-CA", 0, &orders.LineItem{Sku: "TOY", Quantity: 1}), codes.FailedPrecondition, ""},
+		{"no rules", false, taxRequest("k", "US-CA", 0, shirt), codes.FailedPrecondition, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			opts := []Option{WithCatalog(newFakeCatalog())}
+			if tt.rules {
+				opts = append(opts, WithTax(DefaultTaxRules()))
+			}
+			srv := NewServer(repo, opts...)
+			t.Cleanup(srv.Close)
+			_, err := srv.CreateOrder(context.Background(), tt.req)
+			st := status.Convert(err)
+			if st.Code() != tt.code {
+				t.Fatalf("CreateOrder error %v, want %s", err, tt.code)
+			}
+			if tt.field != "" && !hasFieldViolation(st, tt.field) {
+				t.Errorf("error details %v, want a violation on %s", st.Details(), tt.field)
+			}
+			if head, _ := repo.LastEventSeq(context.Background()); head != 0 {
+				t.Errorf("a rejected order was stored")
+			}
+		})
+	}
+}
+
+func TestTaxableLines(t *testing.T) {
+	mugs := Item{SKU: "MUG-350", Quantity: 3, UnitPriceCents: 899, TaxCategory: tax.DefaultCategory}
+	tests := []struct {
+		name      string
+		items     []Item
+		discounts []Discount
+		want      []int64
+	}{
+		{"no discounts", []Item{mugs}, nil, []int64{2697}},
+		{
+			"free item first",
+			[]Item{mugs, {SKU: "COFFEE", Quantity: 1, UnitPriceCents: 1000, TaxCategory: "food"}},
+			[]Discount{{SKU: "MUG-350", AmountCents: 899}, {AmountCents: 370}},
+			// 370 of 1798 + 1000 is 237.76 + 132.24.
+			[]int64{1560, 868},
+		},
+		{
+			"free item spills over",
+			[]Item{mugs, {SKU: "TSHIRT-M", Quantity: 1, UnitPriceCents: 1299, TaxCategory: "clothing"}},
+			[]Discount{{AmountCents: 3000}, {SKU: "MUG-350", AmountCents: 899}},
+			// 3000 of 2697 + 1299 leaves 672 + 324, too little mug for 899.
+			[]int64{0, 97},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := taxableLines(tt.items, tt.discounts, "USD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, l := range lines {
+				if l.Amount.Amount != tt.want[i] || l.Amount.Currency != "USD" || l.Category != tt.items[i].TaxCategory {
+					t.Errorf("line %d = %+v, want %d USD of %s", i, l, tt.want[i], tt.items[i].TaxCategory)
+				}
+			}
+		})
+	}
+}
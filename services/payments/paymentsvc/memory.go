@@ -0,0 +1,73 @@
+package paymentsvc
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore is a Store held in process memory, for tests and local runs.
+type MemoryStore struct {
+	mu       sync.Mutex
+	payments map[string]Payment
+	charges  map[string]ChargeResult
+}
+
+// NewMemoryStore returns an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{payments: make(map[string]Payment), charges: make(map[string]ChargeResult)}
+}
+
+func (m *MemoryStore) Payment(ctx context.Context, orderID string) (Payment, error) {
+	if err := ctx.Err(); err != nil {
+		return Payment{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.payments[orderID]
+	if !ok {
+		return Payment{}, ErrNotFound
+	}
+	return p, nil
+}
+
+func (m *MemoryStore) UpdatePayment(ctx context.Context, orderID string, update func(*Payment) error) (Payment, error) {
+	if err := ctx.Err(); err != nil {
+		return Payment{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.payments[orderID]
+	if !ok {
+		p = Payment{OrderID: orderID}
+	}
+	if err := update(&p); err != nil {
+		return Payment{}, err
+	}
+	m.payments[orderID] = p
+	return p, nil
+}
+
+func (m *MemoryStore) ChargeResult(ctx context.Context, key string) (ChargeResult, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return ChargeResult{}, false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.charges[key]
+	return r, ok, nil
+}
+
+func (m *MemoryStore) SaveChargeResult(ctx context.Context, key string, r ChargeResult) (ChargeResult, error) {
+	if err := ctx.Err(); err != nil {
+		return ChargeResult{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored, ok := m.charges[key]; ok {
+		return stored, nil
+	}
+	m.charges[key] = r
+	return r, nil
+}
+
+var _ Store = (*MemoryStore)(nil)
@@ -0,0 +1,25 @@
+package paymentsvc
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	paymentsDeclinedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "payments_declined_total",
+			Help: "Total payment declines",
+		},
+	)
+	paymentsReversalsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payments_reversals_total",
+			Help: "Payments reversed by order cancellation, by outcome: VOIDED or REFUNDED",
+		},
+		[]string{"status"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(paymentsDeclinedTotal, paymentsReversalsTotal)
+}
@@ -0,0 +1,114 @@
+package paymentsvc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore is the Store on Postgres. UpdatePayment holds the payment's
+// row lock while update runs, so a Charge and a Reverse of the same order
+// serialize across replicas.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore keeps payments in pool, which InitDB has migrated.
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+// InitDB connects to connStr and creates the payments schema if needed.
+func InitDB(ctx context.Context, connStr string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, connStr)
+	if err != nil {
+		return nil, err
+	}
+	q := `CREATE TABLE IF NOT EXISTS payments (
+		order_id TEXT PRIMARY KEY,
+		status TEXT NOT NULL,
+		currency TEXT NOT NULL DEFAULT '',
+		amount_cents BIGINT NOT NULL DEFAULT 0,
+		reason TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE IF NOT EXISTS charge_results (
+		idempotency_key TEXT PRIMARY KEY,
+		success BOOLEAN NOT NULL,
+		code TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`
+	if _, err := pool.Exec(ctx, q); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+const paymentColumns = `order_id, status, currency, amount_cents, reason`
+
+func scanPayment(row pgx.Row) (Payment, error) {
+	var p Payment
+	err := row.Scan(&p.OrderID, &p.Status, &p.Amount.Currency, &p.Amount.Amount, &p.Reason)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Payment{}, ErrNotFound
+	}
+	return p, err
+}
+
+func (s *PostgresStore) Payment(ctx context.Context, orderID string) (Payment, error) {
+	// Rows that only hold a lock for a first UpdatePayment have no status.
+	return scanPayment(s.pool.QueryRow(ctx, `SELECT `+paymentColumns+` FROM payments WHERE order_id = $1 AND status <> ''`, orderID))
+}
+
+func (s *PostgresStore) UpdatePayment(ctx context.Context, orderID string, update func(*Payment) error) (Payment, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return Payment{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// A new order's row is inserted first so concurrent updates lock it.
+	if _, err := tx.Exec(ctx, `INSERT INTO payments (order_id, status) VALUES ($1, '') ON CONFLICT (order_id) DO NOTHING`, orderID); err != nil {
+		return Payment{}, err
+	}
+	p, err := scanPayment(tx.QueryRow(ctx, `SELECT `+paymentColumns+` FROM payments WHERE order_id = $1 FOR UPDATE`, orderID))
+	if err != nil {
+		return Payment{}, err
+	}
+	if err := update(&p); err != nil {
+		return Payment{}, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE payments SET status = $2, currency = $3, amount_cents = $4, reason = $5, updated_at = now()
+		WHERE order_id = $1`, orderID, p.Status, p.Amount.Currency, p.Amount.Amount, p.Reason); err != nil {
+		return Payment{}, err
+	}
+	return p, tx.Commit(ctx)
+}
+
+func (s *PostgresStore) ChargeResult(ctx context.Context, key string) (ChargeResult, bool, error) {
+	var r ChargeResult
+	err := s.pool.QueryRow(ctx, `SELECT success, code FROM charge_results WHERE idempotency_key = $1`, key).Scan(&r.Success, &r.Code)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ChargeResult{}, false, nil
+	}
+	if err != nil {
+		return ChargeResult{}, false, err
+	}
+	return r, true, nil
+}
+
+func (s *PostgresStore) SaveChargeResult(ctx context.Context, key string, r ChargeResult) (ChargeResult, error) {
+	// DO UPDATE with no change returns the stored row when one exists.
+	err := s.pool.QueryRow(ctx, `INSERT INTO charge_results (idempotency_key, success, code) VALUES ($1, $2, $3)
+		ON CONFLICT (idempotency_key) DO UPDATE SET idempotency_key = charge_results.idempotency_key
+		RETURNING success, code`, key, r.Success, r.Code).Scan(&r.Success, &r.Code)
+	if err != nil {
+		return ChargeResult{}, err
+	}
+	return r, nil
+}
+
+var _ Store = (*PostgresStore)(nil)
@@ -0,0 +1,24 @@
+package paymentsvc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reliability-lab/pkg/platform/pgtest"
+)
+
+// TestPostgresStore runs the conformance suite on one database, emptied
+// before each case.
+func TestPostgresStore(t *testing.T) {
+	db, err := InitDB(context.Background(), pgtest.Start(t))
+	if err != nil {
+		t.Fatalf("initDB: %v", err)
+	}
+	t.Cleanup(db.Close)
+	testStore(t, func(t *testing.T) Store {
+		if _, err := db.Exec(context.Background(), `TRUNCATE payments, charge_results`); err != nil {
+			t.Fatal(err)
+		}
+		return NewPostgresStore(db)
+	})
+}
@@ -0,0 +1,136 @@
+package paymentsvc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/reliability-lab/gen/payments"
+	"github.com/reliability-lab/pkg/money"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Payment statuses. Charge authorizes an order's payment and captures it once
+// the processor has approved it; Reverse voids an authorization or refunds a
+// capture.
+const (
+	paymentAuthorized = "AUTHORIZED"
+	paymentCaptured   = "CAPTURED"
+	paymentDeclined   = "DECLINED"
+	paymentVoided     = "VOIDED"
+	paymentRefunded   = "REFUNDED"
+)
+
+// codeVoided is the Charge code for an order whose payment was reversed.
+const codeVoided = "VOIDED"
+
+// errVoided makes authorize leave a reversed payment alone.
+var errVoided = errors.New("payment was reversed")
+
+// authorize starts an order's payment. It refuses orders that have been
+// reversed; an order that is already authorized or captured keeps its
+// payment.
+func (s *Server) authorize(ctx context.Context, orderID string, amount money.Money) (bool, string, error) {
+	_, err := s.store.UpdatePayment(ctx, orderID, func(p *Payment) error {
+		switch p.Status {
+		case "", paymentDeclined:
+			p.Status, p.Amount = paymentAuthorized, amount
+		case paymentVoided, paymentRefunded:
+			return errVoided
+		}
+		return nil
+	})
+	if errors.Is(err, errVoided) {
+		return false, codeVoided, nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	return true, "APPROVED", nil
+}
+
+// capture settles an authorization as captured, or declined when the
+// processor refused it. It reports false if Reverse voided the authorization
+// in the meantime.
+func (s *Server) capture(ctx context.Context, orderID string, approved bool) (bool, error) {
+	p, err := s.store.UpdatePayment(ctx, orderID, func(p *Payment) error {
+		switch {
+		case p.Status != paymentAuthorized:
+		case approved:
+			p.Status = paymentCaptured
+		default:
+			p.Status = paymentDeclined
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return p.Status != paymentVoided, nil
+}
+
+func (s *Server) Reverse(ctx context.Context, req *payments.ReverseRequest) (*payments.ReverseResponse, error) {
+	ctx, span := otel.Tracer("payments").Start(ctx, "Reverse")
+	defer span.End()
+	span.SetAttributes(attribute.String("order_id", req.OrderId))
+
+	if req.OrderId == "" {
+		return nil, status.Error(grpccodes.InvalidArgument, "order_id required")
+	}
+
+	reversed := false
+	p, err := s.store.UpdatePayment(ctx, req.OrderId, func(p *Payment) error {
+		reversed = false
+		switch p.Status {
+		case paymentVoided, paymentRefunded:
+			return nil
+		case paymentCaptured:
+			p.Status = paymentRefunded
+		default:
+			p.Status = paymentVoided
+		}
+		p.Reason, reversed = req.Reason, true
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, storeStatus(err, "failed to reverse")
+	}
+	if reversed {
+		paymentsReversalsTotal.WithLabelValues(p.Status).Inc()
+	}
+	span.SetAttributes(attribute.String("status", p.Status))
+
+	// A void refunds nothing, in the currency of the charge if there was one.
+	refunded := money.Money{Currency: p.Amount.Currency}
+	if p.Status == paymentRefunded {
+		refunded = p.Amount
+	}
+	return &payments.ReverseResponse{OrderId: req.OrderId, Status: p.Status, RefundedCents: refunded.Amount,
+		Currency: refunded.Currency, Refunded: refunded.Proto()}, nil
+}
+
+// chargeAmount returns what req charges: amount, or amount_cents and
+// currency from a client that predates Money. If both are sent they must
+// agree. The amount must be positive and in an ISO 4217 currency.
+func chargeAmount(req *payments.ChargeRequest) (money.Money, error) {
+	var m money.Money
+	var err error
+	if req.Amount == nil {
+		m, err = money.New(req.AmountCents, req.Currency)
+	} else {
+		m, err = money.FromProto(req.Amount)
+	}
+	if err != nil {
+		return money.Money{}, status.Errorf(grpccodes.InvalidArgument, "amount: %v", err)
+	}
+	if req.Amount != nil && ((req.AmountCents != 0 && req.AmountCents != m.Amount) || (req.Currency != "" && req.Currency != m.Currency)) {
+		return money.Money{}, status.Errorf(grpccodes.InvalidArgument, "amount_cents %d and currency %q do not match amount %s", req.AmountCents, req.Currency, m)
+	}
+	if m.Amount <= 0 {
+		return money.Money{}, status.Errorf(grpccodes.InvalidArgument, "amount must be positive, got %s", m)
+	}
+	return m, nil
+}
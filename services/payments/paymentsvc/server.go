@@ -0,0 +1,105 @@
+// Package paymentsvc implements the Payments API with a simulated processor.
+package paymentsvc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/reliability-lab/gen/payments"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) Charge(ctx context.Context, req *payments.ChargeRequest) (*payments.ChargeResponse, error) {
+	ctx, span := otel.Tracer("payments").Start(ctx, "Charge")
+	defer span.End()
+
+	amount, err := chargeAmount(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Idempotency: return the stored result if we've seen this key before
+	if cached, ok, err := s.store.ChargeResult(ctx, req.IdempotencyKey); err != nil {
+		return nil, storeStatus(err, "failed to read charge")
+	} else if ok {
+		return &payments.ChargeResponse{Success: cached.Success, Code: cached.Code}, nil
+	}
+
+	// A reversed order is not charged again. Otherwise the payment is
+	// authorized while the processor answers, and Reverse can void it.
+	success, code, err := s.authorize(ctx, req.OrderId, amount)
+	if err != nil {
+		return nil, storeStatus(err, "failed to authorize")
+	}
+	if success {
+		// Fault injection: faults set through the chaos API on payments.charge,
+		// decided per idempotency key when CHAOS_SEED is set
+		ctx = chaos.WithKey(ctx, req.IdempotencyKey)
+		for _, f := range s.chaos.Hit(ctx, chaosCharge, chaos.Call{}) {
+			if err := chaos.Sleep(ctx, s.chaos.Delay(ctx, f)); err != nil {
+				_, _ = s.capture(context.WithoutCancel(ctx), req.OrderId, false)
+				return nil, status.FromContextError(err).Err()
+			}
+			if f.Error != "" {
+				success, code = false, f.Error
+			}
+		}
+		captured, err := s.capture(ctx, req.OrderId, success)
+		if err != nil {
+			return nil, storeStatus(err, "failed to capture")
+		}
+		if !captured {
+			success, code = false, codeVoided
+		}
+	}
+	if !success && code != codeVoided {
+		paymentsDeclinedTotal.Inc()
+	}
+
+	res, err := s.store.SaveChargeResult(ctx, req.IdempotencyKey, ChargeResult{Success: success, Code: code})
+	if err != nil {
+		return nil, storeStatus(err, "failed to save charge")
+	}
+	return &payments.ChargeResponse{Success: res.Success, Code: res.Code}, nil
+}
+
+// storeStatus maps a Store error to a gRPC status.
+func storeStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(grpccodes.DeadlineExceeded, msg+": deadline exceeded")
+	case errors.Is(err, context.Canceled):
+		return status.Error(grpccodes.Canceled, msg+": canceled")
+	}
+	log.Error().Err(err).Msg(msg)
+	return status.Error(grpccodes.Unavailable, msg)
+}
+
+// chaosCharge is the injection point checked by Charge.
+const chaosCharge = "payments.charge"
+
+// Server implements payments.PaymentsServer. Charges are approved unless a
+// payments.charge fault says otherwise, and replayed by idempotency key.
+// Payments and charge outcomes are kept in a Store.
+type Server struct {
+	payments.UnimplementedPaymentsServer
+	chaos *chaos.Registry
+	store Store
+}
+
+// NewServer serves the payments in store and registers the payments.charge
+// injection point on reg.
+func NewServer(store Store, reg *chaos.Registry) *Server {
+	reg.RegisterTarget(chaos.Target{
+		Name:        chaosCharge,
+		Description: "Charge: added latency, and DECLINED instead of APPROVED",
+		Errors:      []string{"DECLINED"},
+	})
+	return &Server{chaos: reg, store: store}
+}
+
+var _ payments.PaymentsServer = (*Server)(nil)
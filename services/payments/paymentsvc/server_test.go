@@ -0,0 +1,195 @@
+package paymentsvc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	moneypb "github.com/reliability-lab/gen/money"
+	"github.com/reliability-lab/gen/payments"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCharge_Idempotency(t *testing.T) {
+	s := NewServer(NewMemoryStore(), chaos.NewRegistry("payments"))
+	ctx := context.Background()
+	req := &payments.ChargeRequest{
+		OrderId:        "order-1",
+		AmountCents:    1000,
+		Currency:       "USD",
+		IdempotencyKey: "idem-key-123",
+	}
+
+	resp1, err := s.Charge(ctx, req)
+	if err != nil {
+		t.Fatalf("first Charge: %v", err)
+	}
+	resp2, err := s.Charge(ctx, req)
+	if err != nil {
+		t.Fatalf("second Charge: %v", err)
+	}
+
+	if resp1.Success != resp2.Success {
+		t.Errorf("Success mismatch: first=%v second=%v", resp1.Success, resp2.Success)
+	}
+	if resp1.Code != resp2.Code {
+		t.Errorf("Code mismatch: first=%q second=%q", resp1.Code, resp2.Code)
+	}
+}
+
+func TestCharge_ForceFail(t *testing.T) {
+	s := NewServer(NewMemoryStore(), chaos.NewRegistry("payments"))
+	ctx := context.Background()
+	if _, err := s.chaos.Set(ctx, chaos.Fault{Target: chaosCharge, Error: "DECLINED"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	req := &payments.ChargeRequest{
+		OrderId:        "order-2",
+		AmountCents:    1000,
+		Currency:       "USD",
+		IdempotencyKey: "idem-force-fail",
+	}
+	resp, err := s.Charge(ctx, req)
+	if err != nil {
+		t.Fatalf("Charge: %v", err)
+	}
+	if resp.Success {
+		t.Error("expected success=false with a DECLINED fault on every call")
+	}
+	if resp.Code != "DECLINED" {
+		t.Errorf("expected code=DECLINED, got %q", resp.Code)
+	}
+}
+
+func TestCharge_RejectsAmounts(t *testing.T) {
+	s := NewServer(NewMemoryStore(), chaos.NewRegistry("payments"))
+	for name, req := range map[string]*payments.ChargeRequest{
+		"no currency":       {AmountCents: 1000},
+		"not ISO 4217":      {Amount: &moneypb.Money{Currency: "usd", MinorUnits: 1000}},
+		"zero":              {Amount: &moneypb.Money{Currency: "USD"}},
+		"negative":          {AmountCents: -1000, Currency: "USD"},
+		"amounts differ":    {AmountCents: 100, Currency: "USD", Amount: &moneypb.Money{Currency: "USD", MinorUnits: 200}},
+		"currencies differ": {AmountCents: 100, Currency: "USD", Amount: &moneypb.Money{Currency: "EUR", MinorUnits: 100}},
+	} {
+		req.OrderId, req.IdempotencyKey = "rejected", "k-"+name
+		if _, err := s.Charge(context.Background(), req); status.Code(err) != grpccodes.InvalidArgument {
+			t.Errorf("%s: %v, want InvalidArgument", name, err)
+		}
+	}
+	if _, err := s.store.Payment(context.Background(), "rejected"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("a rejected charge was authorized: %v", err)
+	}
+}
+
+// TestReverse_VoidKeepsCurrency checks that voiding a declined charge
+// refunds a zero amount in its currency.
+func TestReverse_VoidKeepsCurrency(t *testing.T) {
+	s := NewServer(NewMemoryStore(), chaos.NewRegistry("payments"))
+	ctx := context.Background()
+	if _, err := s.chaos.Set(ctx, chaos.Fault{Target: chaosCharge, Error: "DECLINED"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Charge(ctx, &payments.ChargeRequest{OrderId: "declined", Amount: &moneypb.Money{Currency: "EUR", MinorUnits: 1195}, IdempotencyKey: "k-declined"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.Reverse(ctx, &payments.ReverseRequest{OrderId: "declined"})
+	if want := (&moneypb.Money{Currency: "EUR"}); err != nil || resp.Status != paymentVoided || !proto.Equal(resp.Refunded, want) {
+		t.Errorf("Reverse of a declined charge = %v, %v; want VOIDED with 0 EUR refunded", resp, err)
+	}
+}
+
+func TestCharge_LatencyHonoursDeadline(t *testing.T) {
+	s := NewServer(NewMemoryStore(), chaos.NewRegistry("payments"))
+	fault := chaos.Fault{
+		Target:  chaosCharge,
+		Latency: 200 * time.Millisecond,
+		Model:   chaos.LatencyModel{Distribution: chaos.DistBimodal, Slow: time.Minute, SlowFraction: 1},
+	}
+	if _, err := s.chaos.Set(context.Background(), fault, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := s.Charge(ctx, &payments.ChargeRequest{OrderId: "order-3", AmountCents: 1000, Currency: "USD", IdempotencyKey: "idem-slow"})
+	if status.Code(err) != grpccodes.DeadlineExceeded || time.Since(start) > time.Second {
+		t.Fatalf("got %v after %s, want DeadlineExceeded at the caller's deadline", err, time.Since(start))
+	}
+}
+
+func TestReverse_VoidsOrRefunds(t *testing.T) {
+	s := NewServer(NewMemoryStore(), chaos.NewRegistry("payments"))
+	ctx := context.Background()
+	if _, err := s.Charge(ctx, &payments.ChargeRequest{OrderId: "paid", AmountCents: 1299, Currency: "USD", IdempotencyKey: "k-paid"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		resp, err := s.Reverse(ctx, &payments.ReverseRequest{OrderId: "paid", Reason: "CUSTOMER_REQUEST"})
+		if err != nil || resp.Status != paymentRefunded || resp.RefundedCents != 1299 || resp.Currency != "USD" {
+			t.Errorf("Reverse of a capture = %v, %v; want REFUNDED 1299 USD", resp, err)
+		}
+	}
+	resp, err := s.Reverse(ctx, &payments.ReverseRequest{OrderId: "unpaid"})
+	if err != nil || resp.Status != paymentVoided || resp.RefundedCents != 0 || resp.Refunded == nil || resp.Refunded.MinorUnits != 0 {
+		t.Errorf("Reverse of an uncharged order = %v, %v; want VOIDED with a zero refund", resp, err)
+	}
+	charge, err := s.Charge(ctx, &payments.ChargeRequest{OrderId: "unpaid", AmountCents: 500, Currency: "USD", IdempotencyKey: "k-unpaid"})
+	if err != nil || charge.Success || charge.Code != codeVoided {
+		t.Errorf("Charge after Reverse = %v, %v; want VOIDED", charge, err)
+	}
+	if _, err := s.Reverse(ctx, &payments.ReverseRequest{}); status.Code(err) != grpccodes.InvalidArgument {
+		t.Errorf("Reverse without order_id: %v, want InvalidArgument", err)
+	}
+}
+
+// TestReverse_Money checks that a charge sent as Money is refunded as Money
+// and in the fields that predate it.
+func TestReverse_Money(t *testing.T) {
+	s := NewServer(NewMemoryStore(), chaos.NewRegistry("payments"))
+	ctx := context.Background()
+	amount := &moneypb.Money{Currency: "JPY", MinorUnits: 1949}
+	if _, err := s.Charge(ctx, &payments.ChargeRequest{OrderId: "yen", Amount: amount, IdempotencyKey: "k-yen"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.Reverse(ctx, &payments.ReverseRequest{OrderId: "yen"})
+	if err != nil || !proto.Equal(resp.Refunded, amount) || resp.RefundedCents != 1949 || resp.Currency != "JPY" {
+		t.Errorf("Reverse = %v, %v; want 1949 JPY refunded", resp, err)
+	}
+}
+
+// TestReverse_VoidsChargeInFlight cancels while the processor is still
+// answering: the authorization is voided and the charge is not captured.
+func TestReverse_VoidsChargeInFlight(t *testing.T) {
+	s := NewServer(NewMemoryStore(), chaos.NewRegistry("payments"))
+	ctx := context.Background()
+	if _, err := s.chaos.Set(ctx, chaos.Fault{Target: chaosCharge, Latency: 200 * time.Millisecond}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan *payments.ChargeResponse, 1)
+	go func() {
+		resp, err := s.Charge(ctx, &payments.ChargeRequest{OrderId: "racing", AmountCents: 1299, Currency: "USD", IdempotencyKey: "k-racing"})
+		if err != nil {
+			t.Errorf("Charge: %v", err)
+		}
+		done <- resp
+	}()
+	for {
+		if _, err := s.store.Payment(ctx, "racing"); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rev, err := s.Reverse(ctx, &payments.ReverseRequest{OrderId: "racing"})
+	if err != nil || rev.Status != paymentVoided {
+		t.Fatalf("Reverse = %v, %v; want VOIDED", rev, err)
+	}
+	if charge := <-done; charge == nil || charge.Success || charge.Code != codeVoided {
+		t.Errorf("Charge racing Reverse = %v; want declined with VOIDED", charge)
+	}
+}
@@ -0,0 +1,46 @@
+package paymentsvc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/reliability-lab/pkg/money"
+)
+
+// ErrNotFound is returned for orders without a payment.
+var ErrNotFound = errors.New("payment not found")
+
+// Payment is the latest payment of an order.
+type Payment struct {
+	OrderID string
+	// Status is one of the payment statuses, or "" for an order that has
+	// none yet.
+	Status string
+	Amount money.Money
+	// Reason is the cancellation reason of a reversed payment.
+	Reason string
+}
+
+// ChargeResult is the outcome of a Charge, replayed for its idempotency key.
+type ChargeResult struct {
+	Success bool
+	Code    string
+}
+
+// Store keeps the payment of each order and the outcome of each charge, so
+// that a retried charge or a reversal gets the same answer after a restart
+// or from another replica.
+type Store interface {
+	// Payment returns orderID's payment, and ErrNotFound if it has none.
+	Payment(ctx context.Context, orderID string) (Payment, error)
+	// UpdatePayment locks orderID's payment, or a new one with an empty
+	// Status, lets update change it and stores the result. update's error
+	// is returned as is and nothing is stored.
+	UpdatePayment(ctx context.Context, orderID string, update func(p *Payment) error) (Payment, error)
+	// ChargeResult returns the outcome stored for key; ok is false if there
+	// is none.
+	ChargeResult(ctx context.Context, key string) (r ChargeResult, ok bool, err error)
+	// SaveChargeResult stores r for key unless an outcome is stored already,
+	// and returns the stored one.
+	SaveChargeResult(ctx context.Context, key string, r ChargeResult) (ChargeResult, error)
+}
@@ -0,0 +1,129 @@
+package paymentsvc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/reliability-lab/gen/payments"
+	"github.com/reliability-lab/pkg/money"
+	"github.com/reliability-lab/pkg/platform/chaos"
+)
+
+// testStore is the conformance suite every Store must pass. newStore returns
+// an empty store.
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("UpdatePayment", func(t *testing.T) { testUpdatePayment(t, newStore(t)) })
+	t.Run("UpdateErrorStoresNothing", func(t *testing.T) { testUpdateErrorStoresNothing(t, newStore(t)) })
+	t.Run("UpdatesSerialize", func(t *testing.T) { testUpdatesSerialize(t, newStore(t)) })
+	t.Run("ChargeResultFirstWins", func(t *testing.T) { testChargeResultFirstWins(t, newStore(t)) })
+	t.Run("ReverseIsIdempotent", func(t *testing.T) { testReverseIsIdempotent(t, newStore(t)) })
+}
+
+func testUpdatePayment(t *testing.T, store Store) {
+	ctx := context.Background()
+	if _, err := store.Payment(ctx, "o1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Payment of unknown order: %v, want ErrNotFound", err)
+	}
+	amount := money.Money{Amount: 1299, Currency: "USD"}
+	p, err := store.UpdatePayment(ctx, "o1", func(p *Payment) error {
+		if p.Status != "" {
+			t.Errorf("new payment has status %q", p.Status)
+		}
+		p.Status, p.Amount = paymentAuthorized, amount
+		return nil
+	})
+	if err != nil || p.Status != paymentAuthorized {
+		t.Fatalf("UpdatePayment = %+v, %v", p, err)
+	}
+	if _, err := store.UpdatePayment(ctx, "o1", func(p *Payment) error {
+		p.Status, p.Reason = paymentVoided, "customer request"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := Payment{OrderID: "o1", Status: paymentVoided, Amount: amount, Reason: "customer request"}
+	if got, err := store.Payment(ctx, "o1"); err != nil || got != want {
+		t.Errorf("Payment = %+v, %v; want %+v", got, err, want)
+	}
+}
+
+func testUpdateErrorStoresNothing(t *testing.T, store Store) {
+	ctx := context.Background()
+	boom := errors.New("boom")
+	if _, err := store.UpdatePayment(ctx, "o1", func(p *Payment) error {
+		p.Status = paymentAuthorized
+		return boom
+	}); !errors.Is(err, boom) {
+		t.Errorf("UpdatePayment: %v, want the update's error", err)
+	}
+	if p, err := store.Payment(ctx, "o1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Payment after a failed update = %+v, %v; want ErrNotFound", p, err)
+	}
+}
+
+// testUpdatesSerialize races updates of one payment: none may be lost.
+func testUpdatesSerialize(t *testing.T, store Store) {
+	ctx := context.Background()
+	const n = 20
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.UpdatePayment(ctx, "o1", func(p *Payment) error {
+				p.Status = paymentAuthorized
+				p.Amount.Amount++
+				return nil
+			}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if p, err := store.Payment(ctx, "o1"); err != nil || p.Amount.Amount != n {
+		t.Errorf("Payment after %d increments = %+v, %v", n, p, err)
+	}
+}
+
+func testChargeResultFirstWins(t *testing.T, store Store) {
+	ctx := context.Background()
+	if _, ok, err := store.ChargeResult(ctx, "k1"); ok || err != nil {
+		t.Errorf("ChargeResult of unknown key: ok %v, %v", ok, err)
+	}
+	first := ChargeResult{Success: true, Code: "APPROVED"}
+	if got, err := store.SaveChargeResult(ctx, "k1", first); err != nil || got != first {
+		t.Fatalf("SaveChargeResult = %+v, %v", got, err)
+	}
+	if got, err := store.SaveChargeResult(ctx, "k1", ChargeResult{Code: "DECLINED"}); err != nil || got != first {
+		t.Errorf("second SaveChargeResult = %+v, %v; want the first %+v", got, err, first)
+	}
+	if got, ok, err := store.ChargeResult(ctx, "k1"); err != nil || !ok || got != first {
+		t.Errorf("ChargeResult = %+v, %v, %v; want %+v", got, ok, err, first)
+	}
+}
+
+// testReverseIsIdempotent reverses a captured charge twice through a Server on
+// the store: both calls report the same refund and the payment is refunded once.
+func testReverseIsIdempotent(t *testing.T, store Store) {
+	ctx := context.Background()
+	s := NewServer(store, chaos.NewRegistry("payments"))
+	if _, err := s.Charge(ctx, &payments.ChargeRequest{OrderId: "o1", AmountCents: 1299, Currency: "USD", IdempotencyKey: "k1"}); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		resp, err := s.Reverse(ctx, &payments.ReverseRequest{OrderId: "o1", Reason: "CUSTOMER_REQUEST"})
+		if err != nil || resp.Status != paymentRefunded || resp.RefundedCents != 1299 {
+			t.Errorf("Reverse = %v, %v; want REFUNDED 1299", resp, err)
+		}
+	}
+	want := Payment{OrderID: "o1", Status: paymentRefunded, Amount: money.Money{Amount: 1299, Currency: "USD"}, Reason: "CUSTOMER_REQUEST"}
+	if got, err := store.Payment(ctx, "o1"); err != nil || got != want {
+		t.Errorf("Payment = %+v, %v; want %+v", got, err, want)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(*testing.T) Store { return NewMemoryStore() })
+}
@@ -0,0 +1,219 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"github.com/reliability-lab/tests/harness"
+)
+
+type cancelResponse struct {
+	OrderID       string `json:"order_id"`
+	OrderStatus   string `json:"order_status"`
+	Reason        string `json:"reason"`
+	PaymentStatus string `json:"payment_status"`
+	RefundedCents int64  `json:"refunded_cents"`
+	Error         string `json:"error"`
+}
+
+// cancelOrder sends POST /orders/{id}/cancel with reason.
+func cancelOrder(t *testing.T, h *harness.Harness, id, reason string) (int, cancelResponse) {
+	t.Helper()
+	b, _ := json.Marshal(map[string]string{"reason": reason})
+	resp, err := http.Post(h.GatewayURL+"/orders/"+id+"/cancel", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out cancelResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestCancel_RefundsPaidOrder(t *testing.T) {
+	h := harness.Start(t)
+	_, placed := postOrder(t, h, order("cancel-paid"))
+	if placed.OrderStatus != "PAID" {
+		t.Fatalf("POST /orders: %+v, want PAID", placed)
+	}
+
+	for range 2 {
+		code, out := cancelOrder(t, h, placed.OrderID, "CUSTOMER_REQUEST")
+		if code != http.StatusOK || out.OrderStatus != "CANCELLED" || out.PaymentStatus != "REFUNDED" || out.RefundedCents != 1299 {
+			t.Fatalf("cancel: %d %+v, want 200 CANCELLED with 1299 refunded", code, out)
+		}
+	}
+	if got := getStatus(t, h, placed.OrderID); got != "CANCELLED" {
+		t.Errorf("GET /orders/{id} status %q, want CANCELLED", got)
+	}
+	// A retry of the checkout replays the checkout response without
+	// charging again.
+	if resp, out := postOrder(t, h, order("cancel-paid")); out != placed || resp.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("checkout retry after cancel: %+v, want the replayed %+v", out, placed)
+	}
+	if got := getStatus(t, h, placed.OrderID); got != "CANCELLED" {
+		t.Errorf("GET /orders/{id} after checkout retry: status %q, want CANCELLED", got)
+	}
+}
+
+func TestCancel_Rejected(t *testing.T) {
+	h := harness.Start(t)
+	setFault(t, h.Payments.Chaos, chaos.Fault{Target: "payments.charge", Error: "DECLINED"})
+	_, placed := postOrder(t, h, order("cancel-declined"))
+
+	if code, out := cancelOrder(t, h, placed.OrderID, "CUSTOMER_REQUEST"); code != http.StatusConflict {
+		t.Errorf("cancel of PAYMENT_FAILED: %d %+v, want 409", code, out)
+	}
+	if code, out := cancelOrder(t, h, placed.OrderID, "CHANGED_MIND"); code != http.StatusBadRequest {
+		t.Errorf("cancel with unknown reason: %d %+v, want 400", code, out)
+	}
+	if code, out := cancelOrder(t, h, "no-such-order", "OTHER"); code != http.StatusNotFound {
+		t.Errorf("cancel of unknown order: %d %+v, want 404", code, out)
+	}
+	resp, err := http.Get(h.GatewayURL + "/orders/" + placed.OrderID + "/cancel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /orders/{id}/cancel: %d, want 405", resp.StatusCode)
+	}
+}
+
+// TestCancel_DuringCharge cancels while the processor is still answering:
+// the authorization is voided and the order ends CANCELLED, not PAID.
+func TestCancel_DuringCharge(t *testing.T) {
+	h := harness.Start(t)
+	setFault(t, h.Payments.Chaos, chaos.Fault{Target: "payments.charge", Latency: 300 * time.Millisecond})
+
+	resp, placed := postOrder(t, h, order("cancel-in-flight"), "Prefer", "respond-async")
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST /orders: %d %+v, want 202", resp.StatusCode, placed)
+	}
+	code, out := cancelOrder(t, h, placed.OrderID, "FRAUD_SUSPECTED")
+	if code != http.StatusOK || out.PaymentStatus != "VOIDED" || out.RefundedCents != 0 {
+		t.Fatalf("cancel: %d %+v, want 200 VOIDED", code, out)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(h.GatewayURL + placed.Operation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var op struct {
+			Done   bool           `json:"done"`
+			Result *orderResponse `json:"result"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&op)
+		resp.Body.Close()
+		if op.Done {
+			if op.Result == nil || op.Result.OrderStatus != "CANCELLED" || op.Result.PaymentSuccess {
+				t.Fatalf("operation result %+v, want CANCELLED and not charged", op.Result)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("operation not done after 5s")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := getStatus(t, h, placed.OrderID); got != "CANCELLED" {
+		t.Errorf("order status %q, want CANCELLED", got)
+	}
+}
+
+// TestCancel_GivesBackStock cancels a paid order, whose committed items go
+// back on hand, and an order still being charged, whose hold is released.
+func TestCancel_GivesBackStock(t *testing.T) {
+	h := harness.Start(t)
+	before := stock(t, h, "TSHIRT-S")
+	items := func(key string) map[string]any {
+		return map[string]any{
+			"user_id": "u1", "currency": "USD", "idempotency_key": key,
+			"tax_jurisdiction": "US-OR", "items": []map[string]any{{"sku": "TSHIRT-S", "quantity": 2}},
+		}
+	}
+
+	_, paid := postOrder(t, h, items("cancel-stock-paid"))
+	if got := stock(t, h, "TSHIRT-S"); paid.OrderStatus != "PAID" || got.OnHand != before.OnHand-2 {
+		t.Fatalf("POST /orders: %+v with stock %v, want PAID with 2 sold", paid, got)
+	}
+	for range 2 {
+		if code, out := cancelOrder(t, h, paid.OrderID, "CUSTOMER_REQUEST"); code != http.StatusOK || out.PaymentStatus != "REFUNDED" {
+			t.Fatalf("cancel of PAID: %d %+v, want 200 REFUNDED", code, out)
+		}
+		if got := stock(t, h, "TSHIRT-S"); got.OnHand != before.OnHand || got.Reserved != 0 {
+			t.Errorf("stock after cancelling a PAID order: %v, want %v", got, before)
+		}
+	}
+
+	setFault(t, h.Payments.Chaos, chaos.Fault{Target: "payments.charge", Latency: 300 * time.Millisecond})
+	resp, created := postOrder(t, h, items("cancel-stock-created"), "Prefer", "respond-async")
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST /orders: %d %+v, want 202", resp.StatusCode, created)
+	}
+	if code, out := cancelOrder(t, h, created.OrderID, "OTHER"); code != http.StatusOK || out.PaymentStatus != "VOIDED" {
+		t.Fatalf("cancel of CREATED: %d %+v, want 200 VOIDED", code, out)
+	}
+	if got := stock(t, h, "TSHIRT-S"); got.OnHand != before.OnHand || got.Reserved != 0 {
+		t.Errorf("stock after cancelling a CREATED order: %v, want %v", got, before)
+	}
+}
+
+func TestOrderETags(t *testing.T) {
+	h := harness.Start(t)
+	_, placed := postOrder(t, h, order("etag"))
+	get := func(ifNoneMatch string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, h.GatewayURL+"/orders/"+placed.OrderID, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// Created, then paid: version 2.
+	resp := get("")
+	if etag := resp.Header.Get("ETag"); resp.StatusCode != http.StatusOK || etag != `"2"` {
+		t.Fatalf("GET /orders/{id}: %d ETag %q, want 200 \"2\"", resp.StatusCode, etag)
+	}
+	if resp := get(`"1", W/"2"`); resp.StatusCode != http.StatusNotModified || resp.Header.Get("ETag") != `"2"` {
+		t.Errorf("GET with a matching If-None-Match: %d ETag %q, want 304 \"2\"", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+
+	cancel := func(ifMatch string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, h.GatewayURL+"/orders/"+placed.OrderID+"/cancel", bytes.NewReader([]byte(`{"reason":"OTHER"}`)))
+		req.Header.Set("If-Match", ifMatch)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	for _, stale := range []string{`"1"`, `W/"2"`, "2"} {
+		if resp := cancel(stale); resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("cancel with If-Match %s: %d, want 412", stale, resp.StatusCode)
+		}
+	}
+	if got := getStatus(t, h, placed.OrderID); got != "PAID" {
+		t.Fatalf("status after rejected cancels %q, want PAID", got)
+	}
+	if resp := cancel(`"2"`); resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"3"` {
+		t.Errorf("cancel with If-Match \"2\": %d ETag %q, want 200 \"3\"", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+	if resp := get(`"2"`); resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"3"` {
+		t.Errorf("GET with a stale If-None-Match: %d ETag %q, want 200 \"3\"", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+}
@@ -0,0 +1,548 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reliability-lab/gen/inventory"
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"github.com/reliability-lab/tests/harness"
+)
+
+type orderResponse struct {
+	OrderID        string `json:"order_id"`
+	OrderStatus    string `json:"order_status"`
+	PaymentSuccess bool   `json:"payment_success"`
+	PaymentCode    string `json:"payment_code"`
+	Operation      string `json:"operation"`
+	Error          string `json:"error"`
+}
+
+// postOrder sends POST /orders with the given headers ("key", "value", ...).
+func postOrder(t *testing.T, h *harness.Harness, body map[string]any, headers ...string) (*http.Response, orderResponse) {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req, err := http.NewRequest(http.MethodPost, h.GatewayURL+"/orders", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	return do(t, req)
+}
+
+func do(t *testing.T, req *http.Request) (*http.Response, orderResponse) {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	var out orderResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp, out
+}
+
+func order(key string) map[string]any {
+	return map[string]any{"user_id": "u1", "amount_cents": 1299, "currency": "USD", "idempotency_key": key}
+}
+
+// money is a money.Money as the REST routes render it.
+type money struct {
+	Currency   string `json:"currency"`
+	MinorUnits int64  `json:"minor_units"`
+}
+
+// storedOrder is the part of GET /orders/{id} the tests look at.
+type storedOrder struct {
+	UserID                string `json:"user_id"`
+	Status                string `json:"status"`
+	AmountCents           int64  `json:"amount_cents"`
+	Currency              string `json:"currency"`
+	Subtotal              money  `json:"subtotal"`
+	Tax                   money  `json:"tax"`
+	Total                 money  `json:"total"`
+	SettlementCurrency    string `json:"settlement_currency"`
+	SettlementAmountCents int64  `json:"settlement_amount_cents"`
+	Settlement            money  `json:"settlement"`
+	FXRate                string `json:"fx_rate"`
+	FXRateAsOf            string `json:"fx_rate_as_of"`
+	Discounts             []struct {
+		CouponCode string `json:"coupon_code"`
+		Amount     money  `json:"amount"`
+	} `json:"discounts"`
+	TaxJurisdiction string `json:"tax_jurisdiction"`
+	TaxRulesVersion string `json:"tax_rules_version"`
+	Taxes           []struct {
+		Rate string `json:"rate"`
+	} `json:"taxes"`
+}
+
+// getOrder reads the order through the gateway's REST route.
+func getOrder(t *testing.T, h *harness.Harness, id string) storedOrder {
+	t.Helper()
+	resp, err := http.Get(h.GatewayURL + "/orders/" + id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out storedOrder
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /orders/%s: %d %v", id, resp.StatusCode, err)
+	}
+	return out
+}
+
+func getStatus(t *testing.T, h *harness.Harness, id string) string {
+	t.Helper()
+	return getOrder(t, h, id).Status
+}
+
+func setFault(t *testing.T, reg *chaos.Registry, f chaos.Fault) {
+	t.Helper()
+	f, err := reg.Set(context.Background(), f, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { reg.Clear(context.Background(), f.ID) })
+}
+
+func TestCheckout(t *testing.T) {
+	h := harness.Start(t)
+
+	resp, out := postOrder(t, h, order("checkout-1"))
+	if resp.StatusCode != http.StatusOK || out.OrderStatus != "PAID" || !out.PaymentSuccess || out.PaymentCode != "APPROVED" {
+		t.Fatalf("POST /orders: %d %+v, want 200 PAID APPROVED", resp.StatusCode, out)
+	}
+	if got := getStatus(t, h, out.OrderID); got != "PAID" {
+		t.Errorf("GET /orders/{id} status %q, want PAID", got)
+	}
+}
+
+// TestCheckout_V1 checks that POST /v1/orders is the full checkout, not a
+// bare orders.CreateOrder.
+func TestCheckout_V1(t *testing.T) {
+	h := harness.Start(t)
+
+	b, _ := json.Marshal(order("checkout-v1"))
+	req, err := http.NewRequest(http.MethodPost, h.GatewayURL+"/v1/orders", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, out := do(t, req)
+	if resp.StatusCode != http.StatusOK || out.OrderStatus != "PAID" || !out.PaymentSuccess {
+		t.Fatalf("POST /v1/orders: %d %+v, want 200 PAID", resp.StatusCode, out)
+	}
+	if got := getStatus(t, h, out.OrderID); got != "PAID" {
+		t.Errorf("GET /orders/{id} status %q, want PAID", got)
+	}
+}
+
+func TestCheckout_Async(t *testing.T) {
+	h := harness.Start(t)
+
+	resp, out := postOrder(t, h, order("checkout-async"), "Prefer", "respond-async")
+	if resp.StatusCode != http.StatusAccepted || out.Operation == "" {
+		t.Fatalf("POST /orders: %d %+v, want 202 with an operation", resp.StatusCode, out)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(h.GatewayURL + out.Operation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var op struct {
+			Done   bool           `json:"done"`
+			Result *orderResponse `json:"result"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&op)
+		resp.Body.Close()
+		if op.Done {
+			if op.Result == nil || op.Result.OrderStatus != "PAID" {
+				t.Fatalf("operation result %+v, want PAID", op.Result)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("operation not done after 5s")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := getStatus(t, h, out.OrderID); got != "PAID" {
+		t.Errorf("order status %q, want PAID", got)
+	}
+}
+
+func TestCheckout_PaymentDeclined(t *testing.T) {
+	h := harness.Start(t)
+	setFault(t, h.Payments.Chaos, chaos.Fault{Target: "payments.charge", Error: "DECLINED"})
+
+	resp, out := postOrder(t, h, order("checkout-declined"))
+	if resp.StatusCode != http.StatusOK || out.OrderStatus != "PAYMENT_FAILED" || out.PaymentCode != "DECLINED" {
+		t.Fatalf("POST /orders: %d %+v, want 200 PAYMENT_FAILED DECLINED", resp.StatusCode, out)
+	}
+}
+
+func TestCheckout_RetriesUnavailablePayments(t *testing.T) {
+	h := harness.Start(t)
+	// Only the first Charge attempt fails; the gateway tags attempts with
+	// chaos.KeyMetadata = <idempotency key>#<attempt>.
+	setFault(t, h.Payments.Chaos, chaos.Fault{
+		Target:   chaos.TargetGRPCServer,
+		Method:   "/payments.Payments/Charge",
+		Metadata: map[string]string{chaos.KeyMetadata: "checkout-retry#0"},
+		Error:    "UNAVAILABLE",
+	})
+
+	resp, out := postOrder(t, h, order("checkout-retry"))
+	if resp.StatusCode != http.StatusOK || out.OrderStatus != "PAID" {
+		t.Fatalf("POST /orders: %d %+v, want 200 PAID after a retry", resp.StatusCode, out)
+	}
+}
+
+func TestCheckout_PaymentsDownThenRecovered(t *testing.T) {
+	h := harness.Start(t)
+	f, err := h.Payments.Chaos.Set(context.Background(), chaos.Fault{
+		Target: chaos.TargetGRPCServer,
+		Method: "/payments.Payments/Charge",
+		Error:  "UNAVAILABLE",
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, out := postOrder(t, h, order("checkout-down"))
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("POST /orders with payments down: %d %+v, want 500", resp.StatusCode, out)
+	}
+	created, err := h.OrdersClient.CreateOrder(context.Background(), &orders.CreateOrderRequest{
+		UserId: "u1", AmountCents: 1299, Currency: "USD", IdempotencyKey: "checkout-down",
+	})
+	if err != nil || created.Status != "CREATED" {
+		t.Fatalf("order after failed checkout: %v %v, want it left CREATED", created, err)
+	}
+
+	// The client retries with the same key once payments is back.
+	h.Payments.Chaos.Clear(context.Background(), f.ID)
+	resp, out = postOrder(t, h, order("checkout-down"))
+	if resp.StatusCode != http.StatusOK || out.OrderStatus != "PAID" || out.OrderID != created.OrderId {
+		t.Fatalf("retry: %d %+v, want 200 PAID for order %s", resp.StatusCode, out, created.OrderId)
+	}
+}
+
+func TestCheckout_StatusWriteFailsAfterCharge(t *testing.T) {
+	h := harness.Start(t)
+	f, err := h.Orders.Chaos.Set(context.Background(), chaos.Fault{
+		Target: chaos.TargetGRPCServer,
+		Method: "/orders.Orders/UpdateOrderStatus",
+		Error:  "UNAVAILABLE",
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The charge went through, so the checkout is not failed: the gateway
+	// keeps recording the outcome in the background.
+	resp, out := postOrder(t, h, order("checkout-unrecorded"))
+	if resp.StatusCode != http.StatusAccepted || out.OrderStatus != "CREATED" || out.Operation == "" {
+		t.Fatalf("POST /orders with status writes failing: %d %+v, want 202 CREATED with an operation", resp.StatusCode, out)
+	}
+	h.Orders.Chaos.Clear(context.Background(), f.ID)
+	deadline := time.Now().Add(10 * time.Second)
+	for getStatus(t, h, out.OrderID) != "PAID" {
+		if time.Now().After(deadline) {
+			t.Fatal("order not PAID 10s after orders recovered")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestCheckout_Idempotency(t *testing.T) {
+	h := harness.Start(t)
+
+	_, first := postOrder(t, h, order("idem-body"))
+	_, second := postOrder(t, h, order("idem-body"))
+	if first.OrderID == "" || first.OrderID != second.OrderID || second.OrderStatus != "PAID" {
+		t.Errorf("same idempotency_key: %+v then %+v, want the same PAID order", first, second)
+	}
+
+	body := order("")
+	delete(body, "idempotency_key")
+	resp1, out1 := postOrder(t, h, body, "Idempotency-Key", "idem-header")
+	resp2, out2 := postOrder(t, h, body, "Idempotency-Key", "idem-header")
+	if resp2.Header.Get("Idempotent-Replayed") != "true" || out1 != out2 || resp1.StatusCode != resp2.StatusCode {
+		t.Errorf("Idempotency-Key replay: %d %+v then %d %+v (replayed=%q), want the stored response",
+			resp1.StatusCode, out1, resp2.StatusCode, out2, resp2.Header.Get("Idempotent-Replayed"))
+	}
+	body["amount_cents"] = 1
+	if resp, _ := postOrder(t, h, body, "Idempotency-Key", "idem-header"); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("reused Idempotency-Key with another body: %d, want 422", resp.StatusCode)
+	}
+}
+
+// TestCheckout_IdempotencyPerClient sends one key from two authenticated
+// users: each gets and pays for an order of its own.
+func TestCheckout_IdempotencyPerClient(t *testing.T) {
+	h := harness.Start(t, harness.WithAuthTokens("u1-token=user:u1", "u2-token=user:u2"))
+
+	_, first := postOrder(t, h, order("idem-shared"), "Authorization", "Bearer u1-token")
+	body := order("idem-shared")
+	body["user_id"] = "u2"
+	resp, second := postOrder(t, h, body, "Authorization", "Bearer u2-token")
+	if resp.StatusCode != http.StatusOK || second.OrderID == first.OrderID || second.OrderStatus != "PAID" {
+		t.Fatalf("key of user:u1 reused by user:u2: %d %+v after %+v, want a PAID order of its own", resp.StatusCode, second, first)
+	}
+	if o := getOrder(t, h, second.OrderID); o.UserID != "u2" {
+		t.Errorf("order of user:u2 belongs to %q", o.UserID)
+	}
+
+	// Keys of unauthenticated clients are not scoped: orders refuses to hand
+	// one user's order to another.
+	_, first = postOrder(t, h, order("idem-anonymous"), "Authorization", "Bearer forged-1")
+	body = order("idem-anonymous")
+	body["user_id"] = "u2"
+	resp, second = postOrder(t, h, body, "Authorization", "Bearer forged-2")
+	if resp.StatusCode != http.StatusUnprocessableEntity || second.OrderID != "" {
+		t.Errorf("key of u1 reused for u2 without a token: %d %+v after %+v, want 422", resp.StatusCode, second, first)
+	}
+}
+
+func TestCheckout_ConcurrentDuplicates(t *testing.T) {
+	h := harness.Start(t)
+
+	const n = 8
+	ids := make([]string, n)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b, _ := json.Marshal(order("idem-concurrent"))
+			resp, err := http.Post(h.GatewayURL+"/orders", "application/json", bytes.NewReader(b))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			var out orderResponse
+			_ = json.NewDecoder(resp.Body).Decode(&out)
+			ids[i] = out.OrderID
+		}()
+	}
+	wg.Wait()
+	for i, id := range ids {
+		if id == "" || id != ids[0] {
+			t.Fatalf("request %d got order %q, want all %q", i, id, ids[0])
+		}
+	}
+	if got := getStatus(t, h, ids[0]); got != "PAID" {
+		t.Errorf("order status %q, want PAID", got)
+	}
+}
+
+func TestCheckout_LineItems(t *testing.T) {
+	h := harness.Start(t)
+
+	body := map[string]any{
+		"user_id": "u1", "currency": "USD", "idempotency_key": "checkout-items",
+		"tax_jurisdiction": "US-OR", "items": []map[string]any{{"sku": "TSHIRT-M", "quantity": 2}, {"sku": "MUG-350", "quantity": 1}},
+	}
+	resp, out := postOrder(t, h, body)
+	if resp.StatusCode != http.StatusOK || out.OrderStatus != "PAID" {
+		t.Fatalf("POST /orders with items: %d %+v, want 200 PAID", resp.StatusCode, out)
+	}
+	got, err := h.OrdersClient.GetOrder(context.Background(), &orders.GetOrderRequest{OrderId: out.OrderID})
+	if err != nil || got.AmountCents != 2*1299+899 || len(got.Items) != 2 || got.Items[1].Sku != "MUG-350" {
+		t.Fatalf("stored order %v, %v; want 3497 cents with both items", got, err)
+	}
+
+	body["idempotency_key"] = "checkout-items-mismatch"
+	body["amount_cents"] = 1299
+	if resp, out := postOrder(t, h, body); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /orders with a wrong total: %d %+v, want 400", resp.StatusCode, out)
+	}
+	body["idempotency_key"] = "checkout-items-unknown"
+	body["amount_cents"] = 0
+	body["items"] = []map[string]any{{"sku": "NO-SUCH-SKU", "quantity": 1}}
+	if resp, out := postOrder(t, h, body); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /orders with an unknown sku: %d %+v, want 400", resp.StatusCode, out)
+	}
+
+	products, err := http.Get(h.GatewayURL + "/v1/products/lab-mug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer products.Body.Close()
+	var p struct {
+		Product struct {
+			Skus []struct {
+				Sku string `json:"sku"`
+			} `json:"skus"`
+		} `json:"product"`
+	}
+	if err := json.NewDecoder(products.Body).Decode(&p); err != nil || products.StatusCode != http.StatusOK || len(p.Product.Skus) != 1 {
+		t.Errorf("GET /v1/products/lab-mug: %d %+v %v", products.StatusCode, p, err)
+	}
+}
+
+func stock(t *testing.T, h *harness.Harness, sku string) *inventory.GetStockResponse {
+	t.Helper()
+	got, err := h.InventoryClient.GetStock(context.Background(), &inventory.GetStockRequest{Sku: sku})
+	if err != nil {
+		t.Fatalf("GetStock(%s): %v", sku, err)
+	}
+	return got
+}
+
+// TestCheckout_NoOversell races more checkouts than there is stock of one
+// SKU: exactly the stock is sold and the rest end OUT_OF_STOCK uncharged.
+func TestCheckout_NoOversell(t *testing.T) {
+	const onHand, buyers = 5, 30
+	h := harness.Start(t)
+	if _, err := h.InventoryClient.SetStock(context.Background(), &inventory.SetStockRequest{Sku: "MUG-350", OnHand: onHand}); err != nil {
+		t.Fatal(err)
+	}
+
+	results := make([]orderResponse, buyers)
+	var wg sync.WaitGroup
+	for i := range buyers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, out := postOrder(t, h, map[string]any{
+				"user_id": "u1", "currency": "USD", "idempotency_key": fmt.Sprintf("hammer-%d", i),
+				"tax_jurisdiction": "US-OR", "items": []map[string]any{{"sku": "MUG-350", "quantity": 1}},
+			})
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("POST /orders: %d %+v", resp.StatusCode, out)
+			}
+			results[i] = out
+		}()
+	}
+	wg.Wait()
+
+	counts := map[string]int{}
+	for _, out := range results {
+		counts[out.OrderStatus]++
+		if out.OrderStatus == "OUT_OF_STOCK" && out.PaymentCode != "" {
+			t.Errorf("out-of-stock order %s was charged: %+v", out.OrderID, out)
+		}
+	}
+	if counts["PAID"] != onHand || counts["OUT_OF_STOCK"] != buyers-onHand {
+		t.Errorf("statuses %v, want %d PAID and %d OUT_OF_STOCK", counts, onHand, buyers-onHand)
+	}
+	if got := stock(t, h, "MUG-350"); got.OnHand != 0 || got.Reserved != 0 {
+		t.Errorf("stock after the rush: %v, want none left", got)
+	}
+}
+
+func TestCheckout_DeclineReleasesStock(t *testing.T) {
+	h := harness.Start(t)
+	before := stock(t, h, "TSHIRT-S")
+	setFault(t, h.Payments.Chaos, chaos.Fault{Target: "payments.charge", Error: "DECLINED"})
+
+	body := map[string]any{
+		"user_id": "u1", "currency": "USD", "idempotency_key": "checkout-declined-items",
+		"tax_jurisdiction": "US-OR", "items": []map[string]any{{"sku": "TSHIRT-S", "quantity": 3}},
+	}
+	resp, out := postOrder(t, h, body)
+	if resp.StatusCode != http.StatusOK || out.OrderStatus != "PAYMENT_FAILED" {
+		t.Fatalf("POST /orders: %d %+v, want 200 PAYMENT_FAILED", resp.StatusCode, out)
+	}
+	if got := stock(t, h, "TSHIRT-S"); got.OnHand != before.OnHand || got.Reserved != 0 {
+		t.Errorf("stock after a decline: %v, want %v", got, before)
+	}
+
+	var rest struct {
+		Sku       string `json:"sku"`
+		Available string `json:"available"`
+	}
+	r, err := http.Get(h.GatewayURL + "/v1/inventory/TSHIRT-S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&rest); err != nil || r.StatusCode != http.StatusOK || rest.Available != fmt.Sprint(before.OnHand) {
+		t.Errorf("GET /v1/inventory/TSHIRT-S: %d %+v %v", r.StatusCode, rest, err)
+	}
+}
+
+// TestCheckout_CommitFailsAfterCharge fails the stock commit of a charged
+// order: the checkout fails instead of reporting PAID, and the order is
+// settled once inventory recovers.
+func TestCheckout_CommitFailsAfterCharge(t *testing.T) {
+	h := harness.Start(t)
+	before := stock(t, h, "TSHIRT-S")
+	f, err := h.Inventory.Chaos.Set(context.Background(), chaos.Fault{
+		Target: chaos.TargetGRPCServer,
+		Method: "/inventory.Inventory/Commit",
+		Error:  "UNAVAILABLE",
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := map[string]any{
+		"user_id": "u1", "currency": "USD", "idempotency_key": "checkout-uncommitted",
+		"tax_jurisdiction": "US-OR", "items": []map[string]any{{"sku": "TSHIRT-S", "quantity": 2}},
+	}
+	resp, out := postOrder(t, h, body)
+	if resp.StatusCode != http.StatusInternalServerError || out.OrderStatus == "PAID" {
+		t.Fatalf("POST /orders with commits failing: %d %+v, want 500", resp.StatusCode, out)
+	}
+	if got := stock(t, h, "TSHIRT-S"); got.OnHand != before.OnHand || got.Reserved != 2 {
+		t.Errorf("stock while commits fail: %v, want 2 still held", got)
+	}
+
+	h.Inventory.Chaos.Clear(context.Background(), f.ID)
+	_, out = postOrder(t, h, body)
+	if out.OrderStatus != "PAID" {
+		t.Fatalf("retry after inventory recovered: %+v, want PAID", out)
+	}
+	if got := stock(t, h, "TSHIRT-S"); got.OnHand != before.OnHand-2 || got.Reserved != 0 {
+		t.Errorf("stock after the retry: %v, want 2 taken", got)
+	}
+}
+
+// TestGetOrder_JSON checks that the unversioned route keeps int64 fields as
+// JSON numbers while /v1/ follows the proto3 JSON mapping, and that only the
+// orders surface is transcoded.
+func TestGetOrder_JSON(t *testing.T) {
+	h := harness.Start(t)
+	_, out := postOrder(t, h, order("checkout-json"))
+
+	for path, want := range map[string]any{"/orders/": float64(1299), "/v1/orders/": "1299"} {
+		resp, err := http.Get(h.GatewayURL + path + out.OrderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]any
+		err = json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if err != nil || got["amount_cents"] != want {
+			t.Errorf("GET %s{id} amount_cents = %#v, %v; want %#v", path, got["amount_cents"], err, want)
+		}
+	}
+
+	for _, path := range []string{"/v1/payments/charges", "/v1/notifications/receipts", "/payments.Payments/Charge", "/notifications.Notifications/SendReceipt", "/orders.Orders/CreateOrder", "/orders.Orders/CancelOrder"} {
+		resp, err := http.Post(h.GatewayURL+path, "application/json", bytes.NewReader([]byte(`{"order_id":"`+out.OrderID+`"}`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		// Connect answers methods of a served service with Unimplemented.
+		if resp.StatusCode != http.StatusNotFound && resp.StatusCode != http.StatusNotImplemented {
+			t.Errorf("POST %s: %d, want 404 or 501", path, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/reliability-lab/tests/harness"
+)
+
+func TestCheckout_Coupons(t *testing.T) {
+	h := harness.Start(t)
+	body := map[string]any{
+		"user_id": "u1", "currency": "USD", "idempotency_key": "checkout-coupons", "coupon_codes": []string{"welcome10", "MUGS3FOR2"},
+		"tax_jurisdiction": "US-OR", "items": []map[string]any{{"sku": "MUG-350", "quantity": 3}},
+	}
+	resp, out := postOrder(t, h, body)
+	if resp.StatusCode != http.StatusOK || out.OrderStatus != "PAID" {
+		t.Fatalf("POST /orders with coupons: %d %+v, want 200 PAID", resp.StatusCode, out)
+	}
+
+	if got := getOrder(t, h, out.OrderID); got.Subtotal.MinorUnits != 2697 || got.Total.MinorUnits != 1529 ||
+		len(got.Discounts) != 2 || got.Discounts[0].CouponCode != "WELCOME10" || got.Discounts[1].Amount.MinorUnits != 899 {
+		t.Errorf("GET /orders/{id} = %+v; want 2697 less 269 and 899", got)
+	}
+
+	// WELCOME10 is once per user.
+	body["idempotency_key"], body["coupon_codes"] = "checkout-coupons-again", []string{"WELCOME10"}
+	resp, _ = postOrder(t, h, body)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("second WELCOME10 order: %d, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,57 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/reliability-lab/tests/harness"
+)
+
+func TestCheckout_SettlesInAnotherCurrency(t *testing.T) {
+	h := harness.Start(t)
+	body := map[string]any{
+		"user_id": "u1", "currency": "USD", "settlement_currency": "EUR", "idempotency_key": "checkout-fx",
+		"tax_jurisdiction": "US-OR", "items": []map[string]any{{"sku": "TSHIRT-M", "quantity": 1}},
+	}
+	if resp, out := postOrder(t, h, body); resp.StatusCode != http.StatusOK || out.OrderStatus != "PAID" {
+		t.Fatalf("POST /orders settled in EUR: %d %+v, want 200 PAID", resp.StatusCode, out)
+	}
+
+	_, out := postOrder(t, h, body)
+	if got := getOrder(t, h, out.OrderID); got.AmountCents != 1299 || got.Currency != "USD" ||
+		got.SettlementCurrency != "EUR" || got.SettlementAmountCents != 1195 || got.FXRate != "0.92" || got.FXRateAsOf == "" ||
+		got.Settlement.Currency != "EUR" || got.Settlement.MinorUnits != 1195 {
+		t.Errorf("GET /orders/{id} = %+v; want 1299 USD settled as 1195 EUR at 0.92", got)
+	}
+}
+
+func TestCheckout_RejectsCurrencies(t *testing.T) {
+	h := harness.Start(t)
+	for _, tt := range []struct {
+		currency, settlement, field string
+	}{
+		{"usd", "", "currency"},
+		{"USD", "XYZ", "settlement_currency"},
+		{"USD", "SEK", "settlement_currency"},
+	} {
+		body := order("checkout-currency-" + tt.currency + tt.settlement)
+		body["currency"], body["settlement_currency"] = tt.currency, tt.settlement
+		b, _ := json.Marshal(body)
+		resp, err := http.Post(h.GatewayURL+"/orders", "application/json", bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out struct {
+			Fields []struct {
+				Field string `json:"field"`
+			} `json:"fields"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest || len(out.Fields) != 1 || out.Fields[0].Field != tt.field {
+			t.Errorf("POST /orders in %s settled in %q: %d %+v, want 400 on %s", tt.currency, tt.settlement, resp.StatusCode, out, tt.field)
+		}
+	}
+}
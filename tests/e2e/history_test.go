@@ -0,0 +1,97 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/reliability-lab/pkg/platform/chaos"
+	"github.com/reliability-lab/tests/harness"
+)
+
+type historyChange struct {
+	Version   int64  `json:"version"`
+	OldStatus string `json:"old_status"`
+	NewStatus string `json:"new_status"`
+	Reason    string `json:"reason"`
+	Actor     string `json:"actor"`
+	Source    string `json:"source"`
+}
+
+// getHistory fetches GET /orders/{id}/history.
+func getHistory(t *testing.T, h *harness.Harness, id string) []historyChange {
+	t.Helper()
+	resp, err := http.Get(h.GatewayURL + "/orders/" + id + "/history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Changes []historyChange `json:"changes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /orders/{id}/history: %d %v", resp.StatusCode, err)
+	}
+	return out.Changes
+}
+
+func TestOrderHistory(t *testing.T) {
+	h := harness.Start(t)
+	setFault(t, h.Payments.Chaos, chaos.Fault{Target: "payments.charge", Error: "DECLINED"})
+	_, placed := postOrder(t, h, order("history"))
+
+	changes := getHistory(t, h, placed.OrderID)
+	if len(changes) != 2 {
+		t.Fatalf("history %+v, want created then failed", changes)
+	}
+	failed := changes[1]
+	if failed.Version != 2 || failed.OldStatus != "CREATED" || failed.NewStatus != "PAYMENT_FAILED" ||
+		failed.Reason != "DECLINED" || failed.Actor != "user:u1" || failed.Source != "gateway" {
+		t.Errorf("change %+v, want CREATED -> PAYMENT_FAILED for DECLINED by user:u1 through gateway", failed)
+	}
+
+	r, err := http.Get(h.GatewayURL + "/orders/00000000-0000-0000-0000-000000000000/history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != http.StatusNotFound {
+		t.Errorf("history of an unknown order: %d, want 404", r.StatusCode)
+	}
+}
+
+// TestOrderHistory_Actor cancels orders with an X-Actor header: only an
+// operator may name who the cancel is for, everyone else is recorded as who
+// they authenticate as.
+func TestOrderHistory_Actor(t *testing.T) {
+	h := harness.Start(t, harness.WithAuthTokens("op-token=operator:alice", "u7-token=user:u7"))
+	for i, tc := range []struct {
+		name, token, want string
+	}{
+		{"anonymous", "", "anonymous"},
+		{"unknown token", "forged", "anonymous"},
+		{"user", "u7-token", "user:u7"},
+		{"operator", "op-token", "user:u1"},
+	} {
+		_, placed := postOrder(t, h, order(fmt.Sprintf("history-actor-%d", i)))
+		req, _ := http.NewRequest(http.MethodPost, h.GatewayURL+"/orders/"+placed.OrderID+"/cancel", strings.NewReader(`{"reason":"OTHER"}`))
+		req.Header.Set("X-Actor", "user:u1")
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: cancel: %d", tc.name, resp.StatusCode)
+		}
+		changes := getHistory(t, h, placed.OrderID)
+		if last := changes[len(changes)-1]; last.NewStatus != "CANCELLED" || last.Actor != tc.want {
+			t.Errorf("%s: change %+v, want CANCELLED by %s", tc.name, last, tc.want)
+		}
+	}
+}
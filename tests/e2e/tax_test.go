@@ -0,0 +1,37 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/reliability-lab/tests/harness"
+)
+
+func TestCheckout_Taxes(t *testing.T) {
+	h := harness.Start(t)
+	body := map[string]any{
+		"user_id": "u1", "currency": "USD", "tax_jurisdiction": "US-CA", "idempotency_key": "checkout-tax",
+		"items": []map[string]any{{"sku": "TSHIRT-M", "quantity": 2}, {"sku": "MUG-350", "quantity": 1}},
+	}
+	resp, out := postOrder(t, h, body)
+	if resp.StatusCode != http.StatusOK || out.OrderStatus != "PAID" {
+		t.Fatalf("POST /orders taxed in US-CA: %d %+v, want 200 PAID", resp.StatusCode, out)
+	}
+
+	// 7.25% of 34.97.
+	if got := getOrder(t, h, out.OrderID); got.Subtotal.MinorUnits != 3497 || got.Tax.MinorUnits != 254 ||
+		got.Total.MinorUnits != 3751 || got.TaxJurisdiction != "US-CA" || got.TaxRulesVersion == "" || len(got.Taxes) != 1 || got.Taxes[0].Rate != "0.0725" {
+		t.Errorf("GET /orders/{id} = %+v; want 34.97 plus 2.54 tax", got)
+	}
+
+	body["idempotency_key"], body["tax_jurisdiction"] = "checkout-tax-unknown", "XX"
+	if resp, _ := postOrder(t, h, body); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /orders taxed in XX: %d, want 400", resp.StatusCode)
+	}
+	// Leaving it out must not skip tax.
+	body["idempotency_key"] = "checkout-tax-missing"
+	delete(body, "tax_jurisdiction")
+	if resp, _ := postOrder(t, h, body); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /orders without tax_jurisdiction: %d, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,37 @@
+module github.com/reliability-lab/tests
+
+go 1.22
+
+require (
+	github.com/reliability-lab/gen v0.0.0
+	github.com/reliability-lab/pkg/money v0.0.0
+	github.com/reliability-lab/pkg/platform v0.0.0
+	github.com/reliability-lab/services/catalog v0.0.0
+	github.com/reliability-lab/services/gateway v0.0.0
+	github.com/reliability-lab/services/inventory v0.0.0
+	github.com/reliability-lab/services/notifications v0.0.0
+	github.com/reliability-lab/services/orders v0.0.0
+	github.com/reliability-lab/services/payments v0.0.0
+	github.com/fergusstrange/embedded-postgres v1.25.0
+	github.com/jackc/pgx/v5 v5.5.2
+	github.com/rs/zerolog v1.32.0
+	google.golang.org/grpc v1.62.0
+)
+
+replace github.com/reliability-lab/gen => ../gen
+
+replace github.com/reliability-lab/pkg/money => ../pkg/money
+
+replace github.com/reliability-lab/pkg/platform => ../pkg/platform
+
+replace github.com/reliability-lab/services/catalog => ../services/catalog
+
+replace github.com/reliability-lab/services/gateway => ../services/gateway
+
+replace github.com/reliability-lab/services/inventory => ../services/inventory
+
+replace github.com/reliability-lab/services/notifications => ../services/notifications
+
+replace github.com/reliability-lab/services/orders => ../services/orders
+
+replace github.com/reliability-lab/services/payments => ../services/payments
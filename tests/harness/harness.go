@@ -0,0 +1,245 @@
+// Package harness runs the gateway, orders, payments, notifications, catalog
+// and inventory in one process for end-to-end tests. The services talk over
+// in-memory bufconn listeners and the gateway is served by httptest, so no
+// Docker or ports are needed.
+package harness
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reliability-lab/gen/catalog"
+	"github.com/reliability-lab/gen/inventory"
+	"github.com/reliability-lab/gen/notifications"
+	"github.com/reliability-lab/gen/orders"
+	"github.com/reliability-lab/gen/payments"
+	"github.com/reliability-lab/pkg/platform"
+	"github.com/reliability-lab/services/catalog/catalogsvc"
+	"github.com/reliability-lab/services/gateway/gatewaysvc"
+	"github.com/reliability-lab/services/inventory/inventorysvc"
+	"github.com/reliability-lab/services/notifications/notificationsvc"
+	"github.com/reliability-lab/services/orders/ordersvc"
+	"github.com/reliability-lab/services/payments/paymentsvc"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Store selects the orders repository.
+type Store string
+
+const (
+	// StoreMemory keeps orders in process memory.
+	StoreMemory Store = "memory"
+	// StorePostgres runs an embedded Postgres per harness. Its binaries are
+	// downloaded on first use and cached in ~/.embedded-postgres-go; initdb
+	// refuses to run as root.
+	StorePostgres Store = "postgres"
+)
+
+// StoreEnv sets the default store, e.g. HARNESS_ORDERS_STORE=postgres to run
+// a suite against Postgres without changing the tests.
+const StoreEnv = "HARNESS_ORDERS_STORE"
+
+const bufSize = 1 << 20
+
+var logOnce sync.Once
+
+// setupLogging sets the logger the services share: warnings and up on
+// stderr, or LOG_LEVEL.
+func setupLogging() {
+	logOnce.Do(func() {
+		level, err := zerolog.ParseLevel(os.Getenv("LOG_LEVEL"))
+		if err != nil || level == zerolog.NoLevel {
+			level = zerolog.WarnLevel
+		}
+		log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).Level(level).With().Timestamp().Logger()
+	})
+}
+
+// Option configures Start.
+type Option func(*options)
+
+type options struct {
+	store      Store
+	chaosSeed  int64
+	authTokens []string
+}
+
+// WithStore overrides StoreEnv.
+func WithStore(s Store) Option {
+	return func(o *options) { o.store = s }
+}
+
+// WithChaosSeed makes fault decisions reproducible; see platform.Config.ChaosSeed.
+func WithChaosSeed(seed int64) Option {
+	return func(o *options) { o.chaosSeed = seed }
+}
+
+// WithAuthTokens configures the "token=principal" pairs every service
+// accepts; see platform.Config.AuthTokens.
+func WithAuthTokens(pairs ...string) Option {
+	return func(o *options) { o.authTokens = pairs }
+}
+
+// Harness is a running stack. Set faults on a service's Chaos registry.
+type Harness struct {
+	// GatewayURL is the base URL of the gateway's HTTP API.
+	GatewayURL string
+	// Store is the orders repository in use.
+	Store Store
+
+	Gateway       *platform.Service
+	Orders        *platform.Service
+	Payments      *platform.Service
+	Notifications *platform.Service
+	Catalog       *platform.Service
+	// Inventory keeps stock in memory, seeded with the built-in stock.
+	Inventory *platform.Service
+
+	// OrdersClient talks to orders directly, bypassing the gateway.
+	OrdersClient orders.OrdersClient
+	// InventoryClient talks to inventory directly, e.g. to set stock.
+	InventoryClient inventory.InventoryClient
+}
+
+// Start runs the stack until the test ends. It fails the test if a service
+// does not start.
+func Start(t testing.TB, opts ...Option) *Harness {
+	t.Helper()
+	o := options{store: StoreMemory}
+	if s := os.Getenv(StoreEnv); s != "" {
+		o.store = Store(s)
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	setupLogging()
+	cfg := platform.DefaultConfig()
+	cfg.ChaosSeed = o.chaosSeed
+	cfg.AuthTokens = o.authTokens
+	cfg.ShutdownTimeout = 5 * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Harness{Store: o.store}
+	var stopped []chan error
+	t.Cleanup(func() {
+		cancel()
+		for _, done := range stopped {
+			if err := <-done; err != nil {
+				t.Errorf("harness shutdown: %v", err)
+			}
+		}
+	})
+
+	// run serves a backend on a bufconn listener until the test ends.
+	run := func(name string, register func(*platform.Service)) (*platform.Service, *bufconn.Listener) {
+		lis := bufconn.Listen(bufSize)
+		svc := platform.New(ctx, name, platform.WithConfig(cfg), platform.WithoutTelemetry(), platform.WithoutLogging(), platform.WithGRPCListener(lis))
+		register(svc)
+		done := make(chan error, 1)
+		stopped = append(stopped, done)
+		go func() { done <- svc.Run(ctx) }()
+		return svc, lis
+	}
+
+	dial := func(lis *bufconn.Listener, opts []grpc.DialOption) *grpc.ClientConn {
+		conn, err := grpc.Dial("passthrough:///bufnet", append(opts,
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }))...)
+		if err != nil {
+			t.Fatalf("harness: dial: %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn
+	}
+
+	var ordersLis, paymentsLis, notificationsLis, catalogLis, inventoryLis *bufconn.Listener
+	h.Catalog, catalogLis = run("catalog", func(svc *platform.Service) {
+		catalog.RegisterCatalogServer(svc.GRPC, catalogsvc.NewServer(catalogsvc.Default()))
+	})
+	h.Inventory, inventoryLis = run("inventory", func(svc *platform.Service) {
+		store := inventorysvc.NewMemoryStore()
+		if err := inventorysvc.Seed(ctx, store, inventorysvc.DefaultStock()); err != nil {
+			t.Fatalf("harness: seed inventory: %v", err)
+		}
+		srv := inventorysvc.NewServer(store)
+		svc.OnClose("hold sweeper", func(context.Context) error {
+			srv.Close()
+			return nil
+		})
+		inventory.RegisterInventoryServer(svc.GRPC, srv)
+	})
+	h.Payments, paymentsLis = run("payments", func(svc *platform.Service) {
+		payments.RegisterPaymentsServer(svc.GRPC, paymentsvc.NewServer(paymentsvc.NewMemoryStore(), svc.Chaos))
+	})
+	h.Orders, ordersLis = run("orders", func(svc *platform.Service) {
+		catalogClient := catalog.NewCatalogClient(dial(catalogLis, svc.DialOptions()))
+		paymentsClient := payments.NewPaymentsClient(dial(paymentsLis, svc.DialOptions()))
+		inventoryClient := inventory.NewInventoryClient(dial(inventoryLis, svc.DialOptions()))
+		srv := ordersvc.NewServer(newOrderRepository(t, svc, o.store), ordersvc.WithCatalog(catalogClient), ordersvc.WithPayments(paymentsClient),
+			ordersvc.WithInventory(inventoryClient), ordersvc.WithFX(ordersvc.DefaultRates()), ordersvc.WithPromotions(ordersvc.DefaultPromotions()),
+			ordersvc.WithTax(ordersvc.DefaultTaxRules()))
+		svc.OnStop("order events", func(context.Context) error {
+			srv.Close()
+			return nil
+		})
+		orders.RegisterOrdersServer(svc.GRPC, srv)
+	})
+	h.Notifications, notificationsLis = run("notifications", func(svc *platform.Service) {
+		notifications.RegisterNotificationsServer(svc.GRPC, &notificationsvc.Server{})
+	})
+
+	h.Gateway = platform.New(ctx, "gateway", platform.WithConfig(cfg), platform.WithoutTelemetry(), platform.WithoutLogging())
+	gwOpts := h.Gateway.DialOptions()
+	h.OrdersClient = orders.NewOrdersClient(dial(ordersLis, gwOpts))
+	h.InventoryClient = inventory.NewInventoryClient(dial(inventoryLis, gwOpts))
+	gw, err := gatewaysvc.New(ctx, gatewaysvc.Config{
+		Orders:         h.OrdersClient,
+		Payments:       payments.NewPaymentsClient(dial(paymentsLis, gwOpts)),
+		Notifications:  notifications.NewNotificationsClient(dial(notificationsLis, gwOpts)),
+		Catalog:        catalog.NewCatalogClient(dial(catalogLis, gwOpts)),
+		Inventory:      h.InventoryClient,
+		Admin:          h.Gateway.Admin,
+		Auth:           h.Gateway.Auth,
+		IdempotencyTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("harness: gateway: %v", err)
+	}
+	srv := httptest.NewServer(gw)
+	// Cleanups run last-in first-out: this one runs before the connections
+	// close and the services stop.
+	t.Cleanup(func() {
+		gw.StopStreams()
+		srv.Close()
+		drainCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		_ = gw.WaitStreams(drainCtx)
+		if err := gw.WaitBackground(drainCtx); err != nil {
+			t.Errorf("harness: async orders still settling: %v", err)
+		}
+	})
+	h.GatewayURL = srv.URL
+	return h
+}
+
+// newOrderRepository returns the orders store. Postgres is stopped when svc
+// shuts down, after the orders server.
+func newOrderRepository(t testing.TB, svc *platform.Service, s Store) ordersvc.OrderRepository {
+	switch s {
+	case StoreMemory:
+		return ordersvc.NewMemoryRepository()
+	case StorePostgres:
+		return ordersvc.NewPostgresRepository(startPostgres(t, svc), svc.Chaos)
+	default:
+		t.Fatalf("harness: unknown orders store %q (want %s or %s)", s, StoreMemory, StorePostgres)
+		return nil
+	}
+}